@@ -3,20 +3,26 @@
 # Build script for ZUGFeRD XML Extractor
 echo "Building ZUGFeRD XML Extractor..."
 
+# Build information shown by "zugferd-extractor version"
+VERSION=$(git describe --tags --always --dirty 2>/dev/null || echo dev)
+COMMIT=$(git rev-parse HEAD 2>/dev/null)
+DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+LDFLAGS="-X main.version=${VERSION} -X main.commit=${COMMIT} -X main.date=${DATE}"
+
 # Create build directory
 mkdir -p build
 
 # Build for different platforms
 echo "Building for Windows (amd64)..."
-GOOS=windows GOARCH=amd64 go build -o build/zugferd-extractor.exe ./cmd/zugferd-extractor
+GOOS=windows GOARCH=amd64 go build -ldflags "$LDFLAGS" -o build/zugferd-extractor.exe ./cmd/zugferd-extractor
 
 echo "Building for Linux (amd64)..."
-GOOS=linux GOARCH=amd64 go build -o build/zugferd-extractor-linux ./cmd/zugferd-extractor
+GOOS=linux GOARCH=amd64 go build -ldflags "$LDFLAGS" -o build/zugferd-extractor-linux ./cmd/zugferd-extractor
 
 echo "Building for macOS (amd64)..."
-GOOS=darwin GOARCH=amd64 go build -o build/zugferd-extractor-macos ./cmd/zugferd-extractor
+GOOS=darwin GOARCH=amd64 go build -ldflags "$LDFLAGS" -o build/zugferd-extractor-macos ./cmd/zugferd-extractor
 
 echo "Building for macOS (arm64)..."
-GOOS=darwin GOARCH=arm64 go build -o build/zugferd-extractor-macos-arm64 ./cmd/zugferd-extractor
+GOOS=darwin GOARCH=arm64 go build -ldflags "$LDFLAGS" -o build/zugferd-extractor-macos-arm64 ./cmd/zugferd-extractor
 
 echo "Build complete! Binaries are in the 'build' directory."
\ No newline at end of file
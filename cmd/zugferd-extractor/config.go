@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// configFilenames are the names of the config file, in order of preference
+var configFilenames = []string{"zugferd.yaml", ".zugferdrc"}
+
+// configAliases maps the long option names accepted in a config file to
+// the flag they set; the flag names themselves are accepted as well
+var configAliases = map[string]string{
+	"output":    "o",
+	"verbose":   "v",
+	"quiet":     "q",
+	"recursive": "r",
+}
+
+// configExclusive maps an option to the one it excludes: -q on the command
+// line also overrides verbose in a config file and vice versa
+var configExclusive = map[string]string{
+	"q": "v",
+	"v": "q",
+}
+
+// configEntry is a single option of a config file
+type configEntry struct {
+	name  string
+	value string
+	line  int
+}
+
+// configFile is a parsed config file
+type configFile struct {
+	path    string
+	entries []configEntry
+}
+
+// findConfigFiles returns the config files of the home directory and of
+// the working directory, in this order, so the options of the working
+// directory override those of the home directory. Per directory the first
+// existing name of configFilenames is used.
+func findConfigFiles() []string {
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, home)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, cwd)
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		for _, name := range configFilenames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				if !seen[path] {
+					seen[path] = true
+					paths = append(paths, path)
+				}
+				break
+			}
+		}
+	}
+	return paths
+}
+
+// parseConfig reads a config file. Every line sets one option, either as
+// "name: value" like a flat YAML mapping or as "name = value"; empty lines
+// and lines starting with # are ignored and values may be quoted.
+func parseConfig(path, lang string) (configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return configFile{}, i18n.Errorf(lang, "Konfigurationsdatei konnte nicht gelesen werden: %v", err)
+	}
+
+	config := configFile{path: path}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+
+		separator := strings.IndexAny(line, ":=")
+		if separator < 0 {
+			return configFile{}, i18n.Errorf(lang, "%s, Zeile %d: erwartet wird name: wert", path, number)
+		}
+		name := strings.TrimSpace(line[:separator])
+		value := unquoteConfigValue(strings.TrimSpace(line[separator+1:]))
+		if alias, ok := configAliases[name]; ok {
+			name = alias
+		}
+		config.entries = append(config.entries, configEntry{name: name, value: value, line: number})
+	}
+	if err := scanner.Err(); err != nil {
+		return configFile{}, i18n.Errorf(lang, "Konfigurationsdatei konnte nicht gelesen werden: %v", err)
+	}
+	return config, nil
+}
+
+// unquoteConfigValue removes matching single or double quotes; an
+// unquoted value ends at a comment
+func unquoteConfigValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	if comment := strings.Index(value, " #"); comment >= 0 {
+		value = strings.TrimSpace(value[:comment])
+	}
+	return value
+}
+
+// configurable reports whether an option may be set in a config file; the
+// options that select an action instead of a default may not
+func configurable(name string) bool {
+	switch name {
+	case "h", "version", "no-config":
+		return false
+	}
+	return true
+}
+
+// applyConfig sets the options of the config files as defaults: an option
+// given on the command line is never overridden. It returns the paths of
+// the config files that were read.
+func applyConfig(flags *flag.FlagSet, lang string) ([]string, error) {
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		name := f.Name
+		if alias, ok := configAliases[name]; ok {
+			name = alias
+		}
+		explicit[name] = true
+		if other, ok := configExclusive[name]; ok {
+			explicit[other] = true
+		}
+	})
+
+	paths := findConfigFiles()
+	for _, path := range paths {
+		config, err := parseConfig(path, lang)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range config.entries {
+			if flags.Lookup(entry.name) == nil || !configurable(entry.name) {
+				return nil, i18n.Errorf(lang, "%s, Zeile %d: unbekannte Option %s", path, entry.line, entry.name)
+			}
+			if explicit[entry.name] {
+				continue
+			}
+			if err := flags.Set(entry.name, entry.value); err != nil {
+				return nil, i18n.Errorf(lang, "%s, Zeile %d: ungültiger Wert für %s: %v", path, entry.line, entry.name, err)
+			}
+		}
+	}
+	return paths, nil
+}
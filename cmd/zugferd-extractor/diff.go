@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"zugferd-extractor/internal/extractor"
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/invoice"
+)
+
+// runDiff implements the diff subcommand and returns the exit code: like
+// diff(1) exitOK without and exitFailure with differences
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	jsonPtr := flags.Bool("json", false, "Unterschiede als JSON ausgeben")
+	passwordPtr := flags.String("password", "", "Passwort für verschlüsselte PDF-Dateien")
+	langPtr := flags.String("lang", "", "Sprache der Meldungen (de, en; Standard: $ZUGFERD_LANG oder de)")
+	helpPtr := flags.Bool("h", false, "Hilfe anzeigen")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	lang := i18n.Resolve(*langPtr)
+
+	if *helpPtr || flags.NArg() != 2 {
+		printDiffUsage(lang)
+		if *helpPtr {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	var invoices [2]*invoice.Invoice
+	for i, input := range flags.Args() {
+		zugferd := &extractor.ZUGFeRDExtractor{InputPath: input, Password: *passwordPtr, Lang: lang}
+		if extractor.IsURL(input) {
+			if err := zugferd.LoadURL(context.Background(), input); err != nil {
+				fatalf(exitCode(err), i18n.T(lang, "Fehler beim Herunterladen der PDF: %v"), err)
+			}
+		}
+		inv, err := zugferd.ExtractInvoice()
+		if err != nil {
+			fatalf(exitCode(err), i18n.T(lang, "%s: Fehler beim Extrahieren der Rechnungsdaten: %v"), input, err)
+		}
+		invoices[i] = inv
+	}
+
+	diffs := invoice.Diff(invoices[0], invoices[1])
+	if *jsonPtr {
+		if diffs == nil {
+			diffs = []invoice.Difference{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diffs); err != nil {
+			fatalf(exitIO, i18n.T(lang, "Fehler beim Schreiben der JSON-Ausgabe: %v"), err)
+		}
+	} else if len(diffs) == 0 {
+		fmt.Println(i18n.T(lang, "Keine Unterschiede"))
+	} else {
+		fmt.Printf("--- %s\n+++ %s\n", flags.Arg(0), flags.Arg(1))
+		for _, diff := range diffs {
+			fmt.Println(diff)
+		}
+	}
+
+	if len(diffs) > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func printDiffUsage(lang string) {
+	fmt.Println(i18n.T(lang, "Verwendung: zugferd-extractor diff [optionen] <alt.pdf> <neu.pdf>"))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Vergleicht die Rechnungsdaten zweier ZUGFeRD-Rechnungen Feld für Feld."))
+	fmt.Println(i18n.T(lang, "Positionen werden über ihre Positionsnummer, Steuergruppen über Kategorie und"))
+	fmt.Println(i18n.T(lang, "Steuersatz zugeordnet. Exit-Code 0 ohne, 1 mit Unterschieden."))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Optionen:"))
+	fmt.Println(i18n.T(lang, "  -json      Unterschiede als JSON ausgeben"))
+	fmt.Println(i18n.T(lang, "  -password <passwort>  Passwort für verschlüsselte PDF-Dateien"))
+	fmt.Println(i18n.T(lang, "  -lang <code>  Sprache der Meldungen: de oder en (Standard: $ZUGFERD_LANG, sonst de)"))
+	fmt.Println(i18n.T(lang, "  -h         Diese Hilfe anzeigen"))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Beispiele:"))
+	fmt.Println("  zugferd-extractor diff rechnung.pdf rechnungskorrektur.pdf")
+	fmt.Println("  zugferd-extractor diff -json rechnung.pdf rechnungskorrektur.pdf")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"os"
+
+	"zugferd-extractor/internal/extractor"
+)
+
+// Exit codes, documented in the usage output
+const (
+	exitOK = 0
+	// exitFailure covers all errors without a code of their own, e.g. an
+	// unparsable PDF or business rule violations
+	exitFailure = 1
+	// exitNoXML means the PDF contains no ZUGFeRD XML
+	exitNoXML = 2
+	// exitIO means a file could not be read or written
+	exitIO = 3
+	// exitUsage means invalid command line arguments
+	exitUsage = 4
+)
+
+// exitCode maps the kind of an extraction error to its exit code
+func exitCode(err error) int {
+	var extractErr *extractor.ExtractError
+	if !errors.As(err, &extractErr) {
+		return exitFailure
+	}
+	switch extractErr.Kind {
+	case extractor.ErrNoAttachments, extractor.ErrNoZUGFeRDXML:
+		return exitNoXML
+	case extractor.ErrIO:
+		return exitIO
+	}
+	return exitFailure
+}
+
+// parseFlags parses the arguments of a subcommand like main parses its
+// own: -help ends with exitOK and an invalid argument with exitUsage
+// instead of the exit code 2 of the flag package, which is exitNoXML. It
+// returns false with the exit code if the subcommand must not continue.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// exitHooks run before fatalf exits, since os.Exit skips deferred calls
+var exitHooks []func()
+
+// fatalf logs the message like log.Fatalf but exits with code
+func fatalf(code int, format string, args ...any) {
+	log.Printf(format, args...)
+	for _, hook := range exitHooks {
+		hook()
+	}
+	os.Exit(code)
+}
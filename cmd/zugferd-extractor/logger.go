@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"zugferd-extractor/internal/extractor"
+)
+
+// Log formats of the -log-format option
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// textHandler prints log messages as plain lines, like the verbose output
+// of earlier versions. Warnings and errors always go to stderr, colored
+// like the status lines. The structured attributes of the extractor events
+// are left to the JSON format, the message already names what they
+// contain.
+type textHandler struct {
+	mu    *sync.Mutex
+	out   io.Writer
+	level slog.Level
+}
+
+// newTextLogger creates the CLI logger; debug messages are only shown in
+// verbose mode, quiet mode shows nothing but errors
+func newTextLogger(out io.Writer, verbose, quiet bool) *slog.Logger {
+	return slog.New(&textHandler{mu: &sync.Mutex{}, out: out, level: logLevel(verbose, quiet)})
+}
+
+// newJSONLogger creates the logger of -log-format json, which writes one
+// JSON object per event to stderr. It records the complete trace, as if in
+// verbose mode, since it is meant for analysis rather than reading; quiet
+// mode still shows nothing but errors. The indentation of the verbose
+// messages is dropped.
+func newJSONLogger(quiet bool) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+		Level: logLevel(true, quiet),
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.MessageKey {
+				attr.Value = slog.StringValue(strings.TrimSpace(attr.Value.String()))
+			}
+			return attr
+		},
+	}))
+}
+
+// logLevel returns the minimum level shown in verbose or quiet mode
+func logLevel(verbose, quiet bool) slog.Level {
+	switch {
+	case quiet:
+		return slog.LevelError
+	case verbose:
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *textHandler) Handle(_ context.Context, record slog.Record) error {
+	out := h.out
+	if record.Level >= slog.LevelWarn {
+		out = os.Stderr
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case record.Level >= slog.LevelError:
+		extractor.WriteStatus(out, extractor.StatusFailure, record.Message+"\n")
+	case record.Level >= slog.LevelWarn:
+		extractor.WriteStatus(out, extractor.StatusWarning, record.Message+"\n")
+	default:
+		_, err := fmt.Fprintln(out, record.Message)
+		return err
+	}
+	return nil
+}
+
+// The text format drops attributes and groups on purpose, see textHandler;
+// the JSON format keeps them
+func (h *textHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *textHandler) WithGroup(string) slog.Handler { return h }
@@ -1,116 +1,851 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"zugferd-extractor/internal/extractor"
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/invoice"
 )
 
 func main() {
-	// Kommandozeilenargumente definieren
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "embed":
+			os.Exit(runEmbed(os.Args[2:]))
+		case "selftest":
+			os.Exit(runSelftest(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "watch":
+			os.Exit(runWatch(os.Args[2:]))
+		case "version":
+			runVersion(os.Args[2:])
+			return
+		}
+	}
+
+	// Kommandozeilenargumente definieren; ungültige Argumente enden mit
+	// exitUsage statt mit dem Exit-Code 2 des flag-Pakets
+	flag.CommandLine.Init(os.Args[0], flag.ContinueOnError)
 	verbosePtr := flag.Bool("v", false, "Ausführliche Ausgabe")
+	logFormatPtr := flag.String("log-format", logFormatText, "Format der Protokollausgabe: text oder json")
+	quietPtr := flag.Bool("q", false, "Nur Fehler ausgeben")
+	flag.BoolVar(quietPtr, "quiet", false, "Nur Fehler ausgeben")
 	outputPtr := flag.String("o", "", "Ausgabepfad für die XML-Datei")
 	helpPtr := flag.Bool("h", false, "Hilfe anzeigen")
-	flag.Parse()
+	versionPtr := flag.Bool("version", false, "Version anzeigen")
+	validatePtr := flag.Bool("validate", false, "EN16931-Geschäftsregeln prüfen")
+	xsdPtr := flag.Bool("xsd", false, "Elementstruktur der XML gegen das vereinfachte Schema ihrer Version prüfen")
+	xsdDirPtr := flag.String("xsd-dir", "", "Verzeichnis mit eigenen XSD-Dateien statt der vereinfachten mitgelieferten (aktiviert -xsd)")
+	leitwegIDPtr := flag.Bool("check-leitweg-id", false, "Käuferreferenz von XRechnungen als Leitweg-ID prüfen (aktiviert -validate)")
+	jsonPtr := flag.Bool("json", false, "Rechnungsdaten als JSON ausgeben")
+	base64Ptr := flag.Bool("base64", false, "Extrahierte XML base64-kodiert nach stdout ausgeben")
+	jsonlPtr := flag.Bool("jsonl", false, "Rechnungsdaten als JSON-Zeilen (JSONL) ausgeben")
+	allPtr := flag.Bool("all", false, "Alle eingebetteten Dateien extrahieren")
+	multiPtr := flag.Bool("multi", false, "Alle ZUGFeRD-XML-Anhänge einer Sammel-PDF in nummerierte Dateien extrahieren")
+	recursivePtr := flag.Bool("r", false, "Verzeichnisse rekursiv durchsuchen")
+	flag.BoolVar(recursivePtr, "recursive", false, "Verzeichnisse rekursiv durchsuchen")
+	workersPtr := flag.Int("workers", 0, "Anzahl paralleler Worker (0 = automatisch)")
+	skipExistingPtr := flag.Bool("skip-existing", false, "Dateien mit aktueller XML-Ausgabe überspringen")
+	minConfidencePtr := flag.Int("min-confidence", 0, "Mindestanzahl erkannter ZUGFeRD-Indikatoren einer XML")
+	noClobberPtr := flag.Bool("no-clobber", false, "Vorhandene Ausgabedateien nicht überschreiben")
+	onCollisionPtr := flag.String("on-collision", "rename", "Gleiche Ausgabepfade im Batch: rename, error oder overwrite")
+	dedupePtr := flag.Bool("dedupe", false, "Byte-identische PDF-Dateien nur einmal verarbeiten")
+	dryRunPtr := flag.Bool("dry-run", false, "Nur anzeigen, was extrahiert würde, ohne Dateien zu schreiben")
+	pdfMetadataPtr := flag.Bool("pdf-metadata", false, "Seitenzahl und Dokumentinformationen der PDF ausgeben")
+	timingsPtr := flag.Bool("timings", false, "Dauer der Extraktionsphasen messen und ausgeben")
+	checksumPtr := flag.String("checksum", "", "Prüfsumme der extrahierten XML ausgeben (sha256, sha1)")
+	prettyPtr := flag.Bool("pretty", false, "Extrahierte XML mit zwei Leerzeichen einrücken")
+	strictMimePtr := flag.Bool("strict-mime", false, "Fehler statt Warnung, wenn die XML keinen XML-MIME-Typ deklariert")
+	stripBOMPtr := flag.Bool("strip-bom", false, "UTF-8-BOM am Anfang der XML vor dem Speichern entfernen")
+	gzipPtr := flag.Bool("gzip", false, "Extrahierte XML gzip-komprimiert als .xml.gz speichern")
+	verifyVerbatimPtr := flag.Bool("verify-verbatim", false, "Geschriebene XML-Datei byte-genau mit der extrahierten XML vergleichen")
+	methodPtr := flag.String("method", "auto", "Extraktionsmethode: standard, relaxed, af, annotation, manual oder auto")
+	noManualPtr := flag.Bool("no-manual", false, "XML nie aus den PDF-Rohdaten rekonstruieren")
+	listPtr := flag.Bool("list", false, "Eingebettete Dateien auflisten statt XML zu extrahieren")
+	checkPDFAPtr := flag.Bool("check-pdfa", false, "PDF/A-3-Konformitätsangaben prüfen statt XML zu extrahieren")
+	langPtr := flag.String("lang", "", "Sprache der Meldungen (de, en; Standard: $ZUGFERD_LANG oder de)")
+	passwordPtr := flag.String("password", "", "Passwort für verschlüsselte PDF-Dateien")
+	timeoutPtr := flag.Duration("timeout", 0, "Maximale Dauer von Download und Extraktion pro Datei (z.B. 30s, 0 = unbegrenzt)")
+	tempDirPtr := flag.String("tmp", "", "Verzeichnis für temporäre Dateien (Standard: $TMPDIR)")
+	keepTempPtr := flag.Bool("keep-temp", false, "Temporäre Verzeichnisse zur Fehlersuche nicht löschen")
+	failFastPtr := flag.Bool("fail-fast", false, "Batch nach der ersten fehlgeschlagenen Datei abbrechen")
+	retriesPtr := flag.Int("retries", 0, "Anzahl Wiederholungen bei vorübergehenden E/A-Fehlern (nur Batch)")
+	retryBackoffPtr := flag.Duration("retry-backoff", 0, "Wartezeit vor der ersten Wiederholung, danach jeweils verdoppelt (0 = 500ms)")
+	reportPtr := flag.String("report", "", "CSV-Bericht der Batch-Verarbeitung schreiben")
+	combinePtr := flag.String("combine", "", "Alle extrahierten XML in eine gemeinsame Sammeldatei schreiben")
+	nameTemplatePtr := flag.String("name-template", "", "Vorlage für den Namen der XML-Datei, z.B. {invoiceNumber}_{date}.xml")
+	filenameMatchPtr := flag.String("filename-match", "", "Zusätzliche Dateinamen des XML-Anhangs, durch Kommas getrennt")
+	keepNamePtr := flag.Bool("keepname", false, "Originalen Dateinamen des XML-Anhangs beibehalten")
+	stdinPtr := flag.Bool("stdin", false, "PDF von der Standardeingabe lesen (wie Eingabe -)")
+	newerThanPtr := flag.String("newer-than", "", "Nur PDF-Dateien verarbeiten, die nach diesem Zeitpunkt geändert wurden")
+	sincePtr := flag.Duration("since", 0, "Nur PDF-Dateien verarbeiten, die in dieser Zeitspanne geändert wurden (z.B. 24h)")
+	fromFilePtr := flag.String("from-file", "", "Eingabepfade zeilenweise aus einer Datei lesen (- für stdin)")
+	noConfigPtr := flag.Bool("no-config", false, "Keine Konfigurationsdatei lesen")
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(exitOK)
+		}
+		os.Exit(exitUsage)
+	}
+
+	// Optionen der Konfigurationsdateien gelten nur, wenn sie nicht auf der
+	// Kommandozeile angegeben sind
+	var configFiles []string
+	if !*noConfigPtr {
+		var err error
+		if configFiles, err = applyConfig(flag.CommandLine, i18n.Resolve(*langPtr)); err != nil {
+			fatalf(exitUsage, i18n.T(i18n.Resolve(*langPtr), "Fehler in der Konfigurationsdatei: %v"), err)
+		}
+	}
+	lang := i18n.Resolve(*langPtr)
+
+	if *versionPtr {
+		printVersion(*jsonPtr, lang)
+		return
+	}
 
 	// Hilfe anzeigen, wenn angefordert oder keine Argumente vorhanden
-	if *helpPtr || flag.NArg() < 1 {
-		printUsage()
+	if *helpPtr || (flag.NArg() < 1 && !*stdinPtr && *fromFilePtr == "") {
+		printUsage(lang)
 		if *helpPtr {
-			os.Exit(0)
+			os.Exit(exitOK)
 		} else {
-			os.Exit(1)
+			os.Exit(exitUsage)
 		}
 	}
 
 	// Extractor konfigurieren
 	inputPattern := flag.Arg(0)
+	if *stdinPtr {
+		if flag.NArg() > 0 && inputPattern != extractor.StdinPath {
+			fatalf(exitUsage, i18n.T(lang, "-stdin und eine Eingabedatei schließen sich aus"))
+		}
+		inputPattern = extractor.StdinPath
+	}
+	fromFile := *fromFilePtr
+	if fromFile != "" && (flag.NArg() > 0 || *stdinPtr) {
+		fatalf(exitUsage, i18n.T(lang, "-from-file schließt eine Eingabedatei und -stdin aus"))
+	}
 	verbose := *verbosePtr
 	outputPath := *outputPtr
+	validateRules := *validatePtr || *leitwegIDPtr
+	validateSchema := *xsdPtr || *xsdDirPtr != ""
+	jsonOutput := *jsonPtr
+	allAttachments := *allPtr
+
+	// Eine PDF von stdin wird ohne -o nach stdout extrahiert
+	stdin := inputPattern == extractor.StdinPath
+	if stdin && outputPath == "" && !allAttachments && !*multiPtr {
+		outputPath = extractor.StdoutPath
+	}
+	keepName := *keepNamePtr
+	additionalFilenames := splitList(*filenameMatchPtr)
+
+	// Meldungen dürfen weder XML- noch JSON-Ausgabe auf stdout verfälschen
+	logOutput := os.Stdout
+	if outputPath == extractor.StdoutPath || jsonOutput || *jsonlPtr || *base64Ptr {
+		logOutput = os.Stderr
+	}
+	if verbose && *quietPtr {
+		fatalf(exitUsage, i18n.T(lang, "-q und -v schließen sich aus"))
+	}
+	var logger *slog.Logger
+	switch *logFormatPtr {
+	case logFormatText:
+		logger = newTextLogger(logOutput, verbose, *quietPtr)
+	case logFormatJSON:
+		logger = newJSONLogger(*quietPtr)
+	default:
+		fatalf(exitUsage, i18n.T(lang, "Unbekanntes Protokollformat: %s (erlaubt: text, json)"), *logFormatPtr)
+	}
+	for _, configFile := range configFiles {
+		logger.Debug(i18n.Sprintf(lang, "Konfiguration gelesen: %s", configFile))
+	}
 
-	// Prüfen, ob Batch-Verarbeitung oder einzelne Datei
-	files, err := filepath.Glob(inputPattern)
+	if *checksumPtr != "" && !extractor.IsChecksumAlgorithm(*checksumPtr) {
+		fatalf(exitUsage, i18n.T(lang, "Unbekannter Prüfsummen-Algorithmus: %s (erlaubt: sha256, sha1)"), *checksumPtr)
+	}
+
+	// Ein ZIP-Archiv wird immer als Batch über seine PDF-Einträge verarbeitet
+	archive := ""
+	if info, statErr := os.Stat(inputPattern); statErr == nil && !info.IsDir() && extractor.IsArchive(inputPattern) {
+		archive = inputPattern
+	}
+
+	if *verifyVerbatimPtr && *prettyPtr {
+		fatalf(exitUsage, i18n.T(lang, "-verify-verbatim und -pretty schließen sich aus"))
+	}
+	if *verifyVerbatimPtr && *stripBOMPtr {
+		fatalf(exitUsage, i18n.T(lang, "-verify-verbatim und -strip-bom schließen sich aus"))
+	}
+
+	if *tempDirPtr != "" {
+		if info, err := os.Stat(*tempDirPtr); err != nil {
+			fatalf(exitUsage, i18n.T(lang, "Temporäres Verzeichnis nicht verwendbar: %v"), err)
+		} else if !info.IsDir() {
+			fatalf(exitUsage, i18n.T(lang, "Temporäres Verzeichnis nicht verwendbar: %s ist kein Verzeichnis"), *tempDirPtr)
+		}
+	}
+	if *xsdDirPtr != "" {
+		if info, err := os.Stat(*xsdDirPtr); err != nil {
+			fatalf(exitUsage, i18n.T(lang, "XSD-Verzeichnis nicht verwendbar: %v"), err)
+		} else if !info.IsDir() {
+			fatalf(exitUsage, i18n.T(lang, "XSD-Verzeichnis nicht verwendbar: %s ist kein Verzeichnis"), *xsdDirPtr)
+		}
+	}
+
+	method, err := extractor.ParseMethod(*methodPtr, lang)
+	if err != nil {
+		fatalf(exitUsage, i18n.T(lang, "Unbekannte Extraktionsmethode: %s (erlaubt: standard, relaxed, af, annotation, manual, auto)"), *methodPtr)
+	}
+	if *noManualPtr && method == extractor.MethodManual {
+		fatalf(exitUsage, i18n.T(lang, "-no-manual und -method manual schließen sich aus"))
+	}
+	onCollision, err := extractor.ParseCollisionPolicy(*onCollisionPtr, lang)
 	if err != nil {
-		log.Fatalf("Fehler beim Suchen von Dateien: %v", err)
+		fatalf(exitUsage, i18n.T(lang, "Unbekannte Regel für Namenskonflikte: %s (erlaubt: rename, error, overwrite)"), *onCollisionPtr)
 	}
 
-	// Keine übereinstimmenden Dateien gefunden
-	if len(files) == 0 {
-		log.Fatalf("Keine Dateien gefunden, die dem Muster '%s' entsprechen", inputPattern)
+	// Der Zeitpunkt kommt entweder aus -newer-than oder aus -since
+	var newerThan time.Time
+	if *newerThanPtr != "" && *sincePtr != 0 {
+		fatalf(exitUsage, i18n.T(lang, "-newer-than und -since schließen sich aus"))
+	}
+	if *newerThanPtr != "" {
+		if newerThan, err = parseTimestamp(*newerThanPtr); err != nil {
+			fatalf(exitUsage, i18n.T(lang, "Ungültiger Zeitpunkt für -newer-than: %s (erwartet z.B. 2024-01-01 oder 2024-01-01T00:00:00Z)"), *newerThanPtr)
+		}
+	}
+	if *sincePtr < 0 {
+		fatalf(exitUsage, i18n.T(lang, "-since muss positiv sein: %s"), *sincePtr)
+	}
+	if *sincePtr > 0 {
+		newerThan = time.Now().Add(-*sincePtr)
+	}
+	if !newerThan.IsZero() && (stdin || extractor.IsURL(inputPattern)) {
+		fatalf(exitUsage, i18n.T(lang, "-newer-than und -since unterstützen keine Eingabe über stdin oder URLs"))
+	}
+
+	// Eine URL wird heruntergeladen und wie eine einzelne Datei verarbeitet
+	url := ""
+	if extractor.IsURL(inputPattern) {
+		if *listPtr || *checkPDFAPtr || *jsonlPtr {
+			fatalf(exitUsage, i18n.T(lang, "-list, -check-pdfa und -jsonl unterstützen keine URLs"))
+		}
+		url = inputPattern
+	}
+	if stdin {
+		if *listPtr || *checkPDFAPtr || *jsonlPtr {
+			fatalf(exitUsage, i18n.T(lang, "-list, -check-pdfa und -jsonl unterstützen keine Eingabe über stdin"))
+		}
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fatalf(exitUsage, i18n.T(lang, "Keine PDF auf stdin: die Eingabe muss umgeleitet werden, z.B. < rechnung.pdf"))
+		}
+	}
+
+	// Prüfen, ob Batch-Verarbeitung oder einzelne Datei; die Einträge eines
+	// Archivs liest der BatchProcessor selbst
+	var files []string
+	if fromFile != "" {
+		files = readFileList(fromFile, lang)
+	} else if url != "" || stdin {
+		files = []string{inputPattern}
+	} else if archive == "" {
+		var err error
+		if info, statErr := os.Stat(inputPattern); *recursivePtr && statErr == nil && info.IsDir() {
+			files, err = extractor.CollectPDFFiles(inputPattern, lang)
+		} else {
+			files, err = extractor.ExpandPattern(inputPattern)
+		}
+		if err != nil {
+			fatalf(exitUsage, i18n.T(lang, "Fehler beim Suchen von Dateien: %v"), err)
+		}
+
+		// Keine übereinstimmenden Dateien gefunden
+		if len(files) == 0 {
+			fatalf(exitIO, i18n.T(lang, "Keine Dateien gefunden, die dem Muster '%s' entsprechen"), inputPattern)
+		}
+	}
+
+	if archive != "" && (*listPtr || *checkPDFAPtr) {
+		fatalf(exitUsage, i18n.T(lang, "-list und -check-pdfa unterstützen keine ZIP-Archive"))
+	}
+
+	if *listPtr {
+		if !listAttachments(files, lang) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *checkPDFAPtr {
+		if !checkPDFA(files, lang, *passwordPtr) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if jsonOutput && *jsonlPtr {
+		fatalf(exitUsage, i18n.T(lang, "-json und -jsonl können nicht kombiniert werden"))
+	}
+	if *base64Ptr && allAttachments {
+		fatalf(exitUsage, i18n.T(lang, "-base64 und -all können nicht kombiniert werden"))
+	}
+	if *multiPtr {
+		if len(files) > 1 || *jsonlPtr || archive != "" || fromFile != "" || !newerThan.IsZero() {
+			fatalf(exitUsage, i18n.T(lang, "-multi ist nur bei einer einzelnen Datei möglich"))
+		}
+		if allAttachments || jsonOutput || *base64Ptr || outputPath == extractor.StdoutPath {
+			fatalf(exitUsage, i18n.T(lang, "-multi kann nicht mit -all, -json, -base64 oder -o - kombiniert werden"))
+		}
+	}
+
+	if *combinePtr != "" {
+		if outputPath != "" || jsonOutput || *jsonlPtr || *base64Ptr || allAttachments || *multiPtr {
+			fatalf(exitUsage, i18n.T(lang, "-combine kann nicht mit -o, -json, -jsonl, -base64, -all oder -multi kombiniert werden"))
+		}
 	}
 
-	// Batchverarbeitung für mehrere Dateien
-	if len(files) > 1 {
+	// Batchverarbeitung für mehrere Dateien; JSONL, eine Dateiliste, eine
+	// Sammeldatei und ein Zeitfilter werden auch bei einer einzelnen Datei
+	// als Batch verarbeitet
+	if len(files) > 1 || *jsonlPtr || archive != "" || fromFile != "" || *combinePtr != "" || !newerThan.IsZero() {
+		// Mehrere XML-Dateien lassen sich nicht sinnvoll nach stdout schreiben
+		if outputPath == extractor.StdoutPath {
+			fatalf(exitUsage, i18n.T(lang, "Ausgabe nach stdout ist nur bei einer einzelnen Datei möglich"))
+		}
+		if *base64Ptr && !jsonOutput && !*jsonlPtr {
+			fatalf(exitUsage, i18n.T(lang, "-base64 ist bei mehreren Dateien nur mit -json oder -jsonl möglich"))
+		}
+
 		// Wenn ein Ausgabepfad angegeben wurde, muss es ein Verzeichnis sein
 		if outputPath != "" {
 			info, err := os.Stat(outputPath)
 			if err != nil {
 				if os.IsNotExist(err) {
-					err = os.MkdirAll(outputPath, 0755)
+					// Im Probelauf werden keine Verzeichnisse angelegt
+					if *dryRunPtr {
+						err = nil
+					} else {
+						err = os.MkdirAll(outputPath, 0755)
+					}
 					if err != nil {
-						log.Fatalf("Fehler beim Erstellen des Ausgabeverzeichnisses: %v", err)
+						fatalf(exitIO, i18n.T(lang, "Fehler beim Erstellen des Ausgabeverzeichnisses: %v"), err)
 					}
 				} else {
-					log.Fatalf("Fehler beim Überprüfen des Ausgabepfads: %v", err)
+					fatalf(exitIO, i18n.T(lang, "Fehler beim Überprüfen des Ausgabepfads: %v"), err)
 				}
 			} else if !info.IsDir() {
-				log.Fatalf("Ausgabepfad muss ein Verzeichnis sein, wenn mehrere Dateien verarbeitet werden")
+				fatalf(exitUsage, i18n.T(lang, "Ausgabepfad muss ein Verzeichnis sein, wenn mehrere Dateien verarbeitet werden"))
 			}
 		}
 
-		// Anzahl der Worker basierend auf CPU-Kernen
-		numWorkers := runtime.NumCPU()
-		if numWorkers > len(files) {
+		// Anzahl der Worker, standardmäßig basierend auf CPU-Kernen
+		numWorkers := *workersPtr
+		if numWorkers <= 0 {
+			numWorkers = runtime.NumCPU()
+		}
+		if len(files) > 0 && numWorkers > len(files) {
 			numWorkers = len(files)
 		}
 
 		processor := &extractor.BatchProcessor{
-			InputPattern: inputPattern,
-			OutputDir:    outputPath,
-			Workers:      numWorkers,
-			Verbose:      verbose,
+			InputPattern:         inputPattern,
+			Files:                files,
+			Archive:              archive,
+			OutputDir:            outputPath,
+			Workers:              numWorkers,
+			Verbose:              verbose,
+			Quiet:                *quietPtr,
+			ValidateRules:        validateRules,
+			CheckLeitwegID:       *leitwegIDPtr,
+			ValidateSchema:       validateSchema,
+			SchemaDir:            *xsdDirPtr,
+			JSONOutput:           jsonOutput,
+			JSONLines:            *jsonlPtr,
+			XMLBase64:            *base64Ptr,
+			AllAttachments:       allAttachments,
+			PreserveOriginalName: keepName,
+			NameTemplate:         *nameTemplatePtr,
+			OnCollision:          onCollision,
+			AdditionalFilenames:  additionalFilenames,
+			ReportPath:           *reportPtr,
+			CombinePath:          *combinePtr,
+			NewerThan:            newerThan,
+			SkipExisting:         *skipExistingPtr,
+			NoClobber:            *noClobberPtr,
+			MinConfidence:        *minConfidencePtr,
+			NoManual:             *noManualPtr,
+			Dedupe:               *dedupePtr,
+			DryRun:               *dryRunPtr,
+			Checksum:             *checksumPtr,
+			IncludeMetadata:      *pdfMetadataPtr,
+			IncludeTimings:       *timingsPtr,
+			Pretty:               *prettyPtr,
+			StrictMimeType:       *strictMimePtr,
+			StripBOM:             *stripBOMPtr,
+			Gzip:                 *gzipPtr,
+			VerifyVerbatim:       *verifyVerbatimPtr,
+			Method:               method,
+			Timeout:              *timeoutPtr,
+			TempDir:              *tempDirPtr,
+			KeepTemp:             *keepTempPtr,
+			Retries:              *retriesPtr,
+			RetryBackoff:         *retryBackoffPtr,
+			FailFast:             *failFastPtr,
+			Password:             *passwordPtr,
+			Logger:               logger,
+			Lang:                 lang,
 		}
 
-		if err := processor.ProcessBatch(); err != nil {
-			log.Fatalf("Batch-Verarbeitungsfehler: %v", err)
+		// Ctrl+C stops the batch after the files in progress
+		ctx, stop := interruptContext(lang)
+		defer stop()
+		if err := processor.ProcessBatch(ctx); err != nil {
+			fatalf(exitCode(err), i18n.T(lang, "Batch-Verarbeitungsfehler: %v"), err)
 		}
 		return
 	}
 
+	// Ein vorhandenes Verzeichnis als -o nimmt die XML wie bei mehreren
+	// Dateien unter dem Namen der PDF auf, auch unter Windows etwa
+	// C:\Rechnungen\ oder eine Freigabe \\server\xml
+	outputDir := ""
+	if outputPath != "" && outputPath != extractor.StdoutPath && !allAttachments && !*multiPtr {
+		if info, err := os.Stat(outputPath); err == nil && info.IsDir() {
+			outputDir, outputPath = outputPath, ""
+		}
+	}
+
 	// Einzelne Datei verarbeiten
 	extractorObj := &extractor.ZUGFeRDExtractor{
-		InputPath:  files[0],
-		OutputPath: outputPath,
-		Verbose:    verbose,
+		InputPath:            files[0],
+		OutputPath:           outputPath,
+		OutputDir:            outputDir,
+		PreserveOriginalName: keepName,
+		NameTemplate:         *nameTemplatePtr,
+		AdditionalFilenames:  additionalFilenames,
+		Verbose:              verbose,
+		Quiet:                *quietPtr,
+		ValidateRules:        validateRules,
+		CheckLeitwegID:       *leitwegIDPtr,
+		ValidateSchema:       validateSchema,
+		SchemaDir:            *xsdDirPtr,
+		NoClobber:            *noClobberPtr,
+		MinConfidence:        *minConfidencePtr,
+		NoManual:             *noManualPtr,
+		DryRun:               *dryRunPtr,
+		Checksum:             *checksumPtr,
+		IncludeMetadata:      *pdfMetadataPtr,
+		IncludeTimings:       *timingsPtr,
+		Pretty:               *prettyPtr,
+		StrictMimeType:       *strictMimePtr,
+		StripBOM:             *stripBOMPtr,
+		Gzip:                 *gzipPtr,
+		VerifyVerbatim:       *verifyVerbatimPtr,
+		Method:               method,
+		Timeout:              *timeoutPtr,
+		TempDir:              *tempDirPtr,
+		KeepTemp:             *keepTempPtr,
+		Password:             *passwordPtr,
+		Logger:               logger,
+		Lang:                 lang,
+	}
+	if url != "" {
+		if err := extractorObj.LoadURL(context.Background(), url); err != nil {
+			fatalf(exitCode(err), i18n.T(lang, "Fehler beim Herunterladen der PDF: %v"), err)
+		}
+	}
+	if stdin {
+		cleanup, err := extractorObj.SpoolInput(os.Stdin)
+		if err != nil {
+			fatalf(exitCode(err), i18n.T(lang, "Fehler beim Lesen der PDF von stdin: %v"), err)
+		}
+		defer cleanup()
+		exitHooks = append(exitHooks, cleanup)
+	}
+
+	if allAttachments {
+		written, err := extractorObj.ExtractAllAttachments(outputPath)
+		if err != nil {
+			fatalf(exitCode(err), i18n.T(lang, "Fehler beim Extrahieren der Anhänge: %v"), err)
+		}
+		if !*quietPtr {
+			for name, path := range written {
+				statusf(extractor.StatusSuccess, "✓ %s -> %s\n", name, path)
+			}
+		}
+		return
+	}
+
+	if *multiPtr {
+		written, err := extractorObj.WriteAllXML(outputPath)
+		if err != nil {
+			fatalf(exitCode(err), i18n.T(lang, "Fehler beim Extrahieren von XML: %v"), err)
+		}
+		if !*quietPtr {
+			statusf(extractor.StatusSuccess, i18n.T(lang, "✓ %d ZUGFeRD-XML-Dateien gefunden\n"), len(written))
+			names := make([]string, 0, len(written))
+			for name := range written {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				statusf(extractor.StatusSuccess, "✓ %s -> %s\n", name, written[name])
+			}
+		}
+		return
+	}
+
+	// Die Prüfungen von -validate und -xsd laufen wie beim Schreiben einer
+	// Datei; ein Verstoß steht in der Ausgabe und bestimmt den Exit-Code
+	if jsonOutput {
+		checked, checkErr := extractorObj.ExtractCheckedXML(context.Background())
+		if checked.XML == nil {
+			fatalf(exitCode(checkErr), i18n.T(lang, "Fehler beim Extrahieren der Rechnungsdaten: %v"), checkErr)
+		}
+		inv, err := extractorObj.ParseInvoice(checked.XML)
+		if err != nil {
+			fatalf(exitCode(err), i18n.T(lang, "Fehler beim Extrahieren der Rechnungsdaten: %v"), err)
+		}
+		output := invoiceJSON{Invoice: inv, Violations: checked.Violations, Timings: extractorObj.Timings()}
+		if *base64Ptr {
+			output.XMLBase64 = base64.StdEncoding.EncodeToString(checked.Output)
+		}
+		if *pdfMetadataPtr {
+			if output.PDF, err = extractorObj.ReadPDFMetadata(); err != nil {
+				logger.Warn(i18n.Sprintf(lang, "Warnung: PDF-Metadaten konnten nicht gelesen werden: %v", err))
+			}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			fatalf(exitIO, i18n.T(lang, "Fehler beim Schreiben der JSON-Ausgabe: %v"), err)
+		}
+		if checkErr != nil {
+			fatalf(exitCode(checkErr), i18n.T(lang, "Fehler beim Extrahieren der Rechnungsdaten: %v"), checkErr)
+		}
+		return
+	}
+
+	if *base64Ptr {
+		checked, checkErr := extractorObj.ExtractCheckedXML(context.Background())
+		if checked.XML == nil {
+			fatalf(exitCode(checkErr), i18n.T(lang, "Fehler beim Extrahieren von XML: %v"), checkErr)
+		}
+		if _, err := fmt.Println(base64.StdEncoding.EncodeToString(checked.Output)); err != nil {
+			fatalf(exitIO, i18n.T(lang, "Fehler beim Schreiben der XML-Daten: %v"), err)
+		}
+		// stdout trägt die XML, die Verstöße gehen nach stderr
+		if violations := checked.Violations; violations != nil && !*quietPtr {
+			for _, violation := range violations.Rules {
+				fmt.Fprintf(os.Stderr, "  ⚠ %s\n", violation)
+			}
+		}
+		if checkErr != nil {
+			fatalf(exitCode(checkErr), i18n.T(lang, "Fehler beim Extrahieren von XML: %v"), checkErr)
+		}
+		return
 	}
 
 	if err := extractorObj.ExtractXML(); err != nil {
-		log.Fatalf("Fehler beim Extrahieren von XML: %v", err)
+		fatalf(exitCode(err), i18n.T(lang, "Fehler beim Extrahieren von XML: %v"), err)
+	}
+}
+
+// invoiceJSON is the -json output of a single file: the invoice fields and,
+// with -base64, the XML they were parsed from
+type invoiceJSON struct {
+	*invoice.Invoice
+	XMLBase64  string                 `json:"xmlBase64,omitempty"`
+	Violations *extractor.Violations  `json:"violations,omitempty"`
+	PDF        *extractor.PDFMetadata `json:"pdf,omitempty"`
+	Timings    *extractor.Timings     `json:"timings,omitempty"`
+}
+
+// splitList splits a comma-separated flag value and drops empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// readFileList reads the input paths of -from-file from path, or from stdin
+// for "-", and exits if the list cannot be read or is empty
+func readFileList(path, lang string) []string {
+	input := os.Stdin
+	if path != extractor.StdinPath {
+		file, err := os.Open(path)
+		if err != nil {
+			fatalf(exitIO, i18n.T(lang, "Dateiliste konnte nicht gelesen werden: %v"), err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	files, err := extractor.ReadFileList(input)
+	if err != nil {
+		fatalf(exitIO, i18n.T(lang, "Dateiliste konnte nicht gelesen werden: %v"), err)
+	}
+	if len(files) == 0 {
+		fatalf(exitUsage, i18n.T(lang, "Dateiliste %s enthält keine Dateien"), path)
+	}
+	return files
+}
+
+// parseTimestamp parses the time of -newer-than, either RFC 3339 or a date
+// or date and time without zone, which are taken as local time
+func parseTimestamp(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
+
+// statusf prints a status line to stdout, colored by status on a terminal
+func statusf(status extractor.Status, format string, args ...any) {
+	extractor.WriteStatus(os.Stdout, status, fmt.Sprintf(format, args...))
+}
+
+// listAttachments prints the embedded files of every PDF as a table and
+// reports whether all files could be read
+func listAttachments(files []string, lang string) bool {
+	ok := true
+	for i, file := range files {
+		if i > 0 {
+			fmt.Println()
+		}
+		attachments, err := extractor.ListAttachments(file)
+		if err != nil {
+			statusf(extractor.StatusFailure, "❌ %s: %v\n", file, err)
+			ok = false
+			continue
+		}
+
+		fmt.Printf("%s:\n", file)
+		if len(attachments) == 0 {
+			fmt.Println(i18n.T(lang, "  keine eingebetteten Dateien"))
+			continue
+		}
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, i18n.T(lang, "  NAME\tGRÖSSE\tMIME-TYP\tAFRELATIONSHIP"))
+		for _, attachment := range attachments {
+			fmt.Fprintf(writer, "  %s\t%d\t%s\t%s\n", attachment.Name, attachment.Size, attachment.MimeType, attachment.Relationship)
+		}
+		writer.Flush()
+	}
+	return ok
+}
+
+// checkPDFA prints the PDF/A-3 markers of every file and reports whether
+// all files claim PDF/A-3 conformance; encrypted files are opened with
+// password
+func checkPDFA(files []string, lang, password string) bool {
+	allConform := true
+	for _, file := range files {
+		zugferd := &extractor.ZUGFeRDExtractor{InputPath: file, Lang: lang, Password: password}
+		conforms, missing, err := zugferd.CheckPDFA3()
+		switch {
+		case err != nil:
+			statusf(extractor.StatusFailure, "❌ %s: %v\n", file, err)
+			allConform = false
+		case conforms:
+			statusf(extractor.StatusSuccess, i18n.T(lang, "✓ %s: PDF/A-3\n"), file)
+		default:
+			statusf(extractor.StatusWarning, i18n.T(lang, "⚠ %s: keine PDF/A-3-Konformität angegeben\n"), file)
+			for _, marker := range missing {
+				fmt.Printf("  - %s\n", marker)
+			}
+			allConform = false
+		}
 	}
+	return allConform
 }
 
-func printUsage() {
-	fmt.Println("ZUGFeRD XML Extractor v1.0")
-	fmt.Println("Verwendung: zugferd-extractor [optionen] <pfad-zur-zugferd-pdf>")
+// runEmbed implements the embed subcommand and returns the exit code
+func runEmbed(args []string) int {
+	flags := flag.NewFlagSet("embed", flag.ContinueOnError)
+	outputPtr := flags.String("o", "", "Ausgabepfad für die PDF-Datei")
+	profilePtr := flags.String("profile", "", "ZUGFeRD-Profil der XML (Standard: aus der XML erkannt)")
+	passwordPtr := flags.String("password", "", "Passwort für verschlüsselte PDF-Dateien")
+	langPtr := flags.String("lang", "", "Sprache der Meldungen (de, en; Standard: $ZUGFERD_LANG oder de)")
+	helpPtr := flags.Bool("h", false, "Hilfe anzeigen")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	lang := i18n.Resolve(*langPtr)
+
+	if *helpPtr || flags.NArg() != 2 {
+		printEmbedUsage(lang)
+		if *helpPtr {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	pdfPath, xmlPath := flags.Arg(0), flags.Arg(1)
+	outputPath := *outputPtr
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(pdfPath, filepath.Ext(pdfPath)) + "_zugferd.pdf"
+	}
+
+	zugferd := &extractor.ZUGFeRDExtractor{
+		InputPath: pdfPath,
+		Lang:      lang,
+		Password:  *passwordPtr,
+	}
+	if err := zugferd.Embed(xmlPath, outputPath, *profilePtr); err != nil {
+		fatalf(exitCode(err), i18n.T(lang, "Fehler beim Einbetten der XML: %v"), err)
+	}
+	statusf(extractor.StatusSuccess, i18n.T(lang, "✓ XML eingebettet: %s\n"), outputPath)
+	return exitOK
+}
+
+func printEmbedUsage(lang string) {
+	fmt.Println(i18n.T(lang, "Verwendung: zugferd-extractor embed [optionen] <pdf> <xml>"))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Bettet eine ZUGFeRD-XML als Anhang (AFRelationship Alternative) in eine PDF ein."))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Optionen:"))
+	fmt.Println(i18n.T(lang, "  -o <pfad>  Ausgabepfad für die PDF-Datei (Standard: <name>_zugferd.pdf)"))
+	fmt.Println(i18n.T(lang, "  -profile <profil>  Profil der XML, z.B. EN16931 oder XRECHNUNG (Standard: erkannt)"))
+	fmt.Println(i18n.T(lang, "  -password <passwort>  Passwort für verschlüsselte PDF-Dateien"))
+	fmt.Println(i18n.T(lang, "  -lang <code>  Sprache der Meldungen: de oder en (Standard: $ZUGFERD_LANG, sonst de)"))
+	fmt.Println(i18n.T(lang, "  -h         Diese Hilfe anzeigen"))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Beispiele:"))
+	fmt.Println("  zugferd-extractor embed rechnung.pdf factur-x.xml")
+	fmt.Println("  zugferd-extractor embed -o rechnung_x.pdf -profile XRECHNUNG rechnung.pdf xrechnung.xml")
+}
+
+func printUsage(lang string) {
+	fmt.Printf("ZUGFeRD XML Extractor %s\n", currentBuildInfo().Version)
+	fmt.Println(i18n.T(lang, "Verwendung: zugferd-extractor [optionen] <pfad-zur-zugferd-pdf>"))
+	fmt.Println(i18n.T(lang, "           zugferd-extractor [optionen] <http(s)-url-der-pdf>"))
+	fmt.Println(i18n.T(lang, "           zugferd-extractor [optionen] - < rechnung.pdf"))
+	fmt.Println(i18n.T(lang, "           zugferd-extractor embed [optionen] <pdf> <xml>"))
+	fmt.Println(i18n.T(lang, "           zugferd-extractor diff [optionen] <alt.pdf> <neu.pdf>"))
+	fmt.Println(i18n.T(lang, "           zugferd-extractor validate [optionen] <pdf>..."))
+	fmt.Println(i18n.T(lang, "           zugferd-extractor watch [optionen] <verzeichnis>"))
+	fmt.Println(i18n.T(lang, "           zugferd-extractor selftest"))
+	fmt.Println(i18n.T(lang, "           zugferd-extractor version [-json]"))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Optionen:"))
+	fmt.Println(i18n.T(lang, "  -v         Ausführliche Ausgabe"))
+	fmt.Println(i18n.T(lang, "  -log-format <format>  Protokoll als text (Standard) oder json: ein JSON-Ereignis je Zeile auf stderr"))
+	fmt.Println(i18n.T(lang, "  -q         Nur Fehler ausgeben, keine Erfolgs- und Statusmeldungen (auch -quiet)"))
+	fmt.Println(i18n.T(lang, "  -o <pfad>  Ausgabepfad für die XML-Datei (\"-\" für stdout)"))
+	fmt.Println(i18n.T(lang, "  -validate  EN16931-Geschäftsregeln prüfen (Exit-Code 1 bei Verstößen)"))
+	fmt.Println(i18n.T(lang, "  -check-leitweg-id  Käuferreferenz (BT-10) von XRechnungen als Leitweg-ID prüfen, aktiviert -validate"))
+	fmt.Println(i18n.T(lang, "  -xsd       Elementstruktur gegen das vereinfachte Schema der Version prüfen (Exit-Code 1 bei Verstößen)"))
+	fmt.Println(i18n.T(lang, "  -xsd-dir <verzeichnis>  Eigene XSD-Dateien statt der vereinfachten verwenden, aktiviert -xsd"))
+	fmt.Println(i18n.T(lang, "  -json      Rechnungsdaten als JSON nach stdout ausgeben statt XML zu speichern"))
+	fmt.Println(i18n.T(lang, "  -jsonl     Je Datei eine JSON-Zeile mit Pfad, Profil und Rechnungsdaten nach stdout"))
+	fmt.Println(i18n.T(lang, "  -base64    XML base64-kodiert nach stdout ausgeben; mit -json/-jsonl als Feld xmlBase64"))
+	fmt.Println(i18n.T(lang, "  -all       Alle eingebetteten Dateien extrahieren (-o gibt das Verzeichnis an)"))
+	fmt.Println(i18n.T(lang, "  -multi     Alle ZUGFeRD-XML-Anhänge einer Sammel-PDF als <name>_1.xml, <name>_2.xml, ... speichern"))
+	fmt.Println(i18n.T(lang, "  -r         Verzeichnis rekursiv nach PDF-Dateien durchsuchen (auch -recursive)"))
+	fmt.Println(i18n.T(lang, "  -keepname  Originalen Dateinamen des XML-Anhangs beibehalten"))
+	fmt.Println(i18n.T(lang, "  -stdin     PDF von der Standardeingabe lesen, wie die Eingabe - (XML ohne -o nach stdout)"))
+	fmt.Println(i18n.T(lang, "  -from-file <liste>  Pfade der PDF-Dateien zeilenweise aus einer Datei lesen, - für stdin"))
+	fmt.Println(i18n.T(lang, "  -newer-than <zeit>  Nur PDF-Dateien verarbeiten, die nach dem Zeitpunkt geändert wurden, z.B. 2024-01-01"))
+	fmt.Println(i18n.T(lang, "  -since <dauer>  Nur PDF-Dateien verarbeiten, die in der Zeitspanne geändert wurden, z.B. 24h"))
+	fmt.Println(i18n.T(lang, "  -name-template <vorlage>  Dateiname aus Rechnungsfeldern, z.B. \"{invoiceNumber}_{date}.xml\""))
+	fmt.Println(i18n.T(lang, "  -filename-match <namen>  Weitere Dateinamen des XML-Anhangs, z.B. einvoice.xml,rechnung.xml"))
+	fmt.Println(i18n.T(lang, "  -min-confidence N  XML erst ab N erkannten ZUGFeRD-Indikatoren akzeptieren (Standard: 1)"))
+	fmt.Println(i18n.T(lang, "  -workers N Anzahl paralleler Worker (0 oder negativ = Anzahl CPU-Kerne)"))
+	fmt.Println(i18n.T(lang, "  -skip-existing  Dateien überspringen, deren XML im Ausgabeverzeichnis neuer als die PDF ist"))
+	fmt.Println(i18n.T(lang, "  -no-clobber  Fehler statt Überschreiben, wenn die Ausgabedatei bereits existiert"))
+	fmt.Println(i18n.T(lang, "  -on-collision <regel>  Gleiche Ausgabepfade im Batch: rename (Nummer anhängen, Standard), error oder overwrite"))
+	fmt.Println(i18n.T(lang, "  -dedupe    Byte-identische PDF-Dateien nur einmal verarbeiten"))
+	fmt.Println(i18n.T(lang, "  -dry-run   Extraktion nur simulieren, keine Dateien schreiben"))
+	fmt.Println(i18n.T(lang, "  -checksum <alg>  Prüfsumme der extrahierten XML ausgeben: sha256 oder sha1"))
+	fmt.Println(i18n.T(lang, "  -pdf-metadata  Seitenzahl, Titel, Autor und Erstellungsdatum der PDF mit ausgeben (-v, JSON, CSV)"))
+	fmt.Println(i18n.T(lang, "  -timings   Dauer von pdfcpu, Lesen, manueller Extraktion, Validierung und Schreiben messen (-v, JSON)"))
+	fmt.Println(i18n.T(lang, "  -pretty    Extrahierte XML mit zwei Leerzeichen einrücken"))
+	fmt.Println(i18n.T(lang, "  -strip-bom  UTF-8-BOM am Anfang der XML vor dem Speichern entfernen"))
+	fmt.Println(i18n.T(lang, "  -gzip      Extrahierte XML gzip-komprimiert als <name>.xml.gz speichern"))
+	fmt.Println(i18n.T(lang, "  -verify-verbatim  Geschriebene XML-Datei erneut lesen und byte-genau vergleichen"))
+	fmt.Println(i18n.T(lang, "  -strict-mime  Fehler statt Warnung, wenn der XML-Anhang keinen XML-MIME-Typ deklariert"))
+	fmt.Println(i18n.T(lang, "  -method <methode>  Nur diese Extraktionsmethode verwenden: standard, relaxed, af, annotation, manual oder auto"))
+	fmt.Println(i18n.T(lang, "  -no-manual  Keine manuelle Extraktion aus den PDF-Rohdaten, nur byte-genaue Methoden verwenden"))
+	fmt.Println(i18n.T(lang, "  -list      Eingebettete Dateien als Tabelle auflisten"))
+	fmt.Println(i18n.T(lang, "  -check-pdfa  PDF/A-3-Angaben (XMP, OutputIntent) prüfen statt XML zu extrahieren"))
+	fmt.Println(i18n.T(lang, "  -lang <code>  Sprache der Meldungen: de oder en (Standard: $ZUGFERD_LANG, sonst de)"))
+	fmt.Println(i18n.T(lang, "  -password <passwort>  Passwort für verschlüsselte PDF-Dateien"))
+	fmt.Println(i18n.T(lang, "  -timeout <dauer>  Maximale Dauer von Download und Extraktion pro Datei, z.B. 30s (0 = unbegrenzt)"))
+	fmt.Println(i18n.T(lang, "  -tmp <verzeichnis>  Verzeichnis für temporäre Dateien (Standard: $TMPDIR bzw. /tmp)"))
+	fmt.Println(i18n.T(lang, "  -keep-temp  Temporäre Verzeichnisse zur Fehlersuche nicht löschen und ihre Pfade ausgeben"))
+	fmt.Println(i18n.T(lang, "  -fail-fast  Batch nach der ersten fehlgeschlagenen Datei abbrechen"))
+	fmt.Println(i18n.T(lang, "  -retries N  Datei bei vorübergehenden E/A-Fehlern bis zu N-mal erneut verarbeiten (Batch)"))
+	fmt.Println(i18n.T(lang, "  -retry-backoff <dauer>  Wartezeit vor der ersten Wiederholung, danach verdoppelt (Standard 500ms)"))
+	fmt.Println(i18n.T(lang, "  -report <datei>  CSV-Bericht der Batch-Verarbeitung schreiben"))
+	fmt.Println(i18n.T(lang, "  -combine <datei>  Alle extrahierten XML mit ihrer Quelldatei in eine Sammeldatei schreiben"))
+	fmt.Println(i18n.T(lang, "  -no-config  Keine Konfigurationsdatei lesen"))
+	fmt.Println(i18n.T(lang, "  -version   Version anzeigen (mit -json als JSON)"))
+	fmt.Println(i18n.T(lang, "  -h         Diese Hilfe anzeigen"))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Dateinamen: -o hat Vorrang vor -name-template, das Vorrang vor -keepname hat."))
+	fmt.Println(i18n.T(lang, "Platzhalter: {invoiceNumber}, {date}, {seller}, {buyer}, {currency}, {pdf}."))
+	fmt.Println(i18n.T(lang, "Ohne diese Optionen gilt der Standardname des Anhangs, z.B. factur-x.xml."))
+	fmt.Println(i18n.T(lang, "Sonst, und in einem Ausgabeverzeichnis immer, gilt der Name der PDF."))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Muster: *, ? und [a-z] wie bei der Shell, {a,b} für Alternativen und ** für"))
+	fmt.Println(i18n.T(lang, "beliebig viele Verzeichnisebenen. Muster in Anführungszeichen setzen, damit die"))
+	fmt.Println(i18n.T(lang, "Shell sie nicht selbst auflöst."))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Konfiguration: zugferd.yaml oder .zugferdrc im Home- und im Arbeitsverzeichnis"))
+	fmt.Println(i18n.T(lang, "setzt Standardwerte, eine Option je Zeile (z.B. workers: 8). Vorrang haben"))
+	fmt.Println(i18n.T(lang, "Kommandozeile, Arbeitsverzeichnis, Home-Verzeichnis, eingebaute Standardwerte."))
 	fmt.Println()
-	fmt.Println("Optionen:")
-	fmt.Println("  -v         Ausführliche Ausgabe")
-	fmt.Println("  -o <pfad>  Ausgabepfad für die XML-Datei")
-	fmt.Println("  -h         Diese Hilfe anzeigen")
+	fmt.Println(i18n.T(lang, "Exit-Codes bei einer einzelnen Datei:"))
+	fmt.Println(i18n.T(lang, "  0  Erfolg"))
+	fmt.Println(i18n.T(lang, "  1  Sonstiger Fehler, z.B. beschädigte PDF oder Verstöße gegen Geschäftsregeln"))
+	fmt.Println(i18n.T(lang, "  2  Keine ZUGFeRD-XML in der PDF gefunden"))
+	fmt.Println(i18n.T(lang, "  3  E/A-Fehler: Datei nicht gefunden, nicht lesbar oder nicht schreibbar"))
+	fmt.Println(i18n.T(lang, "  4  Ungültige Argumente"))
+	fmt.Println(i18n.T(lang, "Im Batch endet jede fehlgeschlagene Datei mit Exit-Code 1, mit -fail-fast mit"))
+	fmt.Println(i18n.T(lang, "dem Code der ersten fehlgeschlagenen Datei."))
 	fmt.Println()
-	fmt.Println("Beispiele:")
+	fmt.Println(i18n.T(lang, "Beispiele:"))
 	fmt.Println("  zugferd-extractor rechnung.pdf")
 	fmt.Println("  zugferd-extractor -v rechnung.pdf")
 	fmt.Println("  zugferd-extractor -o ausgabe.xml rechnung.pdf")
+	fmt.Println("  zugferd-extractor -o - rechnung.pdf | xmllint --format -")
 	fmt.Println("  zugferd-extractor *.pdf")
+	fmt.Println("  zugferd-extractor -r -o xml/ rechnungen/")
+	fmt.Println("  zugferd-extractor -o xml/ 'rechnungen/**/*.{pdf,PDF}'")
+	fmt.Println("  zugferd-extractor -o xml/ rechnungen.zip")
+	fmt.Println("  zugferd-extractor -json *.pdf > rechnungen.json")
+	fmt.Println("  cat rechnung.pdf | zugferd-extractor - > rechnung.xml")
+	fmt.Println("  zugferd-extractor -jsonl 'rechnungen/**/*.pdf' > rechnungen.jsonl")
 	fmt.Println()
-	fmt.Println("Unterstützte Formate:")
+	fmt.Println(i18n.T(lang, "Unterstützte Formate:"))
 	fmt.Println("  - ZUGFeRD 1.0, 2.0, 2.1, 2.3")
 	fmt.Println("  - Factur-X")
-	fmt.Println("  - XRechnung")
+	fmt.Println(i18n.T(lang, "  - XRechnung (CII und UBL)"))
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+
+	"zugferd-extractor/internal/extractor"
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/invoice"
+	"zugferd-extractor/internal/validation"
+)
+
+// selftestFiles holds the sample invoices of the selftest subcommand. Both
+// carry the XML of the EN16931_Einfach and XRECHNUNG_Einfach test files in
+// an empty page: en16931.pdf in the EmbeddedFiles name tree, as written by
+// the embed subcommand, xrechnung-af.pdf only in the /AF array.
+//
+//go:embed selftest/*.pdf
+var selftestFiles embed.FS
+
+// selftestSample is a bundled PDF with the expected extraction result
+type selftestSample struct {
+	file     string
+	filename string
+	profile  string
+}
+
+var selftestSamples = []selftestSample{
+	{file: "en16931.pdf", filename: "factur-x.xml", profile: validation.ProfileEN16931},
+	{file: "xrechnung-af.pdf", filename: "xrechnung.xml", profile: validation.ProfileXRechnung},
+}
+
+// runSelftest implements the selftest subcommand and returns the exit code
+func runSelftest(args []string) int {
+	flags := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	langPtr := flags.String("lang", "", "Sprache der Meldungen (de, en; Standard: $ZUGFERD_LANG oder de)")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	lang := i18n.Resolve(*langPtr)
+
+	passed := 0
+	for _, sample := range selftestSamples {
+		if err := sample.run(lang); err != nil {
+			fmt.Printf("FAIL %s: %v\n", sample.file, err)
+			continue
+		}
+		fmt.Printf("PASS %s\n", sample.file)
+		passed++
+	}
+
+	fmt.Printf(i18n.T(lang, "Selbsttest: %d von %d Beispielen bestanden\n"), passed, len(selftestSamples))
+	if passed < len(selftestSamples) {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// run extracts the XML of the sample and checks it like -validate
+func (s selftestSample) run(lang string) error {
+	data, err := selftestFiles.ReadFile("selftest/" + s.file)
+	if err != nil {
+		return err
+	}
+	zugferd, err := extractor.NewExtractorFromReader(bytes.NewReader(data), lang)
+	if err != nil {
+		return err
+	}
+	zugferd.Quiet = true
+
+	xmlData, filename, err := zugferd.ExtractXMLData()
+	if err != nil {
+		return err
+	}
+	if filename != s.filename {
+		return i18n.Errorf(lang, "Anhang %s statt %s extrahiert", filename, s.filename)
+	}
+
+	validator := &validation.Validator{Lang: lang}
+	if !validator.ValidateZUGFeRDXML(xmlData) {
+		return i18n.Errorf(lang, "%s ist keine gültige ZUGFeRD-XML", filename)
+	}
+	profile, err := validator.DetectProfile(xmlData)
+	if err != nil {
+		return err
+	}
+	if profile != s.profile {
+		return i18n.Errorf(lang, "Profil %s statt %s erkannt", profile, s.profile)
+	}
+	violations, err := validator.ValidateBusinessRules(xmlData)
+	if err != nil {
+		return err
+	}
+	for _, violation := range violations {
+		if violation.Severity == validation.SeverityError {
+			return i18n.Errorf(lang, "Verstoß gegen Geschäftsregel: %s", violation)
+		}
+	}
+	if _, err := invoice.ParseInvoice(xmlData, lang); err != nil {
+		return i18n.Errorf(lang, "Rechnungsdaten konnten nicht gelesen werden: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// interruptContext returns a context that is cancelled by the first SIGINT
+// or SIGTERM, which lets a batch finish the files in progress. The signal
+// handler is removed right away, so a second Ctrl+C ends the program at
+// once. The returned function releases the handler.
+func interruptContext(lang string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			fmt.Fprintln(os.Stderr, i18n.T(lang, "Abbruch angefordert: laufende Dateien werden abgeschlossen, erneut Strg+C beendet sofort"))
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"zugferd-extractor/internal/extractor"
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/validation"
+)
+
+// runValidate implements the validate subcommand and returns the exit
+// code: exitOK if every PDF passes all checks, exitFailure if a check
+// fails, otherwise the code of the first PDF whose XML could not be
+// extracted. No file is ever written.
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	noWellFormedPtr := flags.Bool("no-wellformed", false, "Wohlgeformtheit nicht prüfen")
+	noXSDPtr := flags.Bool("no-xsd", false, "Elementstruktur nicht prüfen")
+	noRulesPtr := flags.Bool("no-rules", false, "Geschäftsregeln nicht prüfen")
+	xsdDirPtr := flags.String("xsd-dir", "", "Verzeichnis mit eigenen XSD-Dateien statt der vereinfachten mitgelieferten")
+	leitwegIDPtr := flags.Bool("check-leitweg-id", false, "Käuferreferenz von XRechnungen als Leitweg-ID prüfen")
+	passwordPtr := flags.String("password", "", "Passwort für verschlüsselte PDF-Dateien")
+	quietPtr := flags.Bool("q", false, "Nur Fehler ausgeben")
+	flags.BoolVar(quietPtr, "quiet", false, "Nur Fehler ausgeben")
+	langPtr := flags.String("lang", "", "Sprache der Meldungen (de, en; Standard: $ZUGFERD_LANG oder de)")
+	helpPtr := flags.Bool("h", false, "Hilfe anzeigen")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	lang := i18n.Resolve(*langPtr)
+
+	if *helpPtr || flags.NArg() == 0 {
+		printValidateUsage(lang)
+		if *helpPtr {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	var checks []extractor.Check
+	if !*noWellFormedPtr {
+		checks = append(checks, extractor.CheckWellFormed)
+	}
+	if !*noXSDPtr {
+		checks = append(checks, extractor.CheckSchema)
+	}
+	if !*noRulesPtr {
+		checks = append(checks, extractor.CheckRules)
+	}
+	if len(checks) == 0 {
+		fatalf(exitUsage, i18n.T(lang, "-no-wellformed, -no-xsd und -no-rules zusammen lassen keine Prüfung übrig"))
+	}
+
+	code := exitOK
+	for _, input := range flags.Args() {
+		zugferd := &extractor.ZUGFeRDExtractor{
+			InputPath:      input,
+			SchemaDir:      *xsdDirPtr,
+			CheckLeitwegID: *leitwegIDPtr,
+			Password:       *passwordPtr,
+			Lang:           lang,
+		}
+		report, err := zugferd.Validate(context.Background(), checks...)
+		if err != nil {
+			extractor.WriteStatus(os.Stderr, extractor.StatusFailure, fmt.Sprintf("❌ %s: %v\n", input, err))
+			if code == exitOK {
+				code = exitCode(err)
+			}
+			continue
+		}
+		printValidationReport(input, report, *quietPtr, lang)
+		if !report.Valid() && code == exitOK {
+			code = exitFailure
+		}
+	}
+	return code
+}
+
+// printValidationReport prints the result of every check of a PDF and its
+// overall outcome; quiet prints only the failed checks
+func printValidationReport(input string, report *extractor.ValidationReport, quiet bool, lang string) {
+	if !quiet {
+		fmt.Printf("%s:\n", input)
+		fmt.Printf(i18n.T(lang, "  XML-Anhang: %s (Methode %s)\n"), report.Filename, report.Method)
+		if report.Profile != "" {
+			fmt.Printf(i18n.T(lang, "  Profil: %s\n"), report.Profile)
+		}
+		if report.Syntax != "" {
+			fmt.Printf(i18n.T(lang, "  Syntax: %s\n"), report.Syntax)
+		}
+	}
+	for _, check := range report.Checks {
+		name := checkName(check.Check, lang)
+		if !quiet {
+			for _, violation := range check.Violations {
+				status := extractor.StatusWarning
+				if violation.Severity == validation.SeverityError {
+					status = extractor.StatusFailure
+				}
+				statusf(status, "  ⚠ %s\n", violation)
+			}
+		}
+		switch {
+		case check.Passed() && !quiet:
+			statusf(extractor.StatusSuccess, "  ✓ %s\n", name)
+		case !check.Passed():
+			statusf(extractor.StatusFailure, "  ❌ %s: %v\n", name, check.Err)
+		}
+	}
+
+	switch {
+	case !report.Valid():
+		statusf(extractor.StatusFailure, i18n.T(lang, "❌ %s ist ungültig\n"), input)
+	case !quiet:
+		statusf(extractor.StatusSuccess, i18n.T(lang, "✅ %s ist gültig\n"), input)
+	}
+}
+
+// checkName returns the name of a check in the report
+func checkName(check extractor.Check, lang string) string {
+	switch check {
+	case extractor.CheckWellFormed:
+		return i18n.T(lang, "Wohlgeformtheit")
+	case extractor.CheckSchema:
+		return i18n.T(lang, "Elementstruktur")
+	case extractor.CheckRules:
+		return i18n.T(lang, "Geschäftsregeln")
+	}
+	return string(check)
+}
+
+func printValidateUsage(lang string) {
+	fmt.Println(i18n.T(lang, "Verwendung: zugferd-extractor validate [optionen] <pdf>..."))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Extrahiert die ZUGFeRD-XML jeder PDF im Speicher und prüft Wohlgeformtheit,"))
+	fmt.Println(i18n.T(lang, "Elementstruktur und EN16931-Geschäftsregeln, ohne eine Datei zu schreiben."))
+	fmt.Println(i18n.T(lang, "Exit-Code 0, wenn alle Prüfungen bestanden sind, 1 bei einer fehlgeschlagenen"))
+	fmt.Println(i18n.T(lang, "Prüfung, sonst der Code der ersten PDF, deren XML nicht extrahiert werden konnte."))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Optionen:"))
+	fmt.Println(i18n.T(lang, "  -no-wellformed  Wohlgeformtheit der XML nicht prüfen"))
+	fmt.Println(i18n.T(lang, "  -no-xsd    Elementstruktur nicht gegen das vereinfachte Schema prüfen"))
+	fmt.Println(i18n.T(lang, "  -no-rules  EN16931-Geschäftsregeln nicht prüfen"))
+	fmt.Println(i18n.T(lang, "  -xsd-dir <verzeichnis>  Eigene XSD-Dateien statt der vereinfachten verwenden"))
+	fmt.Println(i18n.T(lang, "  -check-leitweg-id  Käuferreferenz (BT-10) von XRechnungen als Leitweg-ID prüfen"))
+	fmt.Println(i18n.T(lang, "  -password <passwort>  Passwort für verschlüsselte PDF-Dateien"))
+	fmt.Println(i18n.T(lang, "  -q         Nur fehlgeschlagene Prüfungen ausgeben (auch -quiet)"))
+	fmt.Println(i18n.T(lang, "  -lang <code>  Sprache der Meldungen: de oder en (Standard: $ZUGFERD_LANG, sonst de)"))
+	fmt.Println(i18n.T(lang, "  -h         Diese Hilfe anzeigen"))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Beispiele:"))
+	fmt.Println("  zugferd-extractor validate rechnung.pdf")
+	fmt.Println("  zugferd-extractor validate -no-rules -q eingang/*.pdf")
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// Build information, injected at build time, e.g.
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD) -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values that are not injected are taken from the build info Go embeds
+// into the binary, if available.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// buildInfo describes the running binary
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+}
+
+// currentBuildInfo returns the build information of the binary
+func currentBuildInfo() buildInfo {
+	info := buildInfo{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+	if embedded, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && embedded.Main.Version != "(devel)" {
+			info.Version = embedded.Main.Version
+		}
+		for _, setting := range embedded.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+// runVersion implements the version subcommand
+func runVersion(args []string) {
+	flags := flag.NewFlagSet("version", flag.ContinueOnError)
+	jsonPtr := flags.Bool("json", false, "Versionsinformationen als JSON ausgeben")
+	langPtr := flags.String("lang", "", "Sprache der Meldungen (de, en; Standard: $ZUGFERD_LANG oder de)")
+	if code, ok := parseFlags(flags, args); !ok {
+		os.Exit(code)
+	}
+	printVersion(*jsonPtr, i18n.Resolve(*langPtr))
+}
+
+// printVersion prints the build information as text or as JSON
+func printVersion(asJSON bool, lang string) {
+	info := currentBuildInfo()
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(info); err != nil {
+			fatalf(exitIO, i18n.T(lang, "Fehler beim Schreiben der JSON-Ausgabe: %v"), err)
+		}
+		return
+	}
+
+	fmt.Printf("ZUGFeRD XML Extractor %s\n", info.Version)
+	if info.Commit != "" {
+		fmt.Printf(i18n.T(lang, "  Commit:      %s\n"), info.Commit)
+	}
+	if info.Date != "" {
+		fmt.Printf(i18n.T(lang, "  Build-Datum: %s\n"), info.Date)
+	}
+	fmt.Printf(i18n.T(lang, "  Go-Version:  %s\n"), info.GoVersion)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"zugferd-extractor/internal/extractor"
+	"zugferd-extractor/internal/i18n"
+)
+
+// runWatch implements the watch subcommand and returns the exit code. It
+// runs until SIGINT or SIGTERM; the files being processed at that moment
+// are finished first.
+func runWatch(args []string) int {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	outputPtr := flags.String("o", "", "Ausgabeverzeichnis für die XML-Dateien")
+	donePtr := flags.String("done", "", "Verzeichnis für verarbeitete PDF-Dateien")
+	intervalPtr := flags.Duration("interval", 2*time.Second, "Abstand zwischen zwei Durchläufen")
+	workersPtr := flags.Int("workers", 0, "Anzahl paralleler Worker (0 = automatisch)")
+	validatePtr := flags.Bool("validate", false, "EN16931-Geschäftsregeln prüfen")
+	xsdPtr := flags.Bool("xsd", false, "Elementstruktur der XML gegen das vereinfachte Schema ihrer Version prüfen")
+	prettyPtr := flags.Bool("pretty", false, "Extrahierte XML einrücken")
+	passwordPtr := flags.String("password", "", "Passwort für verschlüsselte PDF-Dateien")
+	verbosePtr := flags.Bool("v", false, "Ausführliche Ausgabe")
+	quietPtr := flags.Bool("q", false, "Nur Fehler ausgeben")
+	flags.BoolVar(quietPtr, "quiet", false, "Nur Fehler ausgeben")
+	langPtr := flags.String("lang", "", "Sprache der Meldungen (de, en; Standard: $ZUGFERD_LANG oder de)")
+	helpPtr := flags.Bool("h", false, "Hilfe anzeigen")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	lang := i18n.Resolve(*langPtr)
+
+	if *helpPtr || flags.NArg() != 1 {
+		printWatchUsage(lang)
+		if *helpPtr {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *verbosePtr && *quietPtr {
+		fatalf(exitUsage, i18n.T(lang, "-q und -v schließen sich aus"))
+	}
+	if *intervalPtr <= 0 {
+		fatalf(exitUsage, i18n.T(lang, "Ungültiges Intervall: %s (muss positiv sein)"), *intervalPtr)
+	}
+
+	dir := flags.Arg(0)
+	if info, err := os.Stat(dir); err != nil {
+		fatalf(exitIO, i18n.T(lang, "Verzeichnis nicht gefunden: %s"), dir)
+	} else if !info.IsDir() {
+		fatalf(exitUsage, i18n.T(lang, "%s ist kein Verzeichnis"), dir)
+	}
+	if *outputPtr != "" {
+		if err := os.MkdirAll(*outputPtr, 0755); err != nil {
+			fatalf(exitIO, i18n.T(lang, "Fehler beim Erstellen des Ausgabeverzeichnisses: %v"), err)
+		}
+	}
+
+	numWorkers := *workersPtr
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
+	}
+
+	watcher := &extractor.Watcher{
+		Dir:      dir,
+		DoneDir:  *donePtr,
+		Interval: *intervalPtr,
+		Batch: &extractor.BatchProcessor{
+			OutputDir:      *outputPtr,
+			Workers:        numWorkers,
+			Verbose:        *verbosePtr,
+			Quiet:          *quietPtr,
+			ValidateRules:  *validatePtr,
+			ValidateSchema: *xsdPtr,
+			Pretty:         *prettyPtr,
+			Password:       *passwordPtr,
+			Lang:           lang,
+			Logger:         newTextLogger(os.Stdout, *verbosePtr, *quietPtr),
+		},
+	}
+
+	ctx, stop := interruptContext(lang)
+	defer stop()
+	if err := watcher.Watch(ctx); err != nil {
+		fatalf(exitCode(err), i18n.T(lang, "Fehler bei der Überwachung: %v"), err)
+	}
+	return exitOK
+}
+
+func printWatchUsage(lang string) {
+	fmt.Println(i18n.T(lang, "Verwendung: zugferd-extractor watch [optionen] <verzeichnis>"))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Überwacht ein Verzeichnis und extrahiert die XML jeder neuen PDF-Datei, sobald"))
+	fmt.Println(i18n.T(lang, "sich ihre Größe ein Intervall lang nicht mehr ändert. Verarbeitete PDF-Dateien"))
+	fmt.Println(i18n.T(lang, "werden in das Zielverzeichnis verschoben, fehlgeschlagene bleiben liegen und"))
+	fmt.Println(i18n.T(lang, "werden erst nach einer Änderung erneut versucht. Beenden mit Strg+C."))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Optionen:"))
+	fmt.Println(i18n.T(lang, "  -o <verzeichnis>  Ausgabeverzeichnis für die XML-Dateien (Standard: neben der PDF)"))
+	fmt.Println(i18n.T(lang, "  -done <verzeichnis>  Zielverzeichnis für verarbeitete PDF-Dateien (Standard: <verzeichnis>/done)"))
+	fmt.Println(i18n.T(lang, "  -interval <dauer>  Abstand zwischen zwei Durchläufen, z.B. 10s (Standard: 2s)"))
+	fmt.Println(i18n.T(lang, "  -workers N Anzahl paralleler Worker (0 oder negativ = Anzahl CPU-Kerne)"))
+	fmt.Println(i18n.T(lang, "  -validate  EN16931-Geschäftsregeln prüfen; PDF-Dateien mit Verstößen bleiben liegen"))
+	fmt.Println(i18n.T(lang, "  -xsd       Struktur gegen vereinfachtes Schema prüfen; PDF-Dateien mit Verstößen bleiben liegen"))
+	fmt.Println(i18n.T(lang, "  -pretty    Extrahierte XML mit zwei Leerzeichen einrücken"))
+	fmt.Println(i18n.T(lang, "  -password <passwort>  Passwort für verschlüsselte PDF-Dateien"))
+	fmt.Println(i18n.T(lang, "  -v         Ausführliche Ausgabe"))
+	fmt.Println(i18n.T(lang, "  -q         Nur Fehler ausgeben, keine Erfolgs- und Statusmeldungen (auch -quiet)"))
+	fmt.Println(i18n.T(lang, "  -lang <code>  Sprache der Meldungen: de oder en (Standard: $ZUGFERD_LANG, sonst de)"))
+	fmt.Println(i18n.T(lang, "  -h         Diese Hilfe anzeigen"))
+	fmt.Println()
+	fmt.Println(i18n.T(lang, "Beispiele:"))
+	fmt.Println("  zugferd-extractor watch -o ./out ./inbox")
+	fmt.Println("  zugferd-extractor watch -o ./out -done ./archiv -interval 10s ./inbox")
+}
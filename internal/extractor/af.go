@@ -0,0 +1,66 @@
+package extractor
+
+import (
+	"fmt"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// extractAttachmentsAF reads the embedded files referenced by the /AF
+// (associated files) array of the document catalog. Some generators attach
+// the XML only there and leave the EmbeddedFiles name tree out, which is
+// the only place pdfcpu looks for attachments.
+func (z *ZUGFeRDExtractor) extractAttachmentsAF() (map[string][]byte, error) {
+	defer z.endPhase(phasePDFCPU, z.startPhase())
+	input, err := z.openInput()
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	ctx, err := z.readPDFContext(input)
+	if err != nil {
+		return nil, i18n.Errorf(z.Lang, "PDF konnte nicht gelesen werden: %v", err)
+	}
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return nil, i18n.Errorf(z.Lang, "PDF konnte nicht gelesen werden: %v", err)
+	}
+
+	o, found := catalog.Find("AF")
+	if !found {
+		return nil, i18n.Errorf(z.Lang, "Katalog enthält kein /AF-Array")
+	}
+	associated, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return nil, i18n.Errorf(z.Lang, "/AF-Array konnte nicht gelesen werden: %v", err)
+	}
+
+	attachments := make(map[string][]byte)
+	for i, entry := range associated {
+		d, err := ctx.DereferenceDict(entry)
+		if err != nil || d == nil {
+			z.warnf("Warnung: /AF-Eintrag %d ist keine Dateispezifikation", i)
+			continue
+		}
+
+		name := fileSpecName(ctx.XRefTable, d, fmt.Sprintf("attachment%d", i+1))
+		stream := embeddedFileStream(ctx.XRefTable, d)
+		if stream == nil {
+			z.warnf("Warnung: /AF-Eintrag %s enthält keine eingebettete Datei", name)
+			continue
+		}
+		if err := stream.Decode(); err != nil {
+			z.warnf("Warnung: Konnte Datei nicht lesen %s: %v", name, err)
+			continue
+		}
+
+		attachments[name] = stream.Content
+		z.event([]any{"event", "attachment_read", "name", name, "size", len(stream.Content)}, "  Anhang gelesen: %s (%d Bytes)\n", name, len(stream.Content))
+	}
+
+	if len(attachments) == 0 {
+		return nil, i18n.Errorf(z.Lang, "/AF-Array enthält keine eingebetteten Dateien")
+	}
+	return attachments, nil
+}
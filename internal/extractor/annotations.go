@@ -0,0 +1,133 @@
+package extractor
+
+import (
+	"fmt"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// maxPageTreeDepth bounds the nesting of the page tree walked for
+// annotations, so a malformed tree cannot recurse without end
+const maxPageTreeDepth = 64
+
+// extractAttachmentsAnnotations reads the files attached to pages by
+// FileAttachment annotations. Such a file is only referenced from the /FS
+// entry of its annotation, not from the EmbeddedFiles name tree or the /AF
+// array, so the other methods do not see it. The page tree is walked
+// directly, like the name tree in readFileSpecs, because pdfcpu only counts
+// the pages during validation.
+func (z *ZUGFeRDExtractor) extractAttachmentsAnnotations() (map[string][]byte, error) {
+	defer z.endPhase(phasePDFCPU, z.startPhase())
+
+	input, err := z.openInput()
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	ctx, err := z.readPDFContext(input)
+	if err != nil {
+		return nil, i18n.Errorf(z.Lang, "PDF konnte nicht gelesen werden: %v", err)
+	}
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return nil, i18n.Errorf(z.Lang, "PDF konnte nicht gelesen werden: %v", err)
+	}
+	pages, err := dereferenceDictEntry(ctx.XRefTable, catalog, "Pages")
+	if err != nil || pages == nil {
+		return nil, i18n.Errorf(z.Lang, "Seitenbaum der PDF konnte nicht gelesen werden")
+	}
+
+	attachments := make(map[string][]byte)
+	page := 0
+	visited := make(map[int]bool)
+	z.walkPages(ctx, pages, 0, visited, func(pageDict types.Dict) {
+		page++
+		z.readAnnotationFiles(ctx, pageDict, page, attachments)
+	})
+
+	if len(attachments) == 0 {
+		return nil, i18n.Errorf(z.Lang, "keine Dateianhang-Annotationen mit eingebetteten Dateien gefunden")
+	}
+	return attachments, nil
+}
+
+// walkPages calls visit for every page below the page tree node in
+// document order; nodes already visited are skipped
+func (z *ZUGFeRDExtractor) walkPages(ctx *model.Context, node types.Dict, depth int, visited map[int]bool, visit func(types.Dict)) {
+	o, found := node.Find("Kids")
+	if !found {
+		visit(node)
+		return
+	}
+	if depth >= maxPageTreeDepth {
+		return
+	}
+	kids, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return
+	}
+	for _, kid := range kids {
+		if ref, ok := kid.(types.IndirectRef); ok {
+			if visited[ref.ObjectNumber.Value()] {
+				continue
+			}
+			visited[ref.ObjectNumber.Value()] = true
+		}
+		d, err := ctx.DereferenceDict(kid)
+		if err != nil || d == nil {
+			continue
+		}
+		z.walkPages(ctx, d, depth+1, visited, visit)
+	}
+}
+
+// readAnnotationFiles adds the embedded files of the FileAttachment
+// annotations of a page to attachments
+func (z *ZUGFeRDExtractor) readAnnotationFiles(ctx *model.Context, pageDict types.Dict, page int, attachments map[string][]byte) {
+	o, found := pageDict.Find("Annots")
+	if !found {
+		return
+	}
+	annots, err := ctx.DereferenceArray(o)
+	if err != nil {
+		return
+	}
+
+	for i, entry := range annots {
+		annot, err := ctx.DereferenceDict(entry)
+		if err != nil || annot == nil {
+			continue
+		}
+		if subtype := annot.NameEntry("Subtype"); subtype == nil || *subtype != "FileAttachment" {
+			continue
+		}
+
+		id := fmt.Sprintf("page%d_attachment%d", page, i+1)
+		spec, err := dereferenceDictEntry(ctx.XRefTable, annot, "FS")
+		if err != nil || spec == nil {
+			z.warnf("Warnung: Dateianhang-Annotation %s auf Seite %d hat keine Dateispezifikation", id, page)
+			continue
+		}
+		name := fileSpecName(ctx.XRefTable, spec, id)
+		stream := embeddedFileStream(ctx.XRefTable, spec)
+		if stream == nil {
+			z.warnf("Warnung: Dateianhang-Annotation %s auf Seite %d enthält keine eingebettete Datei", name, page)
+			continue
+		}
+		if err := stream.Decode(); err != nil {
+			z.warnf("Warnung: Konnte Datei nicht lesen %s: %v", name, err)
+			continue
+		}
+
+		// The same file may be attached on several pages under one name
+		if _, exists := attachments[name]; exists {
+			continue
+		}
+		attachments[name] = stream.Content
+		z.event([]any{"event", "attachment_read", "name", name, "size", len(stream.Content), "page", page}, "  Anhang gelesen: %s (%d Bytes, Seite %d)\n", name, len(stream.Content), page)
+	}
+}
@@ -0,0 +1,59 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testdata/annotation.pdf attaches testdata/annotation.xml as factur-x.xml
+// to its page by a FileAttachment annotation only, without an
+// EmbeddedFiles name tree or an /AF array
+const annotationPDF = "testdata/annotation.pdf"
+
+func TestAnnotationMethod(t *testing.T) {
+	want, err := os.ReadFile(filepath.Join("testdata", "annotation.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	z := &ZUGFeRDExtractor{InputPath: annotationPDF, OutputPath: filepath.Join(t.TempDir(), "out.xml"), Method: MethodAnnotation, Quiet: true}
+	result, err := z.ExtractXMLResult(context.Background())
+	if err != nil {
+		t.Fatalf("ExtractXMLResult: %v", err)
+	}
+	if result.Method != MethodAnnotation {
+		t.Errorf("Result.Method = %s, want %s", result.Method, MethodAnnotation)
+	}
+	if result.Filename != "factur-x.xml" {
+		t.Errorf("Result.Filename = %s, want factur-x.xml", result.Filename)
+	}
+
+	written, err := os.ReadFile(result.OutputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, want) {
+		t.Errorf("written XML differs from the attached file:\n got %q\nwant %q", written, want)
+	}
+}
+
+func TestAnnotationOnlyPDF(t *testing.T) {
+	for _, method := range []Method{MethodStandard, MethodAF} {
+		z := &ZUGFeRDExtractor{InputPath: annotationPDF, Method: method}
+		if err := z.WriteXML(&bytes.Buffer{}); err == nil {
+			t.Errorf("method %s found the annotation attachment, the fixture no longer covers the gap", method)
+		}
+	}
+
+	z := &ZUGFeRDExtractor{InputPath: annotationPDF, OutputPath: filepath.Join(t.TempDir(), "out.xml"), NoManual: true, Quiet: true}
+	result, err := z.ExtractXMLResult(context.Background())
+	if err != nil {
+		t.Fatalf("automatic method: %v", err)
+	}
+	if result.Method != MethodAnnotation {
+		t.Errorf("automatic method used %s, want %s", result.Method, MethodAnnotation)
+	}
+}
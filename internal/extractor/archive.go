@@ -0,0 +1,38 @@
+package extractor
+
+import (
+	"archive/zip"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IsArchive reports whether path names a ZIP archive, judged by its
+// extension
+func IsArchive(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".zip")
+}
+
+// archivePDFEntries returns the names of all PDF entries of archive,
+// including those in nested directories, in archive order. Entries whose
+// name could leave the output directory and the resource forks that macOS
+// stores under __MACOSX are skipped. Some Windows tools separate the
+// directories of a name with backslashes; such names are returned with
+// slashes, as archive/zip opens them.
+func archivePDFEntries(archive *zip.Reader) []string {
+	var entries []string
+	for _, file := range archive.File {
+		name := strings.ReplaceAll(file.Name, `\`, "/")
+		if file.FileInfo().IsDir() || !fs.ValidPath(name) {
+			continue
+		}
+		if strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._") {
+			continue
+		}
+		if strings.ToLower(path.Ext(name)) == ".pdf" {
+			entries = append(entries, name)
+		}
+	}
+	return entries
+}
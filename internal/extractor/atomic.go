@@ -0,0 +1,91 @@
+package extractor
+
+import (
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// outputPerm is the permission of written output files before the umask
+// is applied, as with os.WriteFile
+const outputPerm = 0644
+
+// maxTempAttempts limits the random names createAtomic tries before it
+// gives up
+const maxTempAttempts = 10000
+
+// atomicFile is an output file that is written under a temporary name in
+// the directory of its target and renamed to the target by commit. A
+// write that is interrupted, e.g. by Ctrl+C or a full disk, never leaves a
+// truncated file under the target name; an existing file at the target is
+// replaced only once the new one is complete.
+type atomicFile struct {
+	*os.File
+	path      string
+	committed bool
+}
+
+// createAtomic creates the temporary file for path. Its name starts with
+// a dot and ends in .tmp, so it is neither mistaken for an output nor
+// picked up as a PDF input. Unlike os.CreateTemp, which always uses 0600,
+// the file is created with outputPerm so the umask applies.
+func createAtomic(path string) (*atomicFile, error) {
+	dir, base := filepath.Dir(path), filepath.Base(path)
+	for attempt := 1; ; attempt++ {
+		name := filepath.Join(dir, "."+base+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp")
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, outputPerm)
+		if os.IsExist(err) && attempt < maxTempAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &atomicFile{File: file, path: path}, nil
+	}
+}
+
+// commit closes the file and renames it to its target. A replaced target
+// keeps its permissions, as if it had been overwritten in place.
+func (f *atomicFile) commit() error {
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if info, err := os.Stat(f.path); err == nil {
+		if err := os.Chmod(f.Name(), info.Mode().Perm()); err != nil {
+			os.Remove(f.Name())
+			return err
+		}
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	f.committed = true
+	return nil
+}
+
+// abort closes and removes the temporary file unless it was committed; it
+// is meant to be deferred
+func (f *atomicFile) abort() {
+	if f.committed {
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// writeFileAtomic writes data to path through an atomicFile
+func writeFileAtomic(path string, data []byte) error {
+	file, err := createAtomic(path)
+	if err != nil {
+		return err
+	}
+	defer file.abort()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	return file.commit()
+}
@@ -0,0 +1,98 @@
+package extractor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// AttachmentInfo describes an embedded file without its content
+type AttachmentInfo struct {
+	Name         string
+	Size         int64
+	MimeType     string
+	Relationship string // AFRelationship, empty if not declared
+}
+
+// ListAttachments returns the embedded files of the PDF at pdfPath, sorted
+// by name, without extracting their content
+func ListAttachments(pdfPath string) ([]AttachmentInfo, error) {
+	z := &ZUGFeRDExtractor{InputPath: pdfPath}
+	input, err := z.openInput()
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	ctx, err := z.readPDFContext(input)
+	if err != nil {
+		return nil, z.errorf(ErrPDFParse, "PDF konnte nicht gelesen werden: %v", err)
+	}
+	specs, err := z.readFileSpecs(ctx)
+	if err != nil {
+		return nil, z.errorf(ErrPDFParse, "PDF konnte nicht gelesen werden: %v", err)
+	}
+
+	infos := make([]AttachmentInfo, 0, len(specs))
+	for _, spec := range specs {
+		infos = append(infos, AttachmentInfo{
+			Name:         spec.name,
+			Size:         spec.size,
+			MimeType:     spec.mimeType,
+			Relationship: spec.relationship,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos, nil
+}
+
+// ExtractAllAttachments writes every embedded file of the PDF to outputDir
+// and returns a map of attachment name to output path. An empty outputDir
+// means the directory of the input PDF.
+func (z *ZUGFeRDExtractor) ExtractAllAttachments(outputDir string) (map[string]string, error) {
+	return z.extractAllAttachments(context.Background(), outputDir)
+}
+
+// extractAllAttachments is ExtractAllAttachments with cancellation support
+func (z *ZUGFeRDExtractor) extractAllAttachments(ctx context.Context, outputDir string) (map[string]string, error) {
+	attachments, _, err := z.extractAttachments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if outputDir == "" {
+		outputDir = filepath.Dir(z.InputPath)
+	}
+	if !z.DryRun {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return nil, z.errorf(ErrIO, "Fehler beim Erstellen des Ausgabeverzeichnisses: %v", err)
+		}
+	}
+
+	// Write in a stable order so the verbose output is reproducible
+	names := make([]string, 0, len(attachments))
+	for name := range attachments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	written := make(map[string]string, len(attachments))
+	for _, name := range names {
+		// Attachment names come from the PDF and must not escape outputDir
+		outputPath := filepath.Join(outputDir, attachmentBase(name))
+		if z.DryRun {
+			written[name] = outputPath
+			continue
+		}
+		if err := writeFileAtomic(outputPath, attachments[name]); err != nil {
+			return written, z.errorf(ErrIO, "Fehler beim Schreiben des Anhangs %s: %v", name, err)
+		}
+		written[name] = outputPath
+		z.logf("  Anhang gespeichert: %s -> %s\n", name, outputPath)
+	}
+
+	return written, nil
+}
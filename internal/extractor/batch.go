@@ -1,66 +1,444 @@
 package extractor
 
 import (
+	"archive/zip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
+
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/invoice"
+	"zugferd-extractor/internal/validation"
 )
 
-// BatchProcessor handles processing multiple PDF files
+// BatchProcessor handles processing multiple PDF files. The input files
+// are taken from Files, which may come from any source; InputPattern is
+// a convenience that is only expanded when Files is empty. A processor
+// can be run several times, but not concurrently.
 type BatchProcessor struct {
+	// InputPattern is expanded with ExpandPattern when Files is empty
 	InputPattern string
-	OutputDir    string
-	Workers      int
-	Verbose      bool
+
+	// Files is the list of input files; files without a .pdf extension
+	// are skipped. If set, InputPattern is not evaluated.
+	Files []string
+
+	// NewerThan restricts the batch to input files modified after it; the
+	// other files are left out before any file is processed. The zero
+	// value processes all files.
+	NewerThan time.Time
+
+	// Archive is the path of a ZIP archive whose PDF entries are processed
+	// instead of Files and InputPattern. The output of an entry keeps its
+	// directory inside the archive, below OutputDir or, if OutputDir is
+	// empty, below the directory of the archive.
+	Archive string
+
+	OutputDir string
+	Workers   int
+	Verbose   bool
+
+	// Quiet suppresses the status messages of the batch and of every file;
+	// failed files are still reported on stderr
+	Quiet bool
+
+	// ValidateRules runs the business rule checks for every file; the batch
+	// fails if any file violates a rule with error severity
+	ValidateRules bool
+
+	// CheckLeitwegID adds the Leitweg-ID format check to the rule checks
+	CheckLeitwegID bool
+
+	// ValidateSchema and SchemaDir check the element structure of every XML
+	// against its schema, see ZUGFeRDExtractor
+	ValidateSchema bool
+	SchemaDir      string
+
+	// JSONOutput parses the invoices instead of writing XML files and prints
+	// all results as a JSON array to stdout
+	JSONOutput bool
+
+	// JSONLines parses the invoices instead of writing XML files and prints
+	// one JSON object per file to stdout as soon as the file is done
+	JSONLines bool
+
+	// CombinePath is the path of a wrapper document that receives the XML
+	// of every file, with the source file as an attribute, instead of an
+	// XML file per PDF. It takes precedence over AllAttachments, JSONOutput
+	// and JSONLines; the document is written after all files are done.
+	CombinePath string
+
+	// XMLBase64 adds the extracted XML base64-encoded to the JSON and JSONL
+	// results, so API consumers need not escape XML inside JSON
+	XMLBase64 bool
+
+	// AllAttachments writes every embedded file instead of only the invoice
+	// XML, into a subdirectory named after each PDF
+	AllAttachments bool
+
+	// PreserveOriginalName names each output after its embedded attachment;
+	// colliding names are resolved by OnCollision
+	PreserveOriginalName bool
+
+	// NameTemplate names every output file after its invoice fields, see
+	// ZUGFeRDExtractor.NameTemplate; colliding names are resolved by
+	// OnCollision
+	NameTemplate string
+
+	// OnCollision decides what happens when two input files resolve to the
+	// same output path, e.g. two PDFs of one directory that both carry a
+	// factur-x.xml; the file that gets to the path first keeps it
+	OnCollision CollisionPolicy
+
+	// AdditionalFilenames are tried after the standard ZUGFeRD filenames, see
+	// ZUGFeRDExtractor.AdditionalFilenames
+	AdditionalFilenames []string
+
+	// ReportPath is the path of a CSV report written after all files have
+	// been processed; empty disables the report
+	ReportPath string
+
+	// SkipExisting skips files whose XML output already exists and is newer
+	// than the PDF. This only applies when the output name is known before
+	// extraction, i.e. with OutputDir set and PreserveOriginalName unset.
+	SkipExisting bool
+
+	// NoClobber makes a file fail instead of overwriting existing output
+	NoClobber bool
+
+	// NoManual disables the manual extraction method, see ZUGFeRDExtractor
+	NoManual bool
+
+	// MinConfidence is the number of ZUGFeRD indicators an XML must match,
+	// see ZUGFeRDExtractor
+	MinConfidence int
+
+	// Dedupe hashes every input PDF and processes only the first of several
+	// byte-identical files; the others are reported as duplicates
+	Dedupe bool
+
+	// DryRun processes every file without writing any output; only the
+	// report, if requested, is written
+	DryRun bool
+
+	// Checksum names the hash algorithm of the per-file checksum, see
+	// ZUGFeRDExtractor.Checksum
+	Checksum string
+
+	// IncludeMetadata adds the PDF metadata to every result and to the
+	// JSON, JSONL and CSV output, see ZUGFeRDExtractor.IncludeMetadata
+	IncludeMetadata bool
+
+	// IncludeTimings adds the phase durations to every result and to the
+	// JSON and JSONL output, see ZUGFeRDExtractor.IncludeTimings
+	IncludeTimings bool
+
+	// Pretty re-indents the written XML, see ZUGFeRDExtractor.Pretty
+	Pretty bool
+
+	// Transformers rewrite the XML before it is written, see
+	// ZUGFeRDExtractor.Transformers; the workers share them, so they must be
+	// safe for concurrent use
+	Transformers []Transformer
+
+	// StrictMimeType fails files whose XML declares no XML MIME type, see
+	// ZUGFeRDExtractor.StrictMimeType
+	StrictMimeType bool
+
+	// StripBOM removes a leading byte order mark, see
+	// ZUGFeRDExtractor.StripBOM
+	StripBOM bool
+
+	// Gzip compresses every written XML, see ZUGFeRDExtractor.Gzip
+	Gzip bool
+
+	// VerifyVerbatim compares every written file with the extracted XML,
+	// see ZUGFeRDExtractor.VerifyVerbatim
+	VerifyVerbatim bool
+
+	// Method forces a single extraction method, see ZUGFeRDExtractor.Method
+	Method Method
+
+	// Logger is passed to the extractor of every file; nil discards the
+	// extraction messages
+	Logger *slog.Logger
+
+	// Password decrypts encrypted input PDFs, see ZUGFeRDExtractor.Password
+	Password string
+
+	// Lang selects the language of messages and errors, see
+	// ZUGFeRDExtractor.Lang
+	Lang string
+
+	// Timeout limits the extraction time per file; a file that exceeds it
+	// is recorded as failed. Zero means no limit.
+	Timeout time.Duration
+
+	// TempDir is passed to every extractor, see ZUGFeRDExtractor.TempDir
+	TempDir string
+
+	// KeepTemp is passed to every extractor, see ZUGFeRDExtractor.KeepTemp;
+	// every file gets temporary directories of its own
+	KeepTemp bool
+
+	// Retries is the number of times a file is processed again after a
+	// transient I/O error, see IsTransient; other errors are not retried
+	Retries int
+
+	// RetryBackoff is the wait before the first retry, doubled for every
+	// further retry; zero means defaultRetryBackoff
+	RetryBackoff time.Duration
+
+	// FailFast stops the batch after the first failed file: no further
+	// files are started, and files that are not processed because of it
+	// are left out of the failure count. The batch error wraps the error
+	// of the file that failed.
+	FailFast bool
+
+	// paths claims the output paths of the current run, see pathRegistry
+	paths *pathRegistry
+
+	// keepExisting makes the output paths of the current run avoid files
+	// already on disk, see pathRegistry.existing; set by Watcher, whose
+	// polls are separate runs
+	keepExisting bool
+
+	// stop cancels the files of the current run after the first failure
+	// when FailFast is set
+	stop context.CancelCauseFunc
+
+	// schemas compiles the XSD schemas once for all files of the current
+	// run when ValidateSchema is set
+	schemas *validation.SchemaSet
+
+	// source provides the input files while an archive is processed; nil
+	// reads them from disk
+	source fs.FS
+
+	// OnResult is called with the result of every file as soon as it is
+	// collected, in completion order, from the goroutine that runs
+	// ProcessBatchResults. Setting OnResult or OnProgress suppresses the
+	// status messages on stdout and stderr; JSON output is still written.
+	OnResult func(ProcessResult)
+
+	// OnProgress is called after every collected result with the number of
+	// files done so far and the total number of files
+	OnProgress func(done, total int)
+
+	// lines serializes the JSONL output of the workers
+	lines *lineWriter
 }
 
 // ProcessResult holds the result of processing a single file
 type ProcessResult struct {
 	Filename   string
 	OutputPath string
-	Error      error
+	Profile    string
+	Syntax     string
+	FileSize   int64
+	Checksum   string
+
+	// DuplicateOf names the identical input file that was processed instead
+	// of this one, see BatchProcessor.Dedupe
+	DuplicateOf string
+
+	// OutputSize is the size of the extracted XML in bytes
+	OutputSize int64
+
+	// Duration is the time spent processing the file
+	Duration time.Duration
+
+	// Method is the extraction method that found the XML
+	Method Method
+
+	// Verbatim reports that the written XML is the embedded file stream,
+	// byte for byte, see Result.Verbatim
+	Verbatim bool
+
+	// MimeType is the MIME type declared for the XML, see Result.MimeType
+	MimeType string
+
+	// Metadata describes the PDF, see BatchProcessor.IncludeMetadata
+	Metadata *PDFMetadata
+
+	// Timings are the phase durations, see BatchProcessor.IncludeTimings
+	Timings *Timings
+
+	Invoice *invoice.Invoice
+
+	// Violations are the findings of ValidateSchema and ValidateRules in
+	// JSON mode, see ZUGFeRDExtractor.ExtractCheckedXML
+	Violations *Violations
+
+	// XML is the XML as written in JSON mode with XMLBase64, or its root
+	// element with CombinePath set
+	XML []byte
+
+	Skipped bool
+	Error   error
+}
+
+// jsonResult is the JSON representation of a ProcessResult
+type jsonResult struct {
+	File        string           `json:"file"`
+	Invoice     *invoice.Invoice `json:"invoice,omitempty"`
+	XMLBase64   string           `json:"xmlBase64,omitempty"`
+	Violations  *Violations      `json:"violations,omitempty"`
+	PDF         *PDFMetadata     `json:"pdf,omitempty"`
+	Timings     *Timings         `json:"timings,omitempty"`
+	DuplicateOf string           `json:"duplicateOf,omitempty"`
+	Error       string           `json:"error,omitempty"`
 }
 
-// ProcessBatch processes multiple PDF files in parallel
-func (bp *BatchProcessor) ProcessBatch() error {
-	// Find all PDF files matching the pattern
-	files, err := filepath.Glob(bp.InputPattern)
+// jsonLine is one line of the JSONL output
+type jsonLine struct {
+	File       string           `json:"file"`
+	Profile    string           `json:"profile,omitempty"`
+	Invoice    *invoice.Invoice `json:"invoice,omitempty"`
+	XMLBase64  string           `json:"xmlBase64,omitempty"`
+	Violations *Violations      `json:"violations,omitempty"`
+	PDF        *PDFMetadata     `json:"pdf,omitempty"`
+	Timings    *Timings         `json:"timings,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// lineWriter writes JSON values as lines; the mutex keeps the lines of
+// concurrent workers from interleaving
+type lineWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// writeJSON writes v as a single line of JSON
+func (lw *lineWriter) writeJSON(v any) error {
+	data, err := json.Marshal(v)
 	if err != nil {
-		return fmt.Errorf("Fehler beim Suchen von Dateien: %v", err)
+		return err
 	}
 
-	if len(files) == 0 {
-		return fmt.Errorf("Keine PDF-Dateien gefunden, die dem Muster entsprechen: %s", bp.InputPattern)
-	}
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	_, err = lw.w.Write(append(data, '\n'))
+	return err
+}
 
-	// Nur PDF-Dateien filtern
-	pdfFiles := make([]string, 0, len(files))
-	for _, file := range files {
-		if strings.ToLower(filepath.Ext(file)) == ".pdf" {
-			pdfFiles = append(pdfFiles, file)
+// ErrBatchFailures is returned when at least one file of a batch failed;
+// the results tell which ones
+var ErrBatchFailures = errors.New("Dateien der Batch-Verarbeitung fehlgeschlagen")
+
+// errFailFast is the cancellation cause of the files that FailFast kept
+// from being processed
+var errFailFast = errors.New("nicht verarbeitet, Batch-Verarbeitung nach dem ersten Fehler abgebrochen")
+
+// ProcessBatch processes multiple PDF files in parallel. When ctx is
+// cancelled, no further files are started, the files in progress are
+// finished, bounded only by Timeout, and the remaining ones are reported
+// with context.Cause(ctx). If any file fails, the error wraps
+// ErrBatchFailures, or ErrBusinessRules if files violated business rules.
+func (bp *BatchProcessor) ProcessBatch(ctx context.Context) error {
+	_, err := bp.ProcessBatchResults(ctx)
+	return err
+}
+
+// ProcessBatchResults is like ProcessBatch but also returns the result of
+// every file, in the order of the input files. The results are returned
+// even if the batch as a whole fails.
+func (bp *BatchProcessor) ProcessBatchResults(ctx context.Context) ([]ProcessResult, error) {
+	// The state of the previous run must not leak into this one
+	bp.paths, bp.source, bp.lines, bp.schemas, bp.stop = nil, nil, nil, nil, nil
+	defer func() { bp.source, bp.stop = nil, nil }()
+
+	var pdfFiles []string
+	if bp.Archive != "" {
+		archive, err := zip.OpenReader(bp.Archive)
+		if err != nil {
+			return nil, i18n.Errorf(bp.Lang, "Fehler beim Öffnen des Archivs: %v", err)
+		}
+		defer archive.Close()
+		bp.source = &archive.Reader
+		pdfFiles = archivePDFEntries(&archive.Reader)
+		if len(pdfFiles) == 0 {
+			return nil, i18n.Errorf(bp.Lang, "Keine PDF-Dateien im Archiv gefunden: %s", bp.Archive)
+		}
+	} else {
+		var err error
+		if pdfFiles, err = bp.inputFiles(); err != nil {
+			return nil, err
 		}
 	}
 
-	if len(pdfFiles) == 0 {
-		return fmt.Errorf("Keine PDF-Dateien gefunden, die dem Muster entsprechen: %s", bp.InputPattern)
+	status := bp.statusWriter()
+	if !bp.NewerThan.IsZero() {
+		var unchanged int
+		pdfFiles, unchanged = bp.filterNewer(pdfFiles)
+		bp.printf(status, "Nicht geändert seit %s: %d Datei(en) ausgelassen\n", bp.NewerThan.Format(time.RFC3339), unchanged)
 	}
-
-	fmt.Printf("Gefunden: %d PDF-Dateien zur Verarbeitung\n", len(pdfFiles))
+	bp.printf(status, "Gefunden: %d PDF-Dateien zur Verarbeitung\n", len(pdfFiles))
 
 	// Create worker pool
 	jobs := make(chan string, len(pdfFiles))
 	results := make(chan ProcessResult, len(pdfFiles))
 
+	// Duplicates are reported right away and never reach the workers
+	unique := pdfFiles
+	if bp.Dedupe {
+		var duplicates []ProcessResult
+		unique, duplicates = dedupeFiles(pdfFiles, bp.digest)
+		for _, duplicate := range duplicates {
+			results <- duplicate
+		}
+	}
+
+	if !bp.JSONOutput && !bp.JSONLines && !bp.AllAttachments && bp.CombinePath == "" {
+		bp.paths = newPathRegistry(bp.OnCollision)
+		bp.paths.existing = bp.keepExisting
+	}
+	if bp.JSONLines {
+		bp.lines = &lineWriter{w: os.Stdout}
+	}
+	if bp.ValidateSchema {
+		bp.schemas = &validation.SchemaSet{Dir: bp.SchemaDir}
+	}
+
+	// Cancelling ctx only stops dispatching: the files in progress are
+	// extracted with work, which does not inherit the cancellation, so
+	// they are finished instead of abandoned halfway. FailFast cancels
+	// both with a cause of its own, so a cancellation from outside can
+	// still be told apart.
+	parent := ctx
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	work, cancelWork := context.WithCancelCause(context.WithoutCancel(parent))
+	defer cancelWork(nil)
+	if bp.FailFast {
+		bp.stop = func(cause error) {
+			cancel(cause)
+			cancelWork(cause)
+		}
+	}
+
 	// Start workers
 	var wg sync.WaitGroup
-	for i := 0; i < bp.Workers; i++ {
+	for i := 0; i < bp.workerCount(len(unique)); i++ {
 		wg.Add(1)
-		go bp.worker(jobs, results, &wg)
+		go bp.worker(ctx, work, jobs, results, &wg)
 	}
 
 	// Send jobs
-	for _, file := range pdfFiles {
+	for _, file := range unique {
 		jobs <- file
 	}
 	close(jobs)
@@ -74,58 +452,553 @@ func (bp *BatchProcessor) ProcessBatch() error {
 	// Process results
 	successful := 0
 	failed := 0
+	skipped := 0
+	duplicates := 0
+	ruleFailures := 0
+	notProcessed := 0
+	var firstFailure *ProcessResult
+	var jsonResults []jsonResult
+	var allResults []ProcessResult
+	counter := newProgress(status, len(pdfFiles), bp.Verbose, bp.Lang)
 	for result := range results {
-		if result.Error != nil {
-			fmt.Printf("❌ %s: %v\n", result.Filename, result.Error)
+		counter.clear()
+		allResults = append(allResults, result)
+		if errors.Is(result.Error, ErrBusinessRules) {
+			ruleFailures++
+		}
+		if bp.JSONOutput {
+			entry := jsonResult{
+				File:        result.Filename,
+				Invoice:     result.Invoice,
+				XMLBase64:   base64.StdEncoding.EncodeToString(result.XML),
+				Violations:  result.Violations,
+				PDF:         result.Metadata,
+				Timings:     result.Timings,
+				DuplicateOf: result.DuplicateOf,
+			}
+			if result.Error != nil {
+				entry.Error = result.Error.Error()
+			}
+			jsonResults = append(jsonResults, entry)
+		}
+		if ctx.Err() != nil && (errors.Is(result.Error, errFailFast) || errors.Is(result.Error, context.Canceled)) {
+			notProcessed++
+		} else if result.Error != nil {
+			bp.statusf(bp.errorWriter(), StatusFailure, "❌ %s: %v\n", result.Filename, result.Error)
 			failed++
+			if bp.FailFast && firstFailure == nil {
+				first := result
+				firstFailure = &first
+			}
+		} else if result.DuplicateOf != "" {
+			bp.printf(status, "⏭ %s: Duplikat von %s\n", result.Filename, result.DuplicateOf)
+			duplicates++
+		} else if result.Skipped {
+			bp.printf(status, "⏭ %s: übersprungen, %s ist aktuell\n", result.Filename, result.OutputPath)
+			skipped++
 		} else {
-			fmt.Printf("✅ %s -> %s\n", result.Filename, result.OutputPath)
+			if bp.JSONOutput || bp.JSONLines {
+				bp.statusf(status, StatusSuccess, "✅ %s\n", result.Filename)
+			} else {
+				bp.statusf(status, StatusSuccess, "✅ %s -> %s\n", result.Filename, result.OutputPath)
+			}
 			successful++
 		}
+		counter.update(result.Filename)
+
+		if bp.OnResult != nil {
+			bp.OnResult(result)
+		}
+		if bp.OnProgress != nil {
+			bp.OnProgress(len(allResults), len(pdfFiles))
+		}
+	}
+	counter.finish()
+	sortByInput(allResults, pdfFiles)
+
+	if bp.ReportPath != "" {
+		if err := writeReport(bp.ReportPath, allResults, bp.Lang); err != nil {
+			return allResults, err
+		}
+		bp.printf(status, "Bericht geschrieben: %s\n", bp.ReportPath)
+	}
+
+	if bp.CombinePath != "" && !bp.DryRun {
+		if err := writeCombined(bp.CombinePath, allResults, bp.Lang); err != nil {
+			return allResults, err
+		}
+		bp.printf(status, "Sammeldatei geschrieben: %s\n", bp.CombinePath)
+	}
+
+	if bp.JSONOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(jsonResults); err != nil {
+			return allResults, i18n.Errorf(bp.Lang, "Fehler beim Schreiben der JSON-Ausgabe: %v", err)
+		}
+	}
+
+	if bp.Dedupe {
+		bp.printf(status, "\nBatch-Verarbeitung abgeschlossen: %d erfolgreich, %d fehlgeschlagen, %d übersprungen, %d Duplikate\n", successful, failed, skipped, duplicates)
+	} else {
+		bp.printf(status, "\nBatch-Verarbeitung abgeschlossen: %d erfolgreich, %d fehlgeschlagen, %d übersprungen\n", successful, failed, skipped)
+	}
+	if bp.paths != nil {
+		if collisions := bp.paths.collisionCount(); collisions > 0 {
+			bp.printf(status, "Namenskonflikte bei Ausgabedateien: %d (Regel: %s)\n", collisions, bp.paths.policy)
+		}
+	}
+	if notProcessed > 0 {
+		if errors.Is(context.Cause(ctx), errFailFast) {
+			bp.printf(status, "Nach dem ersten Fehler abgebrochen: %d Datei(en) nicht verarbeitet\n", notProcessed)
+		} else {
+			bp.printf(status, "Abgebrochen: %d Datei(en) nicht verarbeitet\n", notProcessed)
+		}
+	}
+	if err := parent.Err(); err != nil {
+		return allResults, i18n.Errorf(bp.Lang, "Batch-Verarbeitung abgebrochen: %w", err)
+	}
+	if bp.DryRun {
+		bp.printf(status, "Probelauf: es wurden keine Dateien geschrieben\n")
+	}
+	if firstFailure != nil {
+		return allResults, i18n.Errorf(bp.Lang, "Batch-Verarbeitung nach dem ersten Fehler abgebrochen: %s: %w", firstFailure.Filename, firstFailure.Error)
+	}
+	if ruleFailures > 0 {
+		return allResults, i18n.Wrap(ErrBusinessRules, bp.Lang, "Geschäftsregeln verletzt in %d Datei(en)", ruleFailures)
+	}
+	if failed > 0 {
+		return allResults, i18n.Wrap(ErrBatchFailures, bp.Lang, "%d von %d Datei(en) fehlgeschlagen", failed, len(pdfFiles))
+	}
+	return allResults, nil
+}
+
+// sortByInput orders results like the input files they belong to
+func sortByInput(results []ProcessResult, files []string) {
+	position := make(map[string]int, len(files))
+	for i, file := range files {
+		position[file] = i
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return position[results[i].Filename] < position[results[j].Filename]
+	})
+}
+
+// dedupeFiles splits files into the first file of every distinct content
+// and results for the later, byte-identical copies. A file that cannot be
+// hashed is kept, so its extraction reports the error.
+func dedupeFiles(files []string, fileDigest func(string) (string, error)) (unique []string, duplicates []ProcessResult) {
+	firstByDigest := make(map[string]string, len(files))
+	for _, file := range files {
+		digest, err := fileDigest(file)
+		if err != nil {
+			unique = append(unique, file)
+			continue
+		}
+		if first, ok := firstByDigest[digest]; ok {
+			duplicates = append(duplicates, ProcessResult{Filename: file, DuplicateOf: first})
+			continue
+		}
+		firstByDigest[digest] = file
+		unique = append(unique, file)
+	}
+	return unique, duplicates
+}
+
+// workerCount returns the number of workers to start for the given number
+// of files; Workers < 1 means one worker per CPU core
+func (bp *BatchProcessor) workerCount(files int) int {
+	workers := bp.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > files {
+		workers = files
+	}
+	return workers
+}
+
+// isUpToDate reports whether outputPath exists and is newer than the input
+func isUpToDate(outputPath string, input fs.FileInfo) bool {
+	if outputPath == "" || input == nil {
+		return false
+	}
+	output, err := os.Stat(outputPath)
+	if err != nil {
+		return false
+	}
+	return output.ModTime().After(input.ModTime())
+}
+
+// inputFiles returns the PDF files of Files or, if Files is empty, of the
+// expanded InputPattern
+func (bp *BatchProcessor) inputFiles() ([]string, error) {
+	files := bp.Files
+	fromPattern := len(files) == 0
+	if fromPattern {
+		var err error
+		if files, err = ExpandPattern(bp.InputPattern); err != nil {
+			return nil, i18n.Errorf(bp.Lang, "Fehler beim Suchen von Dateien: %v", err)
+		}
+	}
+
+	pdfFiles := make([]string, 0, len(files))
+	for _, file := range files {
+		if strings.ToLower(filepath.Ext(file)) == ".pdf" {
+			pdfFiles = append(pdfFiles, file)
+		}
+	}
+	if len(pdfFiles) > 0 {
+		return pdfFiles, nil
+	}
+	if fromPattern {
+		return nil, i18n.Errorf(bp.Lang, "Keine PDF-Dateien gefunden, die dem Muster entsprechen: %s", bp.InputPattern)
+	}
+	return nil, i18n.Errorf(bp.Lang, "Keine PDF-Dateien in der Dateiliste gefunden (%d Einträge)", len(files))
+}
+
+// stat returns the file info of an input file
+func (bp *BatchProcessor) stat(filename string) (fs.FileInfo, error) {
+	if bp.source != nil {
+		return fs.Stat(bp.source, filename)
+	}
+	return os.Stat(filename)
+}
+
+// filterNewer returns the files modified after NewerThan and the number of
+// files left out. A file that cannot be stat'ed is kept, so its extraction
+// reports the error.
+func (bp *BatchProcessor) filterNewer(files []string) (newer []string, unchanged int) {
+	for _, file := range files {
+		if info, err := bp.stat(file); err == nil && !info.ModTime().After(bp.NewerThan) {
+			unchanged++
+			continue
+		}
+		newer = append(newer, file)
+	}
+	return newer, unchanged
+}
+
+// digest returns the SHA-256 digest of an input file
+func (bp *BatchProcessor) digest(filename string) (string, error) {
+	if bp.source == nil {
+		return fileDigest(filename)
+	}
+	file, err := bp.source.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return readerDigest(file)
+}
+
+// outputBase returns the output directory and the base name of the output
+// for an input file. The directory is empty if the output belongs next to
+// the input PDF.
+func (bp *BatchProcessor) outputBase(filename string) (dir, baseName string) {
+	if bp.source == nil {
+		_, baseName = splitPDFPath(filename, windowsPaths)
+		return bp.OutputDir, baseName
+	}
+
+	root := bp.OutputDir
+	if root == "" {
+		root = filepath.Dir(bp.Archive)
+	}
+	baseName = path.Base(filename)
+	return filepath.Join(root, filepath.FromSlash(path.Dir(filename))), strings.TrimSuffix(baseName, path.Ext(baseName))
+}
+
+// CollectPDFFiles walks root recursively and returns all PDF files in it.
+// Errors are translated into lang.
+func CollectPDFFiles(root string, lang string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.ToLower(filepath.Ext(path)) == ".pdf" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, i18n.Errorf(lang, "Fehler beim Durchsuchen des Verzeichnisses: %v", err)
 	}
+	return files, nil
+}
 
-	fmt.Printf("\nBatch-Verarbeitung abgeschlossen: %d erfolgreich, %d fehlgeschlagen\n", successful, failed)
-	return nil
+// printf writes a translated status message to w
+func (bp *BatchProcessor) printf(w io.Writer, format string, args ...any) {
+	fmt.Fprint(w, i18n.Sprintf(bp.Lang, format, args...))
 }
 
-// worker processes files from the jobs channel
-func (bp *BatchProcessor) worker(jobs <-chan string, results chan<- ProcessResult, wg *sync.WaitGroup) {
+// statusf is printf for a status line colored by status, see WriteStatus
+func (bp *BatchProcessor) statusf(w io.Writer, status Status, format string, args ...any) {
+	WriteStatus(w, status, i18n.Sprintf(bp.Lang, format, args...))
+}
+
+// statusWriter returns the destination for status messages, which is stderr
+// when stdout carries the JSON output. A caller that receives the results
+// through OnResult or OnProgress gets no status messages.
+func (bp *BatchProcessor) statusWriter() io.Writer {
+	if bp.Quiet || bp.OnResult != nil || bp.OnProgress != nil {
+		return io.Discard
+	}
+	if bp.JSONOutput || bp.JSONLines {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// errorWriter returns the destination for the failures of single files;
+// in quiet mode they still go to stderr
+func (bp *BatchProcessor) errorWriter() io.Writer {
+	if bp.Quiet && bp.OnResult == nil && bp.OnProgress == nil {
+		return os.Stderr
+	}
+	return bp.statusWriter()
+}
+
+// defaultRetryBackoff is the wait before the first retry if
+// BatchProcessor.RetryBackoff is not set
+const defaultRetryBackoff = 500 * time.Millisecond
+
+// retry calls attempt until it succeeds, fails with an error that is not
+// transient or Retries retries are used up. The wait between two attempts
+// starts at RetryBackoff and doubles every time; a cancelled ctx ends the
+// wait and returns the last error.
+func (bp *BatchProcessor) retry(ctx context.Context, extractor *ZUGFeRDExtractor, attempt func() error) error {
+	backoff := bp.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	err := attempt()
+	for retry := 1; retry <= bp.Retries && IsTransient(err); retry++ {
+		extractor.warnf("Warnung: Vorübergehender Fehler bei %s, Wiederholung %d von %d in %s: %v", extractor.InputPath, retry, bp.Retries, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+		err = attempt()
+	}
+	return err
+}
+
+// worker processes files from the jobs channel with the work context;
+// once ctx is cancelled, the remaining jobs are drained without being
+// processed
+func (bp *BatchProcessor) worker(ctx, work context.Context, jobs <-chan string, results chan<- ProcessResult, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	for filename := range jobs {
-		// Bestimme Ausgabepfad
-		var outputPath string
-		if bp.OutputDir != "" {
-			baseName := filepath.Base(filename)
-			baseName = strings.TrimSuffix(baseName, filepath.Ext(baseName))
-			outputPath = filepath.Join(bp.OutputDir, baseName+".xml")
+		if ctx.Err() != nil {
+			results <- ProcessResult{Filename: filename, Error: context.Cause(ctx)}
+			continue
+		}
+		bp.processFile(work, filename, results)
+	}
+}
+
+// processFile processes a single file and sends its result. A panic, e.g.
+// of pdfcpu on a malformed PDF, fails only this file; the deferred cleanup
+// of the extraction, such as removing its temporary directory, has run by
+// the time it is recovered.
+func (bp *BatchProcessor) processFile(ctx context.Context, filename string, results chan<- ProcessResult) {
+	sent := false
+	send := func(result ProcessResult) {
+		sent = true
+		// Stop the other workers before the result is queued behind theirs
+		if result.Error != nil && bp.stop != nil {
+			bp.stop(i18n.Wrap(errFailFast, bp.Lang, "nicht verarbeitet, Batch-Verarbeitung nach dem ersten Fehler abgebrochen"))
+		}
+		results <- result
+	}
+	defer func() {
+		if r := recover(); r != nil && !sent {
+			send(ProcessResult{Filename: filename, Error: i18n.Errorf(bp.Lang, "interner Fehler bei der Verarbeitung: %v", r)})
 		}
+	}()
 
-		extractor := &ZUGFeRDExtractor{
-			InputPath:  filename,
-			OutputPath: outputPath,
-			Verbose:    bp.Verbose,
+	// Bestimme Ausgabepfad
+	outputDir, baseName := bp.outputBase(filename)
+	var outputPath string
+	if outputDir != "" && !bp.PreserveOriginalName && bp.NameTemplate == "" {
+		outputPath = joinOutputPath(outputDir, baseName+".xml", windowsPaths)
+		if bp.Gzip {
+			outputPath = gzipPath(outputPath)
 		}
+	}
+
+	// Decide before opening the PDF so skipped files cost no extraction
+	info, statErr := bp.stat(filename)
+	if bp.SkipExisting && !bp.JSONOutput && !bp.JSONLines && !bp.AllAttachments && bp.CombinePath == "" && isUpToDate(outputPath, info) {
+		send(ProcessResult{Filename: filename, OutputPath: outputPath, Skipped: true})
+		return
+	}
 
-		err := extractor.ExtractXML()
+	started := time.Now()
+	extractor := &ZUGFeRDExtractor{
+		InputPath:            filename,
+		OutputPath:           outputPath,
+		OutputDir:            outputDir,
+		PreserveOriginalName: bp.PreserveOriginalName,
+		NameTemplate:         bp.NameTemplate,
+		AdditionalFilenames:  bp.AdditionalFilenames,
+		Verbose:              bp.Verbose,
+		ValidateRules:        bp.ValidateRules,
+		CheckLeitwegID:       bp.CheckLeitwegID,
+		ValidateSchema:       bp.ValidateSchema,
+		SchemaDir:            bp.SchemaDir,
+		NoClobber:            bp.NoClobber,
+		MinConfidence:        bp.MinConfidence,
+		NoManual:             bp.NoManual,
+		DryRun:               bp.DryRun,
+		Checksum:             bp.Checksum,
+		IncludeMetadata:      bp.IncludeMetadata,
+		IncludeTimings:       bp.IncludeTimings,
+		Pretty:               bp.Pretty,
+		Transformers:         bp.Transformers,
+		StrictMimeType:       bp.StrictMimeType,
+		StripBOM:             bp.StripBOM,
+		Gzip:                 bp.Gzip,
+		VerifyVerbatim:       bp.VerifyVerbatim,
+		Method:               bp.Method,
+		Timeout:              bp.Timeout,
+		TempDir:              bp.TempDir,
+		KeepTemp:             bp.KeepTemp,
+		Password:             bp.Password,
+		Logger:               bp.Logger,
+		Lang:                 bp.Lang,
+		paths:                bp.paths,
+		schemas:              bp.schemas,
+		status:               bp.statusWriter(),
+	}
 
-		// Ermittle tatsächlichen Ausgabepfad für die Erfolgsbenachrichtigung
-		result := ProcessResult{
-			Filename: filename,
-			Error:    err,
+	// Archive entries are read into memory; errors name the archive
+	if bp.source != nil {
+		extractor.InputPath = filepath.Join(bp.Archive, filepath.FromSlash(filename))
+		data, err := fs.ReadFile(bp.source, filename)
+		if err != nil {
+			send(ProcessResult{Filename: filename, Error: extractor.errorf(ErrIO, "Fehler beim Lesen der PDF: %v", err)})
+			return
 		}
+		extractor.input = data
+	}
 
+	if bp.CombinePath != "" {
+		var xmlData []byte
+		var method Method
+		err := bp.retry(ctx, extractor, func() (err error) {
+			xmlData, _, method, err = extractor.extractXMLData(ctx)
+			return err
+		})
+		result := ProcessResult{Filename: filename, Method: method, Duration: time.Since(started), Error: err}
 		if err == nil {
-			// Wenn kein expliziter Ausgabepfad angegeben wurde, nehmen wir den generierten von ExtractXML
-			if outputPath == "" {
-				baseName := filepath.Base(filename)
-				baseName = strings.TrimSuffix(baseName, filepath.Ext(baseName))
-				result.OutputPath = filepath.Join(filepath.Dir(filename), baseName+".xml")
-			} else {
-				result.OutputPath = outputPath
+			validator := &validation.Validator{Lang: bp.Lang}
+			result.Profile, _ = validator.DetectProfile(xmlData)
+			result.Syntax, _ = validator.DetectSyntax(xmlData)
+			result.OutputSize = int64(len(xmlData))
+			var output []byte
+			if output, result.Error = extractor.outputXML(xmlData); result.Error == nil {
+				result.XML, result.Error = combinedXML(output, bp.Lang)
 			}
 		}
+		if result.Error == nil && bp.ValidateSchema {
+			result.Error = extractor.checkSchema(xmlData)
+		}
+		if result.Error == nil && bp.ValidateRules {
+			result.Error = extractor.checkBusinessRules(xmlData)
+		}
+		if statErr == nil {
+			result.FileSize = info.Size()
+		}
+		send(result)
+		return
+	}
 
-		results <- result
+	if bp.AllAttachments {
+		baseDir := outputDir
+		if baseDir == "" {
+			baseDir = filepath.Dir(filename)
+		}
+		attachmentDir := filepath.Join(baseDir, baseName)
+		err := bp.retry(ctx, extractor, func() error {
+			_, err := extractor.extractAllAttachments(ctx, attachmentDir)
+			return err
+		})
+		send(ProcessResult{Filename: filename, OutputPath: attachmentDir, Duration: time.Since(started), Error: err})
+		return
 	}
+
+	if bp.JSONOutput || bp.JSONLines {
+		var inv *invoice.Invoice
+		var xmlData []byte
+		var profile string
+		var method Method
+		err := bp.retry(ctx, extractor, func() (err error) {
+			xmlData, _, method, err = extractor.extractXMLData(ctx)
+			if err != nil {
+				return err
+			}
+			inv, profile, err = extractor.parseInvoice(xmlData)
+			return err
+		})
+		result := ProcessResult{Filename: filename, Profile: profile, Method: method, Invoice: inv, Duration: time.Since(started), Error: err}
+		if err == nil {
+			if bp.XMLBase64 {
+				result.XML, result.Error = extractor.outputXML(xmlData)
+			}
+			// A failed check fails the file; its findings are still output
+			if result.Error == nil {
+				result.Violations, result.Error = extractor.checkViolations(xmlData)
+			}
+			if bp.IncludeMetadata {
+				result.Metadata = extractor.pdfMetadata()
+			}
+			result.Timings = extractor.finishTimings()
+		}
+		if bp.JSONLines {
+			line := jsonLine{File: filename, Profile: profile, Invoice: inv, XMLBase64: base64.StdEncoding.EncodeToString(result.XML), Violations: result.Violations, PDF: result.Metadata, Timings: result.Timings}
+			if result.Error != nil {
+				line.Error = result.Error.Error()
+			}
+			if writeErr := bp.lines.writeJSON(line); writeErr != nil && result.Error == nil {
+				result.Error = i18n.Errorf(bp.Lang, "Fehler beim Schreiben der JSON-Ausgabe: %v", writeErr)
+			}
+		}
+		send(result)
+		return
+	}
+
+	var extracted Result
+	err := bp.retry(ctx, extractor, func() (err error) {
+		extracted, err = extractor.ExtractXMLResult(ctx)
+		return err
+	})
+	result := ProcessResult{
+		Filename:   filename,
+		OutputPath: extracted.OutputPath,
+		Profile:    extracted.Profile,
+		Syntax:     extracted.Syntax,
+		Checksum:   extracted.Checksum,
+		OutputSize: int64(extracted.Size),
+		Duration:   time.Since(started),
+		Method:     extracted.Method,
+		Verbatim:   extracted.Verbatim,
+		MimeType:   extracted.MimeType,
+		Metadata:   extracted.Metadata,
+		Timings:    extracted.Timings,
+		Error:      err,
+	}
+	if statErr == nil {
+		result.FileSize = info.Size()
+	}
+
+	send(result)
 }
@@ -0,0 +1,63 @@
+package extractor
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// checksumHashes maps the supported checksum algorithms to their hash
+// constructors
+var checksumHashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha1":   sha1.New,
+}
+
+// IsChecksumAlgorithm reports whether algorithm is supported by Checksum;
+// the name is compared case-insensitively
+func IsChecksumAlgorithm(algorithm string) bool {
+	_, ok := checksumHashes[strings.ToLower(algorithm)]
+	return ok
+}
+
+// Checksum returns the hex-encoded hash of data using algorithm, which is
+// either "sha256" or "sha1"
+func Checksum(data []byte, algorithm string) (string, error) {
+	return checksum(data, algorithm, "")
+}
+
+// fileDigest returns the SHA-256 digest of the file at path. The file is
+// streamed through the hash instead of being read into memory.
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return readerDigest(file)
+}
+
+// readerDigest returns the SHA-256 digest of everything read from r
+func readerDigest(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func checksum(data []byte, algorithm, lang string) (string, error) {
+	newHash, ok := checksumHashes[strings.ToLower(algorithm)]
+	if !ok {
+		return "", i18n.Errorf(lang, "unbekannter Prüfsummen-Algorithmus: %s (erlaubt: sha256, sha1)", algorithm)
+	}
+	h := newHash()
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,122 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/validation"
+)
+
+// Element names of the wrapper document written by writeCombined
+const (
+	combinedRoot  = "invoices"
+	combinedEntry = "invoice"
+)
+
+// combinedXML returns the root element of an extracted XML for embedding
+// into the wrapper document. The XML declaration, a byte order mark and
+// everything else outside the root element is dropped; the root element
+// keeps its namespace declarations, so the embedded document reads the
+// same as on its own. The wrapper is UTF-8, so other encodings are
+// rejected.
+func combinedXML(data []byte, lang string) ([]byte, error) {
+	data = bytes.TrimPrefix(data, validation.UTF8BOM)
+	validator := &validation.Validator{Lang: lang}
+	if info := validator.DetectEncoding(data); info.Detected != validation.EncodingUTF8 && info.Detected != validation.EncodingASCII {
+		return nil, i18n.Errorf(lang, "XML ist nicht UTF-8-kodiert (%s) und kann nicht in die Sammeldatei übernommen werden", info.Detected)
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	start, depth := int64(-1), 0
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return nil, i18n.Errorf(lang, "XML enthält kein Wurzelelement")
+		}
+		if err != nil {
+			return nil, i18n.Errorf(lang, "XML ist nicht wohlgeformt: %v", err)
+		}
+
+		switch token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				start = offset
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				return data[start:decoder.InputOffset()], nil
+			}
+		}
+	}
+}
+
+// writeCombined writes the results into one wrapper document, in the order
+// given: an invoice element per file with the source file and what is
+// known about its XML as attributes and the XML itself as content. Files
+// without XML, e.g. failed ones or duplicates, get an empty element. The
+// wrapper declares no namespace, so it cannot change the meaning of the
+// embedded documents.
+func writeCombined(path string, results []ProcessResult, lang string) error {
+	file, err := createAtomic(path)
+	if err != nil {
+		return i18n.Errorf(lang, "Fehler beim Erstellen der Sammeldatei: %v", err)
+	}
+	defer file.abort()
+
+	w := bufio.NewWriter(file)
+	w.WriteString(xml.Header)
+	w.WriteString("<" + combinedRoot)
+	writeAttr(w, "count", strconv.Itoa(len(results)))
+	writeAttr(w, "created", time.Now().UTC().Format(time.RFC3339))
+	w.WriteString(">\n")
+
+	for _, result := range results {
+		w.WriteString("  <" + combinedEntry)
+		writeAttr(w, "source", result.Filename)
+		writeAttr(w, "profile", result.Profile)
+		writeAttr(w, "syntax", result.Syntax)
+		writeAttr(w, "method", string(result.Method))
+		writeAttr(w, "duplicateOf", result.DuplicateOf)
+		if result.Error != nil {
+			writeAttr(w, "error", result.Error.Error())
+		}
+		if len(result.XML) == 0 {
+			w.WriteString("/>\n")
+			continue
+		}
+		w.WriteString(">\n")
+		w.Write(result.XML)
+		w.WriteString("\n  </" + combinedEntry + ">\n")
+	}
+	w.WriteString("</" + combinedRoot + ">\n")
+
+	if err := w.Flush(); err != nil {
+		return i18n.Errorf(lang, "Fehler beim Schreiben der Sammeldatei: %v", err)
+	}
+	if err := file.commit(); err != nil {
+		return i18n.Errorf(lang, "Fehler beim Schreiben der Sammeldatei: %v", err)
+	}
+	return nil
+}
+
+// writeAttr writes an escaped attribute; empty values are left out
+func writeAttr(w *bufio.Writer, name, value string) {
+	if value == "" {
+		return
+	}
+	w.WriteString(" " + name + `="`)
+	xml.EscapeText(w, []byte(value))
+	w.WriteString(`"`)
+}
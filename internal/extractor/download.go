@@ -0,0 +1,84 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// downloadName is the input name of a URL without a file name in its path
+const downloadName = "download.pdf"
+
+// IsURL reports whether input is an http or https URL
+func IsURL(input string) bool {
+	u, err := url.Parse(input)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")
+}
+
+// LoadURL downloads the PDF at rawURL into memory, so that the extractor
+// reads it instead of a file. InputPath is set to the file name of the URL
+// path, which places the XML in the current directory unless OutputDir or
+// OutputPath is set. The download honours HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY and is limited by Timeout like the extraction. Messages show
+// the URL without its query, which may hold the signature of a signed URL.
+func (z *ZUGFeRDExtractor) LoadURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return z.errorf(ErrIO, "Ungültige URL: %v", err)
+	}
+	display := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String()
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = downloadName
+	}
+	z.InputPath = name
+
+	if z.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, z.Timeout)
+		defer cancel()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return z.errorf(ErrIO, "Ungültige URL: %v", err)
+	}
+
+	downloadError := func(err error) error {
+		if errors.Is(err, context.DeadlineExceeded) && z.Timeout > 0 {
+			return z.errorf(ErrIO, "Zeitüberschreitung beim Herunterladen von %s nach %s", display, z.Timeout)
+		}
+		// The error of the client repeats the complete URL
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return z.errorf(ErrIO, "Fehler beim Herunterladen von %s: %v", display, err)
+	}
+
+	// The default transport takes the proxy from the environment
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return downloadError(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return z.errorf(ErrIO, "Fehler beim Herunterladen von %s: HTTP-Status %s", display, response.Status)
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return downloadError(err)
+	}
+
+	z.logf("PDF heruntergeladen: %s (%d Bytes)\n", display, len(data))
+	z.input = data
+	return nil
+}
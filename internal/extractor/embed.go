@@ -0,0 +1,204 @@
+package extractor
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/validation"
+)
+
+// knownProfiles lists the profiles accepted by EmbedXML
+var knownProfiles = []string{
+	validation.ProfileMinimum,
+	validation.ProfileBasicWL,
+	validation.ProfileBasic,
+	validation.ProfileComfort,
+	validation.ProfileEN16931,
+	validation.ProfileExtended,
+	validation.ProfileXRechnung,
+}
+
+// EmbedXML attaches the invoice XML at xmlPath to the PDF at pdfPath and
+// writes the result to outputPath. The attachment is named after the
+// profile and version (factur-x.xml, xrechnung.xml, zugferd-invoice.xml for
+// ZUGFeRD 2.0 or ZUGFeRD-invoice.xml for ZUGFeRD 1.0), has the MIME type
+// text/xml and the AFRelationship Alternative, and is referenced from the
+// AF array of the catalog. An empty profile is detected from the XML. The
+// XMP metadata of the PDF is not changed.
+//
+// EmbedXML fails if the PDF already contains a ZUGFeRD XML or a file with
+// the same name.
+func EmbedXML(pdfPath, xmlPath, outputPath string, profile string) error {
+	z := &ZUGFeRDExtractor{InputPath: pdfPath}
+	return z.Embed(xmlPath, outputPath, profile)
+}
+
+// Embed attaches the XML at xmlPath to the input PDF like EmbedXML, using
+// the language and password of the extractor
+func (z *ZUGFeRDExtractor) Embed(xmlPath, outputPath string, profile string) error {
+	xmlData, err := os.ReadFile(xmlPath)
+	if err != nil {
+		return z.errorf(ErrIO, "Fehler beim Lesen der XML-Datei: %v", err)
+	}
+	if !z.isZUGFeRDXML(xmlData) {
+		return i18n.Errorf(z.Lang, "%s ist keine ZUGFeRD-XML", xmlPath)
+	}
+	if err := z.checkWellFormed(xmlData); err != nil {
+		return err
+	}
+
+	profile, err = embedProfile(xmlData, profile, z.Lang)
+	if err != nil {
+		return err
+	}
+	filename := embeddedXMLFilename(xmlData, profile)
+
+	input, err := z.openInput()
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	ctx, err := z.readPDFContext(input)
+	if err != nil {
+		return z.errorf(ErrPDFParse, "PDF konnte nicht gelesen werden: %v", err)
+	}
+	if err := z.checkEmbedConflict(ctx, filename); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(xmlPath)
+	if err != nil {
+		return z.errorf(ErrIO, "Fehler beim Lesen der XML-Datei: %v", err)
+	}
+	if err := addAlternativeAttachment(ctx, filename, profile, xmlData, info.ModTime()); err != nil {
+		return i18n.Errorf(z.Lang, "Anhang konnte nicht eingebettet werden: %v", err)
+	}
+
+	if err := api.WriteContextFile(ctx, outputPath); err != nil {
+		return z.errorf(ErrIO, "Fehler beim Schreiben der PDF: %v", err)
+	}
+	return nil
+}
+
+// embedProfile returns the normalized profile for the attachment. A given
+// profile must be known and match the profile declared in the XML.
+func embedProfile(xmlData []byte, profile, lang string) (string, error) {
+	validator := &validation.Validator{Lang: lang}
+	detected, detectErr := validator.DetectProfile(xmlData)
+	if profile == "" {
+		if detectErr != nil {
+			return "", detectErr
+		}
+		return detected, nil
+	}
+
+	normalized := strings.ToUpper(strings.TrimSpace(profile))
+	known := false
+	for _, candidate := range knownProfiles {
+		if candidate == normalized {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return "", i18n.Errorf(lang, "unbekanntes Profil: %s", profile)
+	}
+	if detectErr == nil && detected != normalized {
+		return "", i18n.Errorf(lang, "Profil %s passt nicht zum Profil der XML (%s)", normalized, detected)
+	}
+	return normalized, nil
+}
+
+// embeddedXMLFilename returns the attachment name the standards prescribe
+// for the XML
+func embeddedXMLFilename(xmlData []byte, profile string) string {
+	if profile == validation.ProfileXRechnung {
+		return validation.FilenameXRechnung
+	}
+	validator := &validation.Validator{}
+	if _, expected := validator.CheckFilenameConsistency("", xmlData); expected != "" {
+		return expected
+	}
+	return validation.FilenameFacturX
+}
+
+// checkEmbedConflict fails if the PDF already has an attachment named
+// filename or any attachment with a standard ZUGFeRD name
+func (z *ZUGFeRDExtractor) checkEmbedConflict(ctx *model.Context, filename string) error {
+	specs, err := z.readFileSpecs(ctx)
+	if err != nil {
+		return i18n.Errorf(z.Lang, "PDF konnte nicht gelesen werden: %v", err)
+	}
+	for _, spec := range specs {
+		name := attachmentBase(spec.name)
+		if strings.EqualFold(name, filename) {
+			return i18n.Errorf(z.Lang, "PDF enthält bereits einen Anhang %s", name)
+		}
+		for _, known := range KnownXMLFilenames {
+			if strings.EqualFold(name, known) {
+				return i18n.Errorf(z.Lang, "PDF enthält bereits eine ZUGFeRD-XML: %s", name)
+			}
+		}
+	}
+	return nil
+}
+
+// addAlternativeAttachment adds data as embedded file with the ZUGFeRD
+// specific file specification entries. pdfcpu's AddAttachment does not set
+// AFRelationship or the MIME type, so the file specification is built here.
+func addAlternativeAttachment(ctx *model.Context, filename, profile string, data []byte, modTime time.Time) error {
+	xRefTable := ctx.XRefTable
+	if err := xRefTable.LocateNameTree("EmbeddedFiles", true); err != nil {
+		return err
+	}
+
+	attachment := model.Attachment{
+		Reader:  bytes.NewReader(data),
+		ID:      filename,
+		Desc:    "ZUGFeRD invoice (" + profile + ")",
+		ModTime: &modTime,
+	}
+	d, err := xRefTable.NewFileSpecDictForAttachment(attachment)
+	if err != nil {
+		return err
+	}
+	d.InsertName("AFRelationship", "Alternative")
+
+	// The embedded file stream carries the MIME type as Subtype
+	if ef, ok := d.Find("EF"); ok {
+		if efDict, ok := ef.(types.Dict); ok {
+			if sd, _, err := xRefTable.DereferenceStreamDict(efDict["F"]); err == nil && sd != nil {
+				sd.InsertName("Subtype", "text#2Fxml")
+			}
+		}
+	}
+
+	ir, err := xRefTable.IndRefForNewObject(d)
+	if err != nil {
+		return err
+	}
+	names := model.NameMap{filename: []types.Dict{d}}
+	if err := xRefTable.Names["EmbeddedFiles"].Add(xRefTable, filename, *ir, names, []string{"F", "UF"}); err != nil {
+		return err
+	}
+
+	// PDF/A-3 requires associated files to be listed in the catalog
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return err
+	}
+	af, err := xRefTable.DereferenceArray(catalog["AF"])
+	if err != nil {
+		return err
+	}
+	catalog["AF"] = append(af, *ir)
+	return nil
+}
@@ -0,0 +1,29 @@
+package extractor
+
+import (
+	"zugferd-extractor/internal/validation"
+)
+
+// checkEncoding warns about an XML whose declared encoding contradicts its
+// bytes and about a leading byte order mark that is kept, as both trip up
+// strict parsers downstream; the XML is written nevertheless
+func (z *ZUGFeRDExtractor) checkEncoding(xmlData []byte) validation.EncodingInfo {
+	validator := &validation.Validator{Lang: z.Lang}
+	info := validator.DetectEncoding(xmlData)
+	if info.Mismatch() {
+		z.warnf("Warnung: XML deklariert die Kodierung %s, ist aber %s kodiert", declaredEncoding(info), info.Detected)
+	}
+	if info.BOM && !z.StripBOM {
+		z.warnf("Warnung: XML beginnt mit einem UTF-8-BOM")
+	}
+	return info
+}
+
+// declaredEncoding returns the declared encoding for messages, with UTF-8
+// for a document without declaration
+func declaredEncoding(info validation.EncodingInfo) string {
+	if info.Declared == "" {
+		return validation.EncodingUTF8
+	}
+	return info.Declared
+}
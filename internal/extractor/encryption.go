@@ -0,0 +1,48 @@
+package extractor
+
+import (
+	"errors"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// newConfiguration returns the pdfcpu configuration for reading the input,
+// carrying the password for encrypted PDFs
+func (z *ZUGFeRDExtractor) newConfiguration() *model.Configuration {
+	config := model.NewDefaultConfiguration()
+	config.UserPW = z.Password
+	config.OwnerPW = z.Password
+	return config
+}
+
+// checkEncryption reports whether the PDF is encrypted. It fails with
+// ErrEncryptedPDF if the PDF cannot be opened with the configured password.
+// Other read errors are left to the extraction methods, which cope with
+// damaged files.
+func (z *ZUGFeRDExtractor) checkEncryption() (bool, error) {
+	input, err := z.openInput()
+	if err != nil {
+		return false, err
+	}
+	defer input.Close()
+
+	ctx, err := z.readPDFContext(input)
+	if errors.Is(err, ErrEncryptedPDF) {
+		return true, err
+	}
+	if err != nil {
+		return false, nil
+	}
+	if ctx.Encrypt != nil {
+		z.logf("PDF ist verschlüsselt\n")
+	}
+	return ctx.Encrypt != nil, nil
+}
+
+// encryptedError creates an ExtractError of kind ErrEncrypted that wraps
+// ErrEncryptedPDF
+func (z *ZUGFeRDExtractor) encryptedError(format string, args ...any) error {
+	return &ExtractError{Kind: ErrEncrypted, Path: z.InputPath, Err: i18n.Wrap(ErrEncryptedPDF, z.Lang, format, args...)}
+}
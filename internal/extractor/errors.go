@@ -0,0 +1,77 @@
+package extractor
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// ErrorKind categorizes the reason an extraction failed
+type ErrorKind int
+
+// Failure categories of an ExtractError
+const (
+	// ErrIO means a file could not be read or written
+	ErrIO ErrorKind = iota + 1
+	// ErrPDFParse means the PDF could not be parsed by any extraction method
+	ErrPDFParse
+	// ErrNoAttachments means the PDF contains no embedded files
+	ErrNoAttachments
+	// ErrNoZUGFeRDXML means none of the embedded files is a ZUGFeRD XML
+	ErrNoZUGFeRDXML
+	// ErrEncrypted means the PDF is encrypted and could not be decrypted;
+	// the error wraps ErrEncryptedPDF
+	ErrEncrypted
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrIO:
+		return "io"
+	case ErrPDFParse:
+		return "pdf-parse"
+	case ErrNoAttachments:
+		return "no-attachments"
+	case ErrNoZUGFeRDXML:
+		return "no-zugferd-xml"
+	case ErrEncrypted:
+		return "encrypted"
+	}
+	return "unknown"
+}
+
+// ExtractError is returned by the extraction functions and carries the
+// failure category, so callers can tell the causes apart with errors.As
+type ExtractError struct {
+	Kind ErrorKind
+	// Path is the input PDF, empty when reading from a stream
+	Path string
+	Err  error
+
+	// cause is the underlying error the message was formatted from, if any
+	cause error
+}
+
+func (e *ExtractError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExtractError) Unwrap() error {
+	return e.Err
+}
+
+// IsTransient reports whether err is an I/O failure that may succeed when
+// the file is processed again, e.g. "resource temporarily unavailable" on a
+// network file system. A missing file, denied access, an existing output
+// file with NoClobber and all failures of the other kinds are permanent.
+func IsTransient(err error) bool {
+	var extractErr *ExtractError
+	if !errors.As(err, &extractErr) || extractErr.Kind != ErrIO {
+		return false
+	}
+	for _, permanent := range []error{fs.ErrNotExist, fs.ErrPermission, fs.ErrExist, fs.ErrInvalid} {
+		if errors.Is(extractErr.cause, permanent) {
+			return false
+		}
+	}
+	return true
+}
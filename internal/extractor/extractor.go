@@ -1,15 +1,26 @@
 package extractor
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pdfcpu/pdfcpu/pkg/api"
 	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/invoice"
+	"zugferd-extractor/internal/validation"
 )
 
 // ZUGFeRDExtractor handles the extraction of XML data from ZUGFeRD PDF files
@@ -17,6 +28,165 @@ type ZUGFeRDExtractor struct {
 	InputPath  string
 	OutputPath string
 	Verbose    bool
+
+	// Quiet suppresses all status messages, such as the success message;
+	// errors are still returned and the log messages are up to Logger
+	Quiet bool
+
+	// OutputDir is the directory for generated output filenames; it defaults
+	// to the directory of the input PDF and is ignored if OutputPath is set.
+	// An output in OutputDir is named after the PDF, as in a batch run, so
+	// the outputs of several PDFs do not collide on a standard name.
+	OutputDir string
+
+	// PreserveOriginalName always names the output after the embedded
+	// attachment, even if it is not a standard ZUGFeRD filename
+	PreserveOriginalName bool
+
+	// NameTemplate names the output file after invoice fields, e.g.
+	// "{invoiceNumber}_{date}.xml"; see NameTemplateFields. If the invoice
+	// cannot be parsed or a field is empty, the PDF name is used instead.
+	// An explicit OutputPath takes precedence.
+	NameTemplate string
+
+	// AdditionalFilenames are project-specific attachment names such as
+	// "einvoice.xml". They are tried after KnownXMLFilenames and before any
+	// other XML attachment; like those they are compared case-insensitively.
+	AdditionalFilenames []string
+
+	// ValidateRules runs the EN16931 business rule checks after extraction
+	// and fails if a rule with error severity is violated
+	ValidateRules bool
+
+	// CheckLeitwegID adds the Leitweg-ID format check of the buyer
+	// reference to the rule checks of XRechnung documents
+	CheckLeitwegID bool
+
+	// ValidateSchema checks the element structure of the extracted XML
+	// against the schema of its version and fails if it does not conform.
+	// The bundled schemas are a simplified subset of the official ones, so
+	// this is no full XSD conformance check, see
+	// validation.Validator.ValidateAgainstXSD.
+	ValidateSchema bool
+
+	// SchemaDir is a directory of XSD files used by ValidateSchema instead
+	// of the bundled schemas, see validation.Validator
+	SchemaDir string
+
+	// NoManual ends the automatic method cascade after the annotation
+	// method, so the XML is never reconstructed from the raw PDF bytes.
+	// If no method finds the XML, the error of the relaxed method is
+	// returned, not those of the AF or annotation method, since it explains
+	// best why pdfcpu could not read the PDF.
+	NoManual bool
+
+	// MinConfidence is the number of ZUGFeRD indicators an XML must match
+	// to be accepted, see validation.Validator.Confidence; values below 1
+	// accept any XML with at least one indicator
+	MinConfidence int
+
+	// NoClobber makes the extraction fail instead of overwriting an
+	// existing output file
+	NoClobber bool
+
+	// DryRun runs the extraction and detection but writes no files; the
+	// status output names the path that would have been written
+	DryRun bool
+
+	// Pretty re-indents the written XML with two spaces, see PrettyXML; the
+	// checksum is still taken from the XML as extracted
+	Pretty bool
+
+	// Transformers rewrite the XML in order between extraction and save,
+	// before Pretty and StripBOM; empty writes the XML as extracted. The
+	// checksum, the file name template and the rule and schema checks use
+	// the XML as extracted.
+	Transformers []Transformer
+
+	// StrictMimeType fails the extraction with ErrMimeType if the file
+	// specification of the XML declares no XML MIME type; by default this is
+	// only a warning
+	StrictMimeType bool
+
+	// StripBOM removes a leading UTF-8 byte order mark from the written XML
+	StripBOM bool
+
+	// Gzip compresses the written XML and appends .gz to the output
+	// filename, including an explicit OutputPath
+	Gzip bool
+
+	// VerifyVerbatim re-reads the written file and fails with ErrIO unless
+	// it is byte-identical to the extracted XML; it cannot be combined with
+	// Pretty and is skipped for stdout and in a dry run
+	VerifyVerbatim bool
+
+	// Method forces a single extraction method whose error is returned as
+	// is; empty or MethodAuto falls back from one method to the next
+	Method Method
+
+	// Checksum names the hash algorithm ("sha256" or "sha1") whose digest
+	// of the extracted XML is printed; empty disables the checksum
+	Checksum string
+
+	// IncludeMetadata adds the page count and document information of the
+	// PDF to the result, see ReadPDFMetadata; a PDF whose metadata cannot
+	// be read only gets a warning
+	IncludeMetadata bool
+
+	// IncludeTimings measures the durations of the extraction phases and
+	// adds them to the result, see Timings
+	IncludeTimings bool
+
+	// Password decrypts an encrypted PDF; it is tried as user and as owner
+	// password
+	Password string
+
+	// Lang selects the language of messages and errors ("de" or "en");
+	// empty means the ZUGFERD_LANG environment variable, then German
+	Lang string
+
+	// Timeout limits the time spent reading the attachments of the PDF;
+	// zero means no limit
+	Timeout time.Duration
+
+	// TempDir is the directory in which the standard and relaxed methods
+	// create their temporary directories; empty means os.TempDir
+	TempDir string
+
+	// KeepTemp leaves the temporary directories of the standard and relaxed
+	// methods in place and reports their paths, to inspect what pdfcpu
+	// extracted
+	KeepTemp bool
+
+	// Logger receives the progress messages of the extraction at debug
+	// level and warnings at warn level; nil discards them
+	Logger *slog.Logger
+
+	// paths resolves collisions between output files of a batch run
+	paths *pathRegistry
+
+	// schemas holds the XSD schemas compiled once for a batch run; nil
+	// compiles them for every file
+	schemas *validation.SchemaSet
+
+	// input holds the PDF content when the extractor reads from a stream
+	// instead of InputPath
+	input []byte
+
+	// inputFile is a temporary copy of the input that is read instead of
+	// InputPath, see SpoolInput
+	inputFile string
+
+	// status overrides the destination of status messages; the batch
+	// processor sets it to its own
+	status io.Writer
+
+	// nested is set on the extractors of the PDFs inside a portfolio, which
+	// are not searched for portfolios of their own
+	nested bool
+
+	// timings are measured during an extraction with IncludeTimings
+	timings *Timings
 }
 
 // KnownXMLFilenames contains the standard filenames for ZUGFeRD XML attachments
@@ -28,101 +198,780 @@ var KnownXMLFilenames = []string{
 	"cii.xml",             // Cross Industry Invoice
 }
 
+// xmlFilenames returns the attachment names tried in priority order:
+// KnownXMLFilenames, then AdditionalFilenames
+func (z *ZUGFeRDExtractor) xmlFilenames() []string {
+	filenames := make([]string, 0, len(KnownXMLFilenames)+len(z.AdditionalFilenames))
+	filenames = append(filenames, KnownXMLFilenames...)
+	return append(filenames, z.AdditionalFilenames...)
+}
+
+// ErrBusinessRules is returned when the extracted XML violates business rules
+// with error severity
+var ErrBusinessRules = errors.New("Geschäftsregeln verletzt")
+
+// ErrTimeout is returned when the extraction exceeds the configured timeout
+var ErrTimeout = errors.New("Zeitüberschreitung bei der Extraktion")
+
+// ErrEncryptedPDF is returned when the PDF is encrypted and cannot be
+// decrypted without a (different) password
+var ErrEncryptedPDF = errors.New("PDF ist verschlüsselt")
+
+// StdoutPath is the output path that makes the extractor write the XML to
+// standard output instead of a file
+const StdoutPath = "-"
+
+// NewExtractorFromReader creates an extractor that reads the PDF from r
+// instead of a file on disk. The stream is buffered in memory because
+// pdfcpu needs random access to the PDF. The extractor uses lang for its
+// messages, and a read error is translated into it.
+func NewExtractorFromReader(r io.Reader, lang string) (*ZUGFeRDExtractor, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &ExtractError{Kind: ErrIO, Err: i18n.Errorf(lang, "Fehler beim Lesen der PDF: %v", err)}
+	}
+	return &ZUGFeRDExtractor{input: data, Lang: lang}, nil
+}
+
+// Extract extracts the ZUGFeRD XML from the PDF at pdfPath. Verbose output
+// is off; use a ZUGFeRDExtractor directly for advanced configuration.
+func Extract(pdfPath string) ([]byte, error) {
+	data, _, err := ExtractWithFilename(pdfPath)
+	return data, err
+}
+
+// ExtractWithFilename extracts the ZUGFeRD XML from the PDF at pdfPath and
+// also returns the original attachment filename. Verbose output is off.
+func ExtractWithFilename(pdfPath string) (data []byte, filename string, err error) {
+	z := &ZUGFeRDExtractor{InputPath: pdfPath}
+	return z.ExtractXMLData()
+}
+
+// ExtractFromReader extracts the ZUGFeRD XML from the PDF read from r
+func ExtractFromReader(r io.Reader) ([]byte, error) {
+	z, err := NewExtractorFromReader(r, "")
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := z.ExtractXMLData()
+	return data, err
+}
+
 // ExtractXML extracts the ZUGFeRD XML from the PDF file using multiple approaches
+// and writes it to the output path
 func (z *ZUGFeRDExtractor) ExtractXML() error {
-	if z.Verbose {
-		fmt.Printf("Verarbeite PDF: %s\n", z.InputPath)
-	}
+	return z.ExtractXMLContext(context.Background())
+}
 
-	// Try multiple extraction methods
-	var attachments map[string][]byte
-	var err error
+// ExtractXMLContext is like ExtractXML but aborts with ctx.Err() when ctx is
+// cancelled before the extraction has finished
+func (z *ZUGFeRDExtractor) ExtractXMLContext(ctx context.Context) error {
+	_, err := z.ExtractXMLResult(ctx)
+	return err
+}
 
-	// Method 1: Try standard pdfcpu extraction
-	attachments, err = z.extractAttachmentsStandard()
+// WriteXML extracts the ZUGFeRD XML and writes it to w, e.g. an HTTP
+// response or a gzip.Writer. The destination is chosen by the caller, so no
+// output filename is generated and OutputPath, OutputDir, NameTemplate,
+// NoClobber and DryRun have no effect; neither are the business rules
+// checked.
+func (z *ZUGFeRDExtractor) WriteXML(w io.Writer) error {
+	return z.WriteXMLContext(context.Background(), w)
+}
+
+// WriteXMLContext is like WriteXML but aborts with ctx.Err() when ctx is
+// cancelled before the extraction has finished
+func (z *ZUGFeRDExtractor) WriteXMLContext(ctx context.Context, w io.Writer) error {
+	xmlData, _, _, err := z.extractXMLData(ctx)
 	if err != nil {
-		if z.Verbose {
-			fmt.Printf("Standard-Extraktion fehlgeschlagen: %v\n", err)
-			fmt.Printf("Versuche relaxierte Extraktion...\n")
-		}
+		return err
+	}
+	output, err := z.outputXML(xmlData)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(output); err != nil {
+		return z.errorf(ErrIO, "Fehler beim Schreiben der XML-Daten: %v", err)
+	}
+	return nil
+}
 
-		// Method 2: Try with relaxed validation
-		attachments, err = z.extractAttachmentsRelaxed()
-		if err != nil {
-			if z.Verbose {
-				fmt.Printf("Relaxierte Extraktion fehlgeschlagen: %v\n", err)
-				fmt.Printf("Versuche manuelle Extraktion...\n")
-			}
+// Result describes a successful extraction to a file
+type Result struct {
+	// OutputPath is the file the XML was written to, or would have been
+	// written to in a dry run
+	OutputPath string
 
-			// Method 3: Try manual extraction
-			attachments, err = z.extractAttachmentsManual()
-			if err != nil {
-				return fmt.Errorf("alle Extraktionsmethoden fehlgeschlagen: %v", err)
-			}
-		}
+	// Filename is the name of the embedded XML attachment
+	Filename string
+
+	Profile string
+	Syntax  string
+
+	// Checksum is the digest of the XML, see ZUGFeRDExtractor.Checksum
+	Checksum string
+
+	// Size is the size of the written XML in bytes, before compression
+	Size int
+
+	// CompressedSize is the size of the written file with Gzip, else zero
+	CompressedSize int
+
+	// Method is the extraction method that found the XML
+	Method Method
+
+	// Verbatim reports that the XML is the embedded file stream, byte for
+	// byte, see Method.Verbatim
+	Verbatim bool
+
+	// MimeType is the MIME type the file specification declares for the
+	// XML; it is empty if the PDF declares none or its file specifications
+	// could not be read
+	MimeType string
+
+	// Metadata describes the PDF if ZUGFeRDExtractor.IncludeMetadata is set
+	Metadata *PDFMetadata
+
+	// Timings are the phase durations if ZUGFeRDExtractor.IncludeTimings is
+	// set
+	Timings *Timings
+}
+
+// ExtractXMLResult is like ExtractXMLContext but also describes the
+// extraction. The result is returned even if only the business rule check
+// fails.
+func (z *ZUGFeRDExtractor) ExtractXMLResult(ctx context.Context) (Result, error) {
+	if z.VerifyVerbatim && z.Pretty {
+		return Result{}, i18n.Errorf(z.Lang, "VerifyVerbatim und Pretty schließen sich aus")
+	}
+	if z.VerifyVerbatim && z.StripBOM {
+		return Result{}, i18n.Errorf(z.Lang, "VerifyVerbatim und StripBOM schließen sich aus")
+	}
+	if z.VerifyVerbatim && len(z.Transformers) > 0 {
+		return Result{}, i18n.Errorf(z.Lang, "VerifyVerbatim und Transformers schließen sich aus")
 	}
 
-	if len(attachments) == 0 {
-		return fmt.Errorf("keine eingebetteten Dateien im PDF gefunden")
+	xmlData, xmlFilename, method, err := z.extractXMLData(ctx)
+	if err != nil {
+		return Result{}, err
 	}
 
-	if z.Verbose {
-		fmt.Printf("Gefunden: %d Anhang/Anhänge\n", len(attachments))
-		for filename := range attachments {
-			fmt.Printf("  - %s\n", filename)
+	// Hash the bytes as extracted, before anything is written
+	var sum string
+	if z.Checksum != "" {
+		if sum, err = checksum(xmlData, z.Checksum, z.Lang); err != nil {
+			return Result{}, err
 		}
 	}
 
-	// Find ZUGFeRD XML attachment
-	xmlData, xmlFilename, err := z.findZUGFeRDXML(attachments)
-	if err != nil {
-		return fmt.Errorf("ZUGFeRD XML nicht gefunden: %v", err)
+	// The file specification is checked before anything is written, so a
+	// strict MIME type check leaves no output behind. Without a readable
+	// file specification, e.g. after a manual extraction, nothing is checked.
+	spec, specErr := z.attachmentSpec(xmlFilename)
+	if specErr == nil {
+		if err := z.checkMimeType(spec); err != nil {
+			return Result{}, err
+		}
 	}
 
+	encoding := z.checkEncoding(xmlData)
+	currency, taxCurrency, _ := invoice.DetectCurrencies(xmlData, z.Lang)
+	z.checkTaxCurrency(currency, taxCurrency)
+
 	// Generate output filename
-	outputPath := z.generateOutputPath(xmlFilename)
+	outputPath, err := z.generateOutputPath(xmlFilename, xmlData)
+	if err != nil {
+		return Result{}, z.errorf(ErrIO, "Fehler beim Speichern der XML-Datei: %v", err)
+	}
 
 	// Save XML to file
-	err = z.saveXMLToFile(xmlData, outputPath)
+	writeStart := z.startPhase()
+	output, err := z.outputXML(xmlData)
+	if err != nil {
+		return Result{}, err
+	}
+	written := output
+	if z.Gzip {
+		if written, err = gzipData(output); err != nil {
+			return Result{}, z.errorf(ErrIO, "Fehler beim Komprimieren der XML: %v", err)
+		}
+	}
+	if z.DryRun {
+		err = z.checkOutputPath(outputPath)
+	} else {
+		err = z.saveXMLToFile(written, outputPath)
+	}
 	if err != nil {
-		return fmt.Errorf("Fehler beim Speichern der XML-Datei: %v", err)
+		return Result{}, z.errorf(ErrIO, "Fehler beim Speichern der XML-Datei: %v", err)
+	}
+	if z.VerifyVerbatim && !z.DryRun && outputPath != StdoutPath {
+		if err := z.verifyVerbatim(xmlData, outputPath, method); err != nil {
+			return Result{}, err
+		}
+	}
+	z.endPhase(phaseWrite, writeStart)
+
+	validator := &validation.Validator{Lang: z.Lang}
+	profile, profileErr := validator.DetectProfile(xmlData)
+	syntax, _ := validator.DetectSyntax(xmlData)
+	result := Result{
+		OutputPath: outputPath,
+		Filename:   xmlFilename,
+		Profile:    profile,
+		Syntax:     syntax,
+		Checksum:   sum,
+		Size:       len(output),
+		Method:     method,
+		Verbatim:   method.Verbatim() && !z.Pretty && !(z.StripBOM && encoding.BOM) && len(z.Transformers) == 0,
+		MimeType:   spec.mimeType,
+	}
+	if z.Gzip {
+		result.CompressedSize = len(written)
+	}
+	if z.IncludeMetadata {
+		result.Metadata = z.pdfMetadata()
 	}
 
-	fmt.Printf("✓ XML erfolgreich extrahiert nach: %s\n", outputPath)
+	// Status messages must not end up in piped XML output
+	status := z.statusWriter()
+	if z.DryRun {
+		z.statusf(status, StatusSuccess, "✓ XML gefunden: %s (Profil %s), würde gespeichert nach: %s\n", xmlFilename, profile, outputPath)
+	} else {
+		z.statusf(status, StatusSuccess, "✓ XML erfolgreich extrahiert nach: %s\n", outputPath)
+	}
+	if sum != "" {
+		z.printf(status, "  Prüfsumme (%s): %s\n", strings.ToLower(z.Checksum), sum)
+	}
 	if z.Verbose {
-		fmt.Printf("  Originaler XML-Dateiname: %s\n", xmlFilename)
-		fmt.Printf("  XML-Größe: %d Bytes\n", len(xmlData))
+		z.printf(status, "  Originaler XML-Dateiname: %s\n", xmlFilename)
+		if z.Gzip {
+			z.printf(status, "  XML-Größe: %d Bytes, komprimiert %d Bytes\n", len(output), len(written))
+		} else {
+			z.printf(status, "  XML-Größe: %d Bytes\n", len(output))
+		}
+		z.printf(status, "  Extraktionsmethode: %s\n", method)
+		if !method.Verbatim() {
+			z.statusf(status, StatusWarning, "  ⚠ XML aus den PDF-Rohdaten rekonstruiert, nicht byte-genau\n")
+		}
 
 		// Basic validation
 		if z.validateZUGFeRDXML(xmlData) {
-			fmt.Printf("  ✓ XML scheint ein gültiges ZUGFeRD-Format zu sein\n")
+			z.statusf(status, StatusSuccess, "  ✓ XML scheint ein gültiges ZUGFeRD-Format zu sein\n")
+		} else {
+			z.statusf(status, StatusWarning, "  ⚠ Warnung: XML könnte kein gültiges ZUGFeRD-Format sein\n")
+		}
+		z.printf(status, "  Konfidenz: %d (%s)\n", validator.Confidence(xmlData), strings.Join(validator.MatchedIndicators(xmlData), ", "))
+
+		if profileErr == nil {
+			z.printf(status, "  Profil: %s\n", profile)
 		} else {
-			fmt.Printf("  ⚠ Warnung: XML könnte kein gültiges ZUGFeRD-Format sein\n")
+			z.statusf(status, StatusWarning, "  ⚠ Profil nicht erkannt: %v\n", profileErr)
+		}
+		if syntax != "" {
+			z.printf(status, "  Syntax: %s\n", syntax)
+		}
+		if major, minor, err := validator.DetectVersion(xmlData); err == nil {
+			z.printf(status, "  Version: %d.%d\n", major, minor)
+		}
+		if currency != "" {
+			z.printf(status, "  Währung: %s\n", currency)
+		}
+		if metadata := result.Metadata; metadata != nil {
+			z.printf(status, "  Seiten: %d\n", metadata.Pages)
+			if metadata.Title != "" {
+				z.printf(status, "  Titel: %s\n", metadata.Title)
+			}
+			if metadata.Author != "" {
+				z.printf(status, "  Autor: %s\n", metadata.Author)
+			}
+			if metadata.CreationDate != "" {
+				z.printf(status, "  Erstellt: %s\n", metadata.CreationDate)
+			}
+		}
+		z.printf(status, "  Kodierung: deklariert %s, erkannt %s\n", declaredEncoding(encoding), encoding.Detected)
+		switch {
+		case encoding.BOM && z.StripBOM:
+			z.printf(status, "  UTF-8-BOM entfernt\n")
+		case encoding.BOM:
+			z.statusf(status, StatusWarning, "  ⚠ XML beginnt mit einem UTF-8-BOM\n")
+		}
+		// The manual method only guesses the attachment name
+		if method != MethodManual {
+			if consistent, expected := validator.CheckFilenameConsistency(xmlFilename, xmlData); !consistent {
+				z.statusf(status, StatusWarning, "  ⚠ Dateiname %s passt nicht zur Version, erwartet: %s\n", xmlFilename, expected)
+			}
+		}
+		if specErr == nil {
+			switch relationship := spec.relationship; {
+			case relationship == "":
+				z.statusf(status, StatusWarning, "  ⚠ AFRelationship fehlt in der Dateispezifikation\n")
+			case isRecommendedRelationship(relationship):
+				z.printf(status, "  AFRelationship: %s\n", relationship)
+			default:
+				z.statusf(status, StatusWarning, "  ⚠ AFRelationship ist %s statt Alternative oder Data\n", relationship)
+			}
+			if spec.mimeType != "" {
+				z.printf(status, "  MIME-Typ: %s\n", spec.mimeType)
+			}
 		}
 	}
 
+	if z.ValidateSchema {
+		err = z.checkSchema(xmlData)
+	}
+	if err == nil && z.ValidateRules {
+		err = z.checkBusinessRules(xmlData)
+	}
+	result.Timings = z.finishTimings()
+	z.printTimings(result.Timings)
+
+	return result, err
+}
+
+// checkSchema checks the element structure of the XML against the schema
+// of its version
+func (z *ZUGFeRDExtractor) checkSchema(xmlData []byte) error {
+	defer z.endPhase(phaseValidation, z.startPhase())
+	validator := &validation.Validator{Lang: z.Lang, SchemaDir: z.SchemaDir, Schemas: z.schemas}
+	if err := validator.ValidateAgainstXSD(xmlData); err != nil {
+		return i18n.Errorf(z.Lang, "Strukturprüfung fehlgeschlagen: %w", err)
+	}
+	z.statusf(z.statusWriter(), StatusSuccess, "  ✓ Elementstruktur entspricht dem Schema\n")
+	return nil
+}
+
+// checkBusinessRules prints all rule violations and fails on errors
+func (z *ZUGFeRDExtractor) checkBusinessRules(xmlData []byte) error {
+	defer z.endPhase(phaseValidation, z.startPhase())
+	violations, err := z.ruleViolations(xmlData)
+	if err != nil {
+		return err
+	}
+
+	status := z.statusWriter()
+	for _, violation := range violations {
+		kind := StatusWarning
+		if violation.Severity == validation.SeverityError {
+			kind = StatusFailure
+		}
+		z.statusf(status, kind, "  ⚠ %s\n", violation)
+	}
+
+	if validation.HasErrors(violations) {
+		return i18n.Wrap(ErrBusinessRules, z.Lang, "Geschäftsregeln verletzt: %s", z.InputPath)
+	}
+	if len(violations) == 0 {
+		z.statusf(status, StatusSuccess, "  ✓ Geschäftsregeln erfüllt\n")
+	}
 	return nil
 }
 
+// ruleViolations returns the violated business rules of the XML, together
+// with the totals check
+func (z *ZUGFeRDExtractor) ruleViolations(xmlData []byte) ([]validation.RuleViolation, error) {
+	validator := &validation.Validator{Lang: z.Lang, CheckLeitwegID: z.CheckLeitwegID}
+	violations, err := validator.ValidateBusinessRules(xmlData)
+	if err != nil {
+		return nil, i18n.Errorf(z.Lang, "Geschäftsregeln konnten nicht geprüft werden: %v", err)
+	}
+	if violation, found := z.checkTotals(xmlData); found {
+		violations = append(violations, violation)
+	}
+	return violations, nil
+}
+
+// checkTotals recomputes the grand total from the invoice lines, see
+// invoice.CheckTotals, and returns a warning with both amounts if they
+// differ. An invoice that cannot be parsed is left to the rule checks.
+func (z *ZUGFeRDExtractor) checkTotals(xmlData []byte) (validation.RuleViolation, bool) {
+	inv, err := invoice.ParseInvoice(xmlData, z.Lang)
+	if err != nil {
+		return validation.RuleViolation{}, false
+	}
+	ok, stated, computed := invoice.CheckTotals(inv)
+	if ok {
+		return validation.RuleViolation{}, false
+	}
+	return validation.RuleViolation{
+		RuleID:   "TOTALS",
+		Severity: validation.SeverityWarning,
+		Message:  i18n.Sprintf(z.Lang, "Gesamtbetrag (BT-112) %.2f entspricht nicht der Summe aus Positionen, Umsatzsteuer, Nachlässen und Zuschlägen %.2f", stated, computed),
+	}, true
+}
+
+// ExtractXMLData extracts the ZUGFeRD XML from the PDF file and returns its
+// content together with the original attachment filename, without writing
+// any output file
+func (z *ZUGFeRDExtractor) ExtractXMLData() ([]byte, string, error) {
+	xmlData, xmlFilename, _, err := z.extractXMLData(context.Background())
+	return xmlData, xmlFilename, err
+}
+
+// extractXMLData is ExtractXMLData with cancellation support; it also
+// returns the method that found the attachments
+func (z *ZUGFeRDExtractor) extractXMLData(ctx context.Context) ([]byte, string, Method, error) {
+	z.startTimings()
+	attachments, method, err := z.extractAttachments(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return z.selectXML(ctx, attachments, method)
+}
+
+// selectXML returns the ZUGFeRD XML among the attachments read by method;
+// a portfolio carries it in one of its embedded PDFs instead
+func (z *ZUGFeRDExtractor) selectXML(ctx context.Context, attachments map[string][]byte, method Method) ([]byte, string, Method, error) {
+	xmlData, xmlFilename, err := z.findZUGFeRDXML(attachments)
+	if err != nil {
+		if !z.nested && z.isPortfolio() {
+			z.event([]any{"event", "portfolio"}, "PDF-Portfolio erkannt, durchsuche eingebettete PDF-Dateien\n")
+			return z.extractFromPortfolio(ctx, attachments)
+		}
+		return nil, "", "", z.errorf(ErrNoZUGFeRDXML, "ZUGFeRD XML nicht gefunden: %v", err)
+	}
+
+	return xmlData, xmlFilename, method, nil
+}
+
+// extractAttachments reads all embedded files from the PDF within the
+// configured timeout. pdfcpu cannot be interrupted, so on timeout or
+// cancellation the extraction is abandoned and left to finish in the
+// background.
+func (z *ZUGFeRDExtractor) extractAttachments(parent context.Context) (map[string][]byte, Method, error) {
+	if err := parent.Err(); err != nil {
+		return nil, "", err
+	}
+	if z.Timeout <= 0 && parent.Done() == nil {
+		return z.extractAttachmentsCascade()
+	}
+
+	ctx, cancel := parent, context.CancelFunc(func() {})
+	if z.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, z.Timeout)
+	}
+	defer cancel()
+
+	type outcome struct {
+		attachments map[string][]byte
+		method      Method
+		err         error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		attachments, method, err := z.extractAttachmentsCascade()
+		done <- outcome{attachments, method, err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.attachments, result.method, result.err
+	case <-ctx.Done():
+		if err := parent.Err(); err != nil {
+			return nil, "", err
+		}
+		return nil, "", i18n.Wrap(ErrTimeout, z.Lang, "Zeitüberschreitung bei der Extraktion nach %s: %s", z.Timeout, z.InputPath)
+	}
+}
+
+// extractAttachmentsCascade reads all embedded files from the PDF, falling
+// back from the standard to the relaxed and finally the manual method. It
+// returns the method that succeeded. A panic of pdfcpu on a malformed PDF
+// is returned as an error; the temporary directories of the methods have
+// been removed by then.
+func (z *ZUGFeRDExtractor) extractAttachmentsCascade() (attachments map[string][]byte, method Method, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			attachments, method = nil, ""
+			err = z.errorf(ErrPDFParse, "interner Fehler beim Lesen der PDF: %v", r)
+		}
+	}()
+
+	z.event([]any{"event", "pdf_start"}, "Verarbeite PDF: %s\n", z.InputPath)
+
+	encrypted, err := z.checkEncryption()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A forced method reports its own error instead of falling back
+	method = z.Method
+	if method != "" && method != MethodAuto {
+		z.event([]any{"event", "method_attempt", "method", string(method)}, "Versuche Extraktionsmethode %s...\n", method)
+		attachments, err = z.extractAttachmentsWith(method, encrypted)
+	} else {
+		attachments, method, err = z.extractAttachmentsFallback(encrypted)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(attachments) == 0 {
+		return nil, "", z.errorf(ErrNoAttachments, "keine eingebetteten Dateien im PDF gefunden")
+	}
+
+	z.event([]any{"event", "attachments_found", "method", string(method), "count", len(attachments)}, "Gefunden: %d Anhang/Anhänge (Methode %s)\n", len(attachments), method)
+	for filename, data := range attachments {
+		z.event([]any{"event", "attachment", "name", filename, "size", len(data)}, "  - %s\n", filename)
+	}
+
+	return attachments, method, nil
+}
+
+// extractAttachmentsFallback tries the extraction methods in turn until one
+// succeeds and returns the embedded files with the method that read them
+func (z *ZUGFeRDExtractor) extractAttachmentsFallback(encrypted bool) (map[string][]byte, Method, error) {
+	// Method 1: Try standard pdfcpu extraction
+	z.event([]any{"event", "method_attempt", "method", string(MethodStandard)}, "Versuche Standard-Extraktion...\n")
+	attachments, err := z.extractAttachmentsStandard()
+	if err == nil {
+		return attachments, MethodStandard, nil
+	}
+	z.event(methodFailed(MethodStandard, err), "Standard-Extraktion fehlgeschlagen: %v\n", err)
+	z.event([]any{"event", "method_attempt", "method", string(MethodRelaxed)}, "Versuche relaxierte Extraktion...\n")
+
+	// Method 2: Try with relaxed validation
+	attachments, err = z.extractAttachmentsRelaxed()
+	if err == nil {
+		return attachments, MethodRelaxed, nil
+	}
+	z.event(methodFailed(MethodRelaxed, err), "Relaxierte Extraktion fehlgeschlagen: %v\n", err)
+	z.event([]any{"event", "method_attempt", "method", string(MethodAF)}, "Versuche Extraktion über das /AF-Array...\n")
+	relaxedErr := err
+
+	// Method 3: Try the associated files of the catalog, for PDFs without
+	// an EmbeddedFiles name tree
+	attachments, err = z.extractAttachmentsAF()
+	if err == nil {
+		return attachments, MethodAF, nil
+	}
+	z.event(methodFailed(MethodAF, err), "Extraktion über das /AF-Array fehlgeschlagen: %v\n", err)
+	z.event([]any{"event", "method_attempt", "method", string(MethodAnnotation)}, "Versuche Extraktion über Dateianhang-Annotationen...\n")
+
+	// Method 4: Try the files attached to pages by annotations
+	attachments, err = z.extractAttachmentsAnnotations()
+	if err == nil {
+		return attachments, MethodAnnotation, nil
+	}
+	z.event(methodFailed(MethodAnnotation, err), "Extraktion über Dateianhang-Annotationen fehlgeschlagen: %v\n", err)
+
+	// The raw bytes of an encrypted PDF are ciphertext, which the manual
+	// extraction would misread. pdfcpu's error explains why decryption
+	// failed, a missing /AF array does not.
+	if encrypted {
+		return nil, "", z.encryptedError("PDF ist verschlüsselt und konnte nicht entschlüsselt werden: %v", relaxedErr)
+	}
+	// The relaxed pdfcpu run explains best why the PDF could not be read
+	if z.NoManual {
+		z.logf("Manuelle Extraktion deaktiviert\n")
+		return nil, "", z.errorf(methodErrorKind(relaxedErr), "Extraktion fehlgeschlagen, manuelle Extraktion deaktiviert: %v", relaxedErr)
+	}
+	z.event([]any{"event", "method_attempt", "method", string(MethodManual)}, "Versuche manuelle Extraktion...\n")
+
+	// Method 5: Try manual extraction
+	attachments, err = z.extractAttachmentsManual()
+	if err != nil {
+		return nil, "", z.errorf(methodErrorKind(err), "alle Extraktionsmethoden fehlgeschlagen: %v", err)
+	}
+	return attachments, MethodManual, nil
+}
+
+// methodFailed returns the event attributes of a failed extraction method
+func methodFailed(method Method, err error) []any {
+	return []any{"event", "method_failed", "method", string(method), "error", err.Error()}
+}
+
+// ExtractInvoice extracts the ZUGFeRD XML and parses its core invoice fields
+func (z *ZUGFeRDExtractor) ExtractInvoice() (*invoice.Invoice, error) {
+	inv, _, _, err := z.extractInvoice(context.Background())
+	return inv, err
+}
+
+// ExtractInvoiceXML is ExtractInvoice that also returns the XML the invoice
+// was parsed from
+func (z *ZUGFeRDExtractor) ExtractInvoiceXML() (*invoice.Invoice, []byte, error) {
+	xmlData, _, _, err := z.extractXMLData(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	inv, _, err := z.parseInvoice(xmlData)
+	z.finishTimings()
+	return inv, xmlData, err
+}
+
+// extractInvoice is ExtractInvoice with cancellation support; it also
+// returns the detected profile, which is empty if it is unknown, and the
+// extraction method
+func (z *ZUGFeRDExtractor) extractInvoice(ctx context.Context) (inv *invoice.Invoice, profile string, method Method, err error) {
+	xmlData, _, method, err := z.extractXMLData(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	inv, profile, err = z.parseInvoice(xmlData)
+	z.finishTimings()
+	return inv, profile, method, err
+}
+
+// parseInvoice parses the core invoice fields of the XML; it also returns
+// the detected profile, which is empty if it is unknown
+func (z *ZUGFeRDExtractor) parseInvoice(xmlData []byte) (*invoice.Invoice, string, error) {
+	validator := &validation.Validator{Lang: z.Lang}
+	profile, _ := validator.DetectProfile(xmlData)
+
+	inv, err := invoice.ParseInvoice(xmlData, z.Lang)
+	if err != nil {
+		return nil, profile, i18n.Errorf(z.Lang, "Rechnungsdaten konnten nicht gelesen werden: %v", err)
+	}
+	z.checkTaxCurrency(inv.Currency, inv.TaxCurrency)
+	return inv, profile, nil
+}
+
+// checkTaxCurrency warns if the VAT is accounted in a currency (BT-6) other
+// than the invoice currency (BT-5); the VAT total must then be converted
+func (z *ZUGFeRDExtractor) checkTaxCurrency(currency, taxCurrency string) {
+	if taxCurrency != "" && taxCurrency != currency {
+		z.warnf("Warnung: Steuerwährung (BT-6) %s weicht von der Rechnungswährung (BT-5) %s ab", taxCurrency, currency)
+	}
+}
+
+// logf writes a debug message to the configured logger
+func (z *ZUGFeRDExtractor) logf(format string, args ...any) {
+	z.log(slog.LevelDebug, nil, format, args...)
+}
+
+// warnf writes a warning to the configured logger
+func (z *ZUGFeRDExtractor) warnf(format string, args ...any) {
+	z.log(slog.LevelWarn, nil, format, args...)
+}
+
+// event writes a progress message like logf together with the structured
+// attributes of the step, as key-value pairs starting with the event name,
+// e.g. "event", "method_attempt", "method", "relaxed". A handler that
+// records attributes, like the JSON handler, can analyze the extraction
+// without parsing the message.
+func (z *ZUGFeRDExtractor) event(attrs []any, format string, args ...any) {
+	z.log(slog.LevelDebug, attrs, format, args...)
+}
+
+// log writes a message with its attributes; every record is tagged with
+// the input file
+func (z *ZUGFeRDExtractor) log(level slog.Level, attrs []any, format string, args ...any) {
+	if z.Logger == nil || !z.Logger.Enabled(context.Background(), level) {
+		return
+	}
+	if z.InputPath != "" {
+		attrs = append([]any{"file", z.InputPath}, attrs...)
+	}
+	z.Logger.Log(context.Background(), level, strings.TrimSuffix(i18n.Sprintf(z.Lang, format, args...), "\n"), attrs...)
+}
+
+// printf writes a translated status message to w
+func (z *ZUGFeRDExtractor) printf(w io.Writer, format string, args ...any) {
+	fmt.Fprint(w, i18n.Sprintf(z.Lang, format, args...))
+}
+
+// statusf is printf for a status line colored by status, see WriteStatus
+func (z *ZUGFeRDExtractor) statusf(w io.Writer, status Status, format string, args ...any) {
+	WriteStatus(w, status, i18n.Sprintf(z.Lang, format, args...))
+}
+
+// errorf creates an ExtractError of the given kind for the input PDF; the
+// first error among args is kept as its cause, see IsTransient
+func (z *ZUGFeRDExtractor) errorf(kind ErrorKind, format string, args ...any) error {
+	extractErr := &ExtractError{Kind: kind, Path: z.InputPath, Err: i18n.Errorf(z.Lang, format, args...)}
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			extractErr.cause = err
+			break
+		}
+	}
+	return extractErr
+}
+
+// statusWriter returns the destination for status messages, which is stderr
+// when the XML itself is written to stdout and nowhere in quiet mode
+func (z *ZUGFeRDExtractor) statusWriter() io.Writer {
+	if z.Quiet {
+		return io.Discard
+	}
+	if z.status != nil {
+		return z.status
+	}
+	if z.OutputPath == StdoutPath {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// openInput opens the PDF from the buffered stream, the spooled copy of
+// the input or from InputPath
+func (z *ZUGFeRDExtractor) openInput() (io.ReadSeekCloser, error) {
+	if z.input != nil {
+		return nopCloser{bytes.NewReader(z.input)}, nil
+	}
+
+	path := z.InputPath
+	if z.inputFile != "" {
+		path = z.inputFile
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, z.errorf(ErrIO, "Fehler beim Öffnen der PDF: %v", err)
+	}
+	return file, nil
+}
+
+// nopCloser adds a no-op Close method to an in-memory reader
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
+
+// makeTempDir creates a temporary directory for pdfcpu to extract into.
+// With KeepTemp its name also contains the name of the input PDF, so the
+// kept directories of a batch run can be told apart.
+func (z *ZUGFeRDExtractor) makeTempDir(prefix string) (string, error) {
+	pattern := prefix + "_*"
+	if z.KeepTemp && z.InputPath != "" {
+		baseName := strings.TrimSuffix(filepath.Base(z.InputPath), filepath.Ext(z.InputPath))
+		pattern = prefix + "_" + strings.ReplaceAll(baseName, "*", "_") + "_*"
+	}
+	return os.MkdirTemp(z.TempDir, pattern)
+}
+
+// removeTempDir removes a temporary directory of makeTempDir, or with
+// KeepTemp reports where it is left
+func (z *ZUGFeRDExtractor) removeTempDir(dir string) {
+	if z.KeepTemp {
+		z.warnf("Temporäres Verzeichnis behalten: %s", dir)
+		return
+	}
+	os.RemoveAll(dir)
+}
+
 // extractAttachmentsStandard tries standard pdfcpu extraction
 func (z *ZUGFeRDExtractor) extractAttachmentsStandard() (map[string][]byte, error) {
-	attachments := make(map[string][]byte)
-
 	// Create a temporary directory for extraction
-	tempDir, err := os.MkdirTemp("", "zugferd_extract_*")
+	tempDir, err := z.makeTempDir("zugferd_extract")
 	if err != nil {
-		return nil, fmt.Errorf("Fehler beim Erstellen des temporären Verzeichnisses: %v", err)
+		return nil, i18n.Errorf(z.Lang, "Fehler beim Erstellen des temporären Verzeichnisses: %v", err)
 	}
-	defer os.RemoveAll(tempDir)
+	defer z.removeTempDir(tempDir)
 
-	if z.Verbose {
-		fmt.Printf("Verwende temporäres Verzeichnis: %s\n", tempDir)
+	z.logf("Verwende temporäres Verzeichnis: %s\n", tempDir)
+
+	input, err := z.openInput()
+	if err != nil {
+		return nil, err
 	}
+	defer input.Close()
 
 	// Extract attachments using pdfcpu
-	err = api.ExtractAttachmentsFile(z.InputPath, tempDir, nil, nil)
+	start := z.startPhase()
+	err = api.ExtractAttachments(input, tempDir, nil, z.newConfiguration())
+	z.endPhase(phasePDFCPU, start)
 	if err != nil {
-		return nil, fmt.Errorf("pdfcpu-Extraktion fehlgeschlagen: %v", err)
+		return nil, i18n.Errorf(z.Lang, "pdfcpu-Extraktion fehlgeschlagen: %v", err)
 	}
 
 	return z.readExtractedFiles(tempDir)
@@ -130,126 +979,325 @@ func (z *ZUGFeRDExtractor) extractAttachmentsStandard() (map[string][]byte, erro
 
 // extractAttachmentsRelaxed tries extraction with relaxed validation
 func (z *ZUGFeRDExtractor) extractAttachmentsRelaxed() (map[string][]byte, error) {
-	attachments := make(map[string][]byte)
-
-	tempDir, err := os.MkdirTemp("", "zugferd_extract_relaxed_*")
+	tempDir, err := z.makeTempDir("zugferd_extract_relaxed")
 	if err != nil {
-		return nil, fmt.Errorf("Fehler beim Erstellen des temporären Verzeichnisses: %v", err)
+		return nil, i18n.Errorf(z.Lang, "Fehler beim Erstellen des temporären Verzeichnisses: %v", err)
 	}
-	defer os.RemoveAll(tempDir)
+	defer z.removeTempDir(tempDir)
 
 	// Create relaxed configuration
-	config := model.NewDefaultConfiguration()
+	config := z.newConfiguration()
 	config.ValidationMode = model.ValidationRelaxed
 	config.DecodeAllStreams = false
 
-	err = api.ExtractAttachmentsFile(z.InputPath, tempDir, nil, config)
+	input, err := z.openInput()
 	if err != nil {
-		return nil, fmt.Errorf("relaxierte pdfcpu-Extraktion fehlgeschlagen: %v", err)
+		return nil, err
+	}
+	defer input.Close()
+
+	start := z.startPhase()
+	err = api.ExtractAttachments(input, tempDir, nil, config)
+	z.endPhase(phasePDFCPU, start)
+	if err != nil {
+		return nil, i18n.Errorf(z.Lang, "relaxierte pdfcpu-Extraktion fehlgeschlagen: %v", err)
 	}
 
 	return z.readExtractedFiles(tempDir)
 }
 
-// extractAttachmentsManual tries manual extraction by parsing PDF structure
+// xmlStartPatterns mark the beginning of a ZUGFeRD XML in the raw PDF bytes
+var xmlStartPatterns = [][]byte{
+	[]byte("<?xml version=\"1.0\""),
+	[]byte("<?xml version='1.0'"),
+	[]byte("<rsm:CrossIndustryDocument"),
+	[]byte("<rsm:CrossIndustryInvoice"),
+}
+
+// xmlDeclaration starts the XML declaration in front of a root element
+var xmlDeclaration = []byte("<?xml ")
+
+// xmlRootPatterns are the start patterns that mark a root element
+var xmlRootPatterns = xmlStartPatterns[2:]
+
+// xmlRootNames are the local names of the invoice root elements whose end
+// tag, with any namespace prefix, ends a ZUGFeRD XML in the raw PDF bytes
+var xmlRootNames = [][]byte{
+	[]byte("CrossIndustryDocument"),
+	[]byte("CrossIndustryInvoice"),
+	[]byte("Invoice"),
+	[]byte("CreditNote"),
+}
+
+const (
+	// manualChunkSize is the read size of the streaming manual extraction
+	manualChunkSize = 64 * 1024
+
+	// maxManualXMLSize limits the bytes buffered for a single XML candidate;
+	// a candidate without end tag within this size is dropped
+	maxManualXMLSize = 64 * 1024 * 1024
+
+	// maxEndTagLength bounds the length of a prefixed end tag, so a tag
+	// split across reads is still found
+	maxEndTagLength = 128
+)
+
+// extractAttachmentsManual tries manual extraction by scanning the raw PDF
+// bytes for uncompressed XML and by inflating FlateDecode compressed
+// embedded file streams. The file is read in chunks, so only the current
+// XML candidate is held in memory, not the whole PDF.
 func (z *ZUGFeRDExtractor) extractAttachmentsManual() (map[string][]byte, error) {
 	// This is a simplified manual extraction - in practice you'd need more robust PDF parsing
-	file, err := os.Open(z.InputPath)
+	defer z.endPhase(phaseManual, z.startPhase())
+	file, err := z.openInput()
 	if err != nil {
-		return nil, fmt.Errorf("Fehler beim Öffnen der PDF: %v", err)
+		return nil, err
 	}
 	defer file.Close()
 
-	// Read the entire file
-	data, err := io.ReadAll(file)
+	attachments := make(map[string][]byte)
+	reader := bufio.NewReaderSize(file, manualChunkSize)
+	err = scanXMLCandidates(reader, func(offset int64, candidate []byte) {
+		if start, added := z.addManualCandidate(attachments, candidate); added {
+			z.logf("  XML manuell extrahiert von Position %d\n", offset+int64(start))
+		}
+	})
 	if err != nil {
-		return nil, fmt.Errorf("Fehler beim Lesen der PDF: %v", err)
+		return nil, z.errorf(ErrIO, "Fehler beim Lesen der PDF: %v", err)
 	}
 
-	attachments := make(map[string][]byte)
-
-	// Look for embedded file markers and XML content
-	content := string(data)
-
-	// Search for common ZUGFeRD XML patterns
-	xmlStartPatterns := []string{
-		"<?xml version=\"1.0\"",
-		"<rsm:CrossIndustryDocument",
-		"<rsm:CrossIndustryInvoice",
-	}
-
-	for _, pattern := range xmlStartPatterns {
-		if idx := strings.Index(content, pattern); idx != -1 {
-			// Try to extract XML from this position
-			xmlData := z.extractXMLFromPosition(data, idx)
-			if len(xmlData) > 0 && z.isZUGFeRDXML(xmlData) {
-				// Guess filename based on content
-				filename := z.guessXMLFilename(xmlData)
-				attachments[filename] = xmlData
-				if z.Verbose {
-					fmt.Printf("  XML manuell extrahiert von Position %d\n", idx)
-				}
-			}
-		}
+	// Embedded files are usually compressed, so their XML is invisible to
+	// the raw scan above
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, z.errorf(ErrIO, "Fehler beim Lesen der PDF: %v", err)
+	}
+	if err := z.scanCompressedEmbeddedFiles(file, attachments); err != nil {
+		return nil, z.errorf(ErrIO, "Fehler beim Lesen der PDF: %v", err)
 	}
 
 	if len(attachments) == 0 {
-		return nil, fmt.Errorf("manuelle Extraktion fand keine XML-Anhänge")
+		return nil, z.errorf(ErrNoZUGFeRDXML, "manuelle Extraktion fand keine XML-Anhänge")
 	}
 
 	return attachments, nil
 }
 
-// extractXMLFromPosition attempts to extract XML content starting from a position
-func (z *ZUGFeRDExtractor) extractXMLFromPosition(data []byte, startPos int) []byte {
-	if startPos < 0 || startPos >= len(data) {
-		return nil
+// addManualCandidate stores candidate if it is a ZUGFeRD XML and reports
+// where in candidate the stored XML starts. A candidate may begin inside an
+// unrelated XML stream such as the XMP metadata and run up to the end of
+// the invoice, so it is cut to the last document start.
+func (z *ZUGFeRDExtractor) addManualCandidate(attachments map[string][]byte, candidate []byte) (start int, added bool) {
+	start = lastDocumentStart(candidate)
+	xmlData := candidate[start:]
+	if !z.isZUGFeRDXML(xmlData) || z.checkWellFormed(xmlData) != nil {
+		return start, false
+	}
+
+	filename := z.guessXMLFilename(xmlData)
+	if _, exists := attachments[filename]; exists {
+		return start, false
+	}
+	// The candidate points into the scan buffer, which is reused
+	attachments[filename] = bytes.Clone(xmlData)
+	return start, true
+}
+
+// lastDocumentStart returns the position of the last document in candidate:
+// the last CII root element, including an XML declaration directly before
+// it, or the last XML declaration if there is no CII root
+func lastDocumentStart(candidate []byte) int {
+	root := -1
+	for _, pattern := range xmlRootPatterns {
+		root = max(root, bytes.LastIndex(candidate, pattern))
+	}
+	if root == -1 {
+		return max(0, bytes.LastIndex(candidate, xmlDeclaration))
 	}
 
-	content := string(data[startPos:])
+	decl := bytes.LastIndex(candidate[:root], xmlDeclaration)
+	if decl == -1 {
+		return root
+	}
+	declEnd := bytes.Index(candidate[decl:root], []byte("?>"))
+	if declEnd == -1 || !onlyComments(candidate[decl+declEnd+2:root]) {
+		return root
+	}
+	return decl
+}
 
-	// Find the end of the XML document
-	endPatterns := []string{
-		"</rsm:CrossIndustryDocument>",
-		"</rsm:CrossIndustryInvoice>",
-		"</CrossIndustryDocument>",
-		"</CrossIndustryInvoice>",
+// onlyComments reports whether data consists of whitespace and XML comments
+func onlyComments(data []byte) bool {
+	for {
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			return true
+		}
+		rest, ok := bytes.CutPrefix(data, []byte("<!--"))
+		if !ok {
+			return false
+		}
+		_, data, ok = bytes.Cut(rest, []byte("-->"))
+		if !ok {
+			return false
+		}
 	}
+}
 
-	maxLength := len(content)
-	endPos := maxLength
+// scanXMLCandidates reads r and calls found for every span from a start
+// pattern to the nearest end pattern after it. The span passed to found is
+// only valid during the call. offset is the position of the span in r.
+func scanXMLCandidates(r io.Reader, found func(offset int64, candidate []byte)) error {
+	maxStart := longestPattern(xmlStartPatterns)
+
+	chunk := make([]byte, manualChunkSize)
+	buf := make([]byte, 0, 2*manualChunkSize)
+	var base int64     // position of buf[0] in r
+	capturing := false // buf starts with a start pattern
+	searched := 0      // bytes of buf already searched for an end pattern
+
+	for {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+
+		for {
+			if !capturing {
+				idx, _ := indexPattern(buf, xmlStartPatterns)
+				if idx < 0 {
+					// Keep just enough bytes to match a pattern split across reads
+					keep := min(len(buf), maxStart-1)
+					base += int64(len(buf) - keep)
+					buf = append(buf[:0], buf[len(buf)-keep:]...)
+					break
+				}
+				base += int64(idx)
+				buf = append(buf[:0], buf[idx:]...)
+				capturing = true
+				searched = 0
+			}
 
-	for _, pattern := range endPatterns {
-		if idx := strings.Index(content, pattern); idx != -1 {
-			candidateEnd := idx + len(pattern)
-			if candidateEnd < endPos {
-				endPos = candidateEnd
+			from := max(0, searched-maxEndTagLength+1)
+			end := xmlEndIndex(buf[from:])
+			if end < 0 {
+				searched = len(buf)
+				if len(buf) <= maxManualXMLSize {
+					break // wait for more data
+				}
+				// Give up on this candidate and search again behind its start
+				base++
+				buf = append(buf[:0], buf[1:]...)
+				capturing = false
+				continue
 			}
+
+			end += from
+			found(base, buf[:end])
+			base += int64(end)
+			buf = append(buf[:0], buf[end:]...)
+			capturing = false
+		}
+
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err == io.EOF {
+			// The XML may end at EOF without a known end tag, e.g. with an
+			// unexpected root element; keep everything up to the last tag
+			if capturing {
+				if last := bytes.LastIndexByte(buf, '>'); last != -1 {
+					found(base, buf[:last+1])
+				}
+			}
+			return nil
 		}
 	}
+}
 
-	if endPos < maxLength {
-		xmlContent := content[:endPos]
-		return []byte(xmlContent)
+// indexPattern returns the position of the earliest of patterns in data
+// and the length of the matched pattern, or -1 if none occurs
+func indexPattern(data []byte, patterns [][]byte) (int, int) {
+	first, length := -1, 0
+	for _, pattern := range patterns {
+		if idx := bytes.Index(data, pattern); idx != -1 && (first == -1 || idx < first) {
+			first, length = idx, len(pattern)
+		}
 	}
+	return first, length
+}
 
-	return nil
+// xmlEndIndex returns the position just behind the earliest end tag of an
+// invoice root element in data, or -1 if none occurs. The end tag may carry
+// any namespace prefix, e.g. </rsm:CrossIndustryInvoice> or </Invoice>.
+func xmlEndIndex(data []byte) int {
+	first := -1
+	for _, name := range xmlRootNames {
+		tag := append(name[:len(name):len(name)], '>')
+		for offset := 0; offset < len(data); {
+			idx := bytes.Index(data[offset:], tag)
+			if idx == -1 {
+				break
+			}
+			idx += offset
+			if isEndTagStart(data[:idx]) {
+				if end := idx + len(tag); first == -1 || end < first {
+					first = end
+				}
+				break
+			}
+			offset = idx + 1
+		}
+	}
+	return first
+}
+
+// isEndTagStart reports whether data ends with "</" or "</prefix:", i.e.
+// whether a local name following data is part of an end tag
+func isEndTagStart(data []byte) bool {
+	if bytes.HasSuffix(data, []byte("</")) {
+		return true
+	}
+	if !bytes.HasSuffix(data, []byte(":")) {
+		return false
+	}
+	prefix := data[:len(data)-1]
+	i := len(prefix)
+	for i > 0 && isNameByte(prefix[i-1]) {
+		i--
+	}
+	return i < len(prefix) && bytes.HasSuffix(prefix[:i], []byte("</"))
+}
+
+// isNameByte reports whether b may appear in an ASCII namespace prefix
+func isNameByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b == '-' || b == '.'
+}
+
+// longestPattern returns the length of the longest of patterns
+func longestPattern(patterns [][]byte) int {
+	longest := 0
+	for _, pattern := range patterns {
+		longest = max(longest, len(pattern))
+	}
+	return longest
 }
 
 // guessXMLFilename guesses the appropriate filename based on XML content
 func (z *ZUGFeRDExtractor) guessXMLFilename(data []byte) string {
 	content := strings.ToLower(string(data))
 
+	validator := &validation.Validator{Lang: z.Lang}
+	if syntax, err := validator.DetectSyntax(data); err == nil && syntax == validation.SyntaxUBL {
+		return "xrechnung.xml" // UBL is only used by XRechnung
+	}
+
 	if strings.Contains(content, "xrechnung") {
 		return "xrechnung.xml"
 	} else if strings.Contains(content, "factur-x") {
 		return "factur-x.xml"
 	} else if strings.Contains(content, "zugferd") {
-		if strings.Contains(content, "urn:ferd:pdfa:crossindustrydocument:invoice:1p0") {
+		if major, _, err := validator.DetectVersion(data); err == nil && major == 1 {
 			return "ZUGFeRD-invoice.xml" // Version 1.0
-		} else {
-			return "zugferd-invoice.xml" // Version 2.0+
 		}
+		return "zugferd-invoice.xml" // Version 2.0+
 	}
 
 	return "invoice.xml" // fallback
@@ -257,11 +1305,12 @@ func (z *ZUGFeRDExtractor) guessXMLFilename(data []byte) string {
 
 // readExtractedFiles reads all files from the extraction directory
 func (z *ZUGFeRDExtractor) readExtractedFiles(tempDir string) (map[string][]byte, error) {
+	defer z.endPhase(phaseRead, z.startPhase())
 	attachments := make(map[string][]byte)
 
 	files, err := os.ReadDir(tempDir)
 	if err != nil {
-		return nil, fmt.Errorf("Fehler beim Lesen des temporären Verzeichnisses: %v", err)
+		return nil, i18n.Errorf(z.Lang, "Fehler beim Lesen des temporären Verzeichnisses: %v", err)
 	}
 
 	for _, file := range files {
@@ -274,15 +1323,13 @@ func (z *ZUGFeRDExtractor) readExtractedFiles(tempDir string) (map[string][]byte
 
 		data, err := os.ReadFile(filepath)
 		if err != nil {
-			log.Printf("Warnung: Konnte Datei nicht lesen %s: %v", filename, err)
+			z.warnf("Warnung: Konnte Datei nicht lesen %s: %v", filename, err)
 			continue
 		}
 
-		attachments[filename] = data
+		attachments[attachmentBase(filename)] = data
 
-		if z.Verbose {
-			fmt.Printf("  Anhang gelesen: %s (%d Bytes)\n", filename, len(data))
-		}
+		z.event([]any{"event", "attachment_read", "name", filename, "size", len(data)}, "  Anhang gelesen: %s (%d Bytes)\n", filename, len(data))
 	}
 
 	return attachments, nil
@@ -290,147 +1337,242 @@ func (z *ZUGFeRDExtractor) readExtractedFiles(tempDir string) (map[string][]byte
 
 // findZUGFeRDXML finds the ZUGFeRD XML attachment from the extracted attachments
 func (z *ZUGFeRDExtractor) findZUGFeRDXML(attachments map[string][]byte) ([]byte, string, error) {
+	// Names are visited in sorted order so ties are resolved the same way
+	// on every run
+	names := make([]string, 0, len(attachments))
+	for filename := range attachments {
+		names = append(names, filename)
+	}
+	sort.Strings(names)
+
 	// First, try to find by known filenames (priority order)
-	for _, knownName := range KnownXMLFilenames {
-		if data, exists := attachments[knownName]; exists {
-			if z.isZUGFeRDXML(data) {
-				if z.Verbose {
-					fmt.Printf("  Standard-ZUGFeRD-XML gefunden: %s\n", knownName)
-				}
-				return data, knownName, nil
-			}
-			if z.Verbose {
-				fmt.Printf("  %s gefunden, aber Inhalt scheint keine ZUGFeRD-XML zu sein\n", knownName)
-			}
+	for i, knownName := range z.xmlFilenames() {
+		filename, exists := matchFilename(names, knownName)
+		if !exists {
+			continue
+		}
+		data := attachments[filename]
+		if !z.isZUGFeRDXML(data) {
+			z.logf("  %s gefunden, aber Inhalt scheint keine ZUGFeRD-XML zu sein\n", filename)
+			continue
 		}
+		if err := z.checkWellFormed(data); err != nil {
+			z.logf("  %s gefunden, aber verworfen: %v\n", filename, err)
+			continue
+		}
+		if i < len(KnownXMLFilenames) {
+			z.event([]any{"event", "xml_selected", "name", filename, "reason", "standard_name"}, "  Standard-ZUGFeRD-XML gefunden: %s\n", filename)
+		} else {
+			z.event([]any{"event", "xml_selected", "name", filename, "reason", "additional_name"}, "  ZUGFeRD-XML mit zusätzlichem Dateinamen gefunden: %s\n", filename)
+		}
+		return data, filename, nil
 	}
 
-	// If not found by standard names, look for any XML file with ZUGFeRD content
-	for filename, data := range attachments {
-		if strings.HasSuffix(strings.ToLower(filename), ".xml") {
-			if z.isZUGFeRDXML(data) {
-				if z.Verbose {
-					fmt.Printf("  ZUGFeRD-XML in nicht-standardisiertem Dateinamen gefunden: %s\n", filename)
-				}
-				return data, filename, nil
+	// Otherwise pick the most complete ZUGFeRD XML among the remaining files
+	best, bestScore := "", -1
+	for _, filename := range names {
+		if z.isKnownXMLFilename(filename) || !strings.HasSuffix(strings.ToLower(filename), ".xml") {
+			continue
+		}
+		data := attachments[filename]
+		if !z.isZUGFeRDXML(data) {
+			z.logf("  Kandidat verworfen: %s (kein ZUGFeRD-Inhalt)\n", filename)
+			continue
+		}
+		if err := z.checkWellFormed(data); err != nil {
+			z.logf("  Kandidat verworfen: %s (%v)\n", filename, err)
+			continue
+		}
+		score := candidateScore(data)
+		if score > bestScore {
+			if best != "" {
+				z.logf("  Kandidat verworfen: %s (weniger vollständig als %s)\n", best, filename)
 			}
+			best, bestScore = filename, score
+		} else if score == bestScore {
+			z.logf("  Kandidat verworfen: %s (gleichwertig mit %s, das alphabetisch zuerst kommt)\n", filename, best)
+		} else {
+			z.logf("  Kandidat verworfen: %s (weniger vollständig als %s)\n", filename, best)
 		}
 	}
-
-	// List all found attachments for debugging
-	var attachmentNames []string
-	for filename := range attachments {
-		attachmentNames = append(attachmentNames, filename)
+	if best != "" {
+		z.event([]any{"event", "xml_selected", "name", best, "reason", "best_candidate"}, "  ZUGFeRD-XML in nicht-standardisiertem Dateinamen gefunden: %s\n", best)
+		return attachments[best], best, nil
 	}
 
-	return nil, "", fmt.Errorf("kein ZUGFeRD-XML-Anhang gefunden. Verfügbare Anhänge: %v", attachmentNames)
+	return nil, "", i18n.Errorf(z.Lang, "kein ZUGFeRD-XML-Anhang gefunden. Verfügbare Anhänge: %v", names)
+}
+
+// candidateScore rates how complete a ZUGFeRD XML candidate is: a
+// recognized root element and version count most, a detectable profile
+// adds to that
+func candidateScore(data []byte) int {
+	validator := &validation.Validator{}
+	score := 0
+	if _, _, err := validator.DetectVersion(data); err == nil {
+		score += 2
+	}
+	if _, err := validator.DetectProfile(data); err == nil {
+		score++
+	}
+	return score
 }
 
-// isZUGFeRDXML validates if the XML data appears to be a ZUGFeRD document
+// isZUGFeRDXML validates if the XML data appears to be a ZUGFeRD document:
+// it must match at least one indicator, or MinConfidence if that is higher,
+// see validation.Validator.Confidence
 func (z *ZUGFeRDExtractor) isZUGFeRDXML(data []byte) bool {
-	if len(data) == 0 {
-		return false
+	validator := &validation.Validator{Lang: z.Lang}
+	matched := validator.MatchedIndicators(data)
+	for _, indicator := range matched {
+		z.event([]any{"event", "indicator_found", "indicator", indicator}, "    Indikator gefunden: %s\n", indicator)
 	}
 
-	content := string(data)
-	contentLower := strings.ToLower(content)
-
-	// Check for ZUGFeRD/Factur-X/XRechnung indicators
-	indicators := []string{
-		"crossindustrydocument",
-		"crossindustryinvoice",
-		"urn:ferd:",
-		"urn:cen.eu:en16931",
-		"zugferd",
-		"factur-x",
-		"xrechnung",
-		"rsm:crossindustrydocument",
-		"crossindustryinvoice",
-	}
-
-	foundIndicators := 0
-	for _, indicator := range indicators {
-		if strings.Contains(contentLower, strings.ToLower(indicator)) {
-			foundIndicators++
-			if z.Verbose {
-				fmt.Printf("    Indikator gefunden: %s\n", indicator)
-			}
-		}
+	if len(matched) > 0 && len(matched) < z.MinConfidence {
+		z.event([]any{"event", "confidence_too_low", "confidence", len(matched), "minConfidence", z.MinConfidence}, "    Konfidenz %d unter dem Mindestwert %d\n", len(matched), z.MinConfidence)
+		return false
 	}
+	return len(matched) > 0
+}
 
-	// Require at least one indicator
-	return foundIndicators > 0
+// checkWellFormed parses the complete XML; the indicator check of
+// isZUGFeRDXML is only a fast pre-filter that a truncated file passes
+func (z *ZUGFeRDExtractor) checkWellFormed(data []byte) error {
+	validator := &validation.Validator{Lang: z.Lang}
+	return validator.CheckWellFormed(data)
 }
 
 // validateZUGFeRDXML performs additional validation on the XML content
 func (z *ZUGFeRDExtractor) validateZUGFeRDXML(data []byte) bool {
-	content := string(data)
-
-	// Check for XML declaration
-	hasXMLDecl := strings.Contains(content, "<?xml")
-
-	// Check for root elements
-	hasRootElement := strings.Contains(content, "CrossIndustryDocument") ||
-		strings.Contains(content, "CrossIndustryInvoice")
-
-	// Check for namespace declarations
-	hasNamespace := strings.Contains(content, "xmlns:") &&
-		(strings.Contains(content, "urn:ferd:") ||
-			strings.Contains(content, "urn:cen.eu:en16931"))
-
-	return hasXMLDecl && hasRootElement && hasNamespace
+	validator := &validation.Validator{Lang: z.Lang}
+	return validator.ValidateZUGFeRDXML(data)
 }
 
-// generateOutputPath generates the output path for the XML file
-func (z *ZUGFeRDExtractor) generateOutputPath(xmlFilename string) string {
+// generateOutputPath generates the output path for the XML file.
+// An explicit OutputPath takes precedence; otherwise the file is placed in
+// OutputDir (or next to the PDF) and named by NameTemplate if set, after
+// the attachment if PreserveOriginalName is set or, next to the PDF, the
+// attachment has a standard name, and after the PDF otherwise.
+func (z *ZUGFeRDExtractor) generateOutputPath(xmlFilename string, xmlData []byte) (string, error) {
 	if z.OutputPath != "" {
-		return z.OutputPath
+		outputPath := z.OutputPath
+		if z.Gzip {
+			outputPath = gzipPath(outputPath)
+		}
+		return z.claimOutputPath(outputPath)
 	}
 
 	// Generate output path based on input PDF path
-	dir := filepath.Dir(z.InputPath)
-	baseName := strings.TrimSuffix(filepath.Base(z.InputPath), filepath.Ext(z.InputPath))
+	dir, baseName := splitPDFPath(z.InputPath, windowsPaths)
+	if z.OutputDir != "" {
+		dir = z.OutputDir
+	}
 
 	// Use original XML filename if it's a standard name, otherwise use PDF basename
 	var outputFilename string
-	if z.isStandardXMLFilename(xmlFilename) {
-		outputFilename = xmlFilename
+	if z.NameTemplate != "" {
+		filename, err := z.templateFilename(xmlData)
+		if err != nil {
+			z.warnf("Warnung: Namensvorlage nicht anwendbar, verwende PDF-Namen: %v", err)
+			filename = baseName + ".xml"
+		}
+		outputFilename = filename
+	} else if z.PreserveOriginalName || (z.OutputDir == "" && z.isStandardXMLFilename(xmlFilename)) {
+		outputFilename = attachmentBase(xmlFilename)
 	} else {
 		outputFilename = baseName + ".xml"
 	}
+	if z.Gzip {
+		outputFilename = gzipPath(outputFilename)
+	}
 
-	return filepath.Join(dir, outputFilename)
+	return z.claimOutputPath(joinOutputPath(dir, outputFilename, windowsPaths))
+}
+
+// claimOutputPath reserves outputPath among the outputs of a batch run and
+// returns the path to write to, see pathRegistry.claim
+func (z *ZUGFeRDExtractor) claimOutputPath(outputPath string) (string, error) {
+	if z.paths == nil || outputPath == StdoutPath {
+		return outputPath, nil
+	}
+	claimed, collided, err := z.paths.claim(outputPath, z.InputPath, z.Lang)
+	switch {
+	case err != nil:
+		return "", err
+	case claimed != outputPath:
+		z.warnf("Warnung: Ausgabepfad %s wird bereits verwendet, speichere als %s", outputPath, claimed)
+	case collided:
+		z.warnf("Warnung: Ausgabepfad %s wird bereits verwendet und überschrieben", outputPath)
+	}
+	return claimed, nil
 }
 
 // isStandardXMLFilename checks if the filename is a standard ZUGFeRD XML filename
 func (z *ZUGFeRDExtractor) isStandardXMLFilename(filename string) bool {
-	for _, knownName := range KnownXMLFilenames {
-		if filename == knownName {
-			return true
+	_, found := matchFilename(KnownXMLFilenames, filename)
+	return found
+}
+
+// isKnownXMLFilename checks if the filename is a standard or an additional
+// ZUGFeRD XML filename
+func (z *ZUGFeRDExtractor) isKnownXMLFilename(filename string) bool {
+	_, found := matchFilename(z.xmlFilenames(), filename)
+	return found
+}
+
+// matchFilename returns the entry of names equal to filename, preferring an
+// exact match over one that differs only in case
+func matchFilename(names []string, filename string) (string, bool) {
+	for _, name := range names {
+		if name == filename {
+			return name, true
 		}
 	}
-	return false
+	for _, name := range names {
+		if strings.EqualFold(name, filename) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// checkOutputPath warns about or, with NoClobber, rejects an existing
+// output file
+func (z *ZUGFeRDExtractor) checkOutputPath(outputPath string) error {
+	if outputPath == StdoutPath {
+		return nil
+	}
+	if _, err := os.Stat(outputPath); err == nil {
+		if z.NoClobber {
+			return i18n.Wrap(fs.ErrExist, z.Lang, "Ausgabedatei existiert bereits: %s", outputPath)
+		}
+		z.logf("  Warnung: Ausgabedatei existiert bereits und wird überschrieben: %s\n", outputPath)
+	}
+	return nil
 }
 
 // saveXMLToFile saves the XML data to the specified file path
 func (z *ZUGFeRDExtractor) saveXMLToFile(data []byte, outputPath string) error {
+	if outputPath == StdoutPath {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return i18n.Errorf(z.Lang, "Fehler beim Schreiben der XML-Daten: %v", err)
+		}
+		return nil
+	}
+
 	// Create output directory if it doesn't exist
 	dir := filepath.Dir(outputPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("Fehler beim Erstellen des Ausgabeverzeichnisses: %v", err)
+		return i18n.Errorf(z.Lang, "Fehler beim Erstellen des Ausgabeverzeichnisses: %v", err)
 	}
 
-	// Check if file already exists
-	if _, err := os.Stat(outputPath); err == nil {
-		if z.Verbose {
-			fmt.Printf("  Warnung: Ausgabedatei existiert bereits und wird überschrieben: %s\n", outputPath)
-		}
+	if err := z.checkOutputPath(outputPath); err != nil {
+		return err
 	}
 
-	// Write XML data to file
-	err := os.WriteFile(outputPath, data, 0644)
-	if err != nil {
-		return fmt.Errorf("Fehler beim Schreiben der XML-Daten: %v", err)
+	// Write XML data to file; an interrupted write leaves no partial file
+	if err := writeFileAtomic(outputPath, data); err != nil {
+		return i18n.Errorf(z.Lang, "Fehler beim Schreiben der XML-Daten: %v", err)
 	}
 
 	return nil
@@ -0,0 +1,158 @@
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ciiXML returns a minimal CII invoice with the given guideline ID; an
+// empty guideline leaves out the document context
+func ciiXML(guideline string) []byte {
+	context := ""
+	if guideline != "" {
+		context = fmt.Sprintf(`<rsm:ExchangedDocumentContext><ram:GuidelineSpecifiedDocumentContextParameter><ram:ID>%s</ram:ID></ram:GuidelineSpecifiedDocumentContextParameter></rsm:ExchangedDocumentContext>`, guideline)
+	}
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100" xmlns:ram="urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100">` +
+		context + `<rsm:ExchangedDocument><ram:ID>RE-1</ram:ID></rsm:ExchangedDocument></rsm:CrossIndustryInvoice>`)
+}
+
+const guidelineEN16931 = "urn:cen.eu:en16931:2017"
+
+func TestFindZUGFeRDXMLStableSelection(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachments map[string][]byte
+		want        string
+	}{
+		{
+			name: "standard name before richer candidate",
+			attachments: map[string][]byte{
+				"factur-x.xml": ciiXML(""),
+				"invoice.xml":  ciiXML(guidelineEN16931),
+			},
+			want: "factur-x.xml",
+		},
+		{
+			name: "most complete candidate",
+			attachments: map[string][]byte{
+				"a-invoice.xml": ciiXML(""),
+				"b-invoice.xml": ciiXML(guidelineEN16931),
+			},
+			want: "b-invoice.xml",
+		},
+		{
+			name: "tie broken by sorted name",
+			attachments: map[string][]byte{
+				"zeta.xml":  ciiXML(guidelineEN16931),
+				"alpha.xml": ciiXML(guidelineEN16931),
+			},
+			want: "alpha.xml",
+		},
+		{
+			name: "malformed candidate skipped",
+			attachments: map[string][]byte{
+				"alpha.xml": ciiXML(guidelineEN16931)[:120],
+				"beta.xml":  ciiXML(guidelineEN16931),
+			},
+			want: "beta.xml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			z := &ZUGFeRDExtractor{}
+			// Map iteration order changes between runs, so repeat to catch
+			// a selection that depends on it
+			for i := 0; i < 50; i++ {
+				_, filename, err := z.findZUGFeRDXML(tt.attachments)
+				if err != nil {
+					t.Fatalf("findZUGFeRDXML: %v", err)
+				}
+				if filename != tt.want {
+					t.Fatalf("run %d: selected %s, want %s", i, filename, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindZUGFeRDXMLNoCandidate(t *testing.T) {
+	z := &ZUGFeRDExtractor{}
+	attachments := map[string][]byte{
+		"notes.txt": []byte("ZUGFeRD"),
+		"other.xml": []byte(`<root/>`),
+	}
+	if _, filename, err := z.findZUGFeRDXML(attachments); err == nil {
+		t.Fatalf("selected %s, want an error", filename)
+	}
+}
+
+func TestTruncatedXMLRejected(t *testing.T) {
+	full := ciiXML(guidelineEN16931)
+	truncated := full[:len(full)-20]
+
+	z := &ZUGFeRDExtractor{}
+	if !z.isZUGFeRDXML(truncated) {
+		t.Fatal("isZUGFeRDXML rejects the truncated XML, the test needs one that passes the pre-filter")
+	}
+	if err := z.checkWellFormed(truncated); err == nil {
+		t.Error("checkWellFormed accepts the truncated XML")
+	}
+	if err := z.checkWellFormed(full); err != nil {
+		t.Errorf("checkWellFormed rejects the complete XML: %v", err)
+	}
+
+	attachments := map[string][]byte{"factur-x.xml": truncated}
+	if _, filename, err := z.findZUGFeRDXML(attachments); err == nil {
+		t.Errorf("findZUGFeRDXML selected the truncated %s", filename)
+	}
+}
+
+// buildPDF returns a PDF made of the given objects, numbered from 1 with
+// the catalog first, and a valid cross-reference table. pdfcpu looks for
+// the last xref section in a tail of fixed size, so short files are padded
+// with comments.
+func buildPDF(objects ...[]byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	for i := 0; i < 32; i++ {
+		buf.WriteString("%" + strings.Repeat("x", 70) + "\n")
+	}
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// blankPDF returns a PDF with one empty page and no attachments
+func blankPDF() []byte {
+	return buildPDF(
+		[]byte("<< /Type /Catalog /Pages 2 0 R >>"),
+		[]byte("<< /Type /Pages /Kids [3 0 R] /Count 1 >>"),
+		[]byte("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] >>"),
+	)
+}
+
+// writeFile writes data to name in a temporary directory and returns its
+// path
+func writeFile(tb testing.TB, name string, data []byte) string {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
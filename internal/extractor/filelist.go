@@ -0,0 +1,27 @@
+package extractor
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ReadFileList reads one input path per line, e.g. from a list generated by
+// another tool. Surrounding blanks are removed; empty lines and lines
+// starting with # are skipped. The paths are returned in file order and,
+// unlike the result of ExpandPattern, neither sorted nor deduplicated.
+func ReadFileList(r io.Reader) ([]string, error) {
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		files = append(files, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
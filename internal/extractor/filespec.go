@@ -0,0 +1,306 @@
+package extractor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// fileSpec holds the entries of an embedded file's file specification
+// dictionary that the raw attachment data does not carry
+type fileSpec struct {
+	name         string
+	relationship string
+	mimeType     string
+	size         int64
+}
+
+// AttachmentRelationship returns the AFRelationship of the ZUGFeRD XML
+// attachment of the PDF at pdfPath. ZUGFeRD 2.x requires "Alternative" or
+// "Data"; an empty string means the file specification declares none.
+func AttachmentRelationship(pdfPath string) (string, error) {
+	z := &ZUGFeRDExtractor{InputPath: pdfPath}
+	spec, err := z.attachmentSpec("")
+	if err != nil {
+		return "", err
+	}
+	return spec.relationship, nil
+}
+
+// attachmentSpec returns the file specification of the attachment named
+// xmlFilename, or of the most likely ZUGFeRD XML if xmlFilename is empty
+func (z *ZUGFeRDExtractor) attachmentSpec(xmlFilename string) (fileSpec, error) {
+	input, err := z.openInput()
+	if err != nil {
+		return fileSpec{}, err
+	}
+	defer input.Close()
+
+	ctx, err := z.readPDFContext(input)
+	if err != nil {
+		return fileSpec{}, z.errorf(ErrPDFParse, "PDF konnte nicht gelesen werden: %v", err)
+	}
+	specs, err := z.readFileSpecs(ctx)
+	if err != nil {
+		return fileSpec{}, z.errorf(ErrPDFParse, "PDF konnte nicht gelesen werden: %v", err)
+	}
+	if len(specs) == 0 {
+		return fileSpec{}, z.errorf(ErrNoAttachments, "keine eingebetteten Dateien im PDF gefunden")
+	}
+
+	spec, found := selectXMLFileSpec(specs, xmlFilename)
+	if !found {
+		return fileSpec{}, z.errorf(ErrNoZUGFeRDXML, "kein XML-Anhang gefunden")
+	}
+	return spec, nil
+}
+
+// selectXMLFileSpec picks the file specification named xmlFilename or,
+// without a name, the first known ZUGFeRD filename and then the first XML
+// file in name order
+func selectXMLFileSpec(specs []fileSpec, xmlFilename string) (fileSpec, bool) {
+	byName := make(map[string]fileSpec, len(specs))
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		byName[spec.name] = spec
+		names = append(names, spec.name)
+	}
+
+	if xmlFilename != "" {
+		spec, found := byName[xmlFilename]
+		return spec, found
+	}
+
+	for _, knownName := range KnownXMLFilenames {
+		if spec, found := byName[knownName]; found {
+			return spec, true
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.EqualFold(filepath.Ext(name), ".xml") {
+			return byName[name], true
+		}
+	}
+	return fileSpec{}, false
+}
+
+// readPDFContext parses the PDF structure with relaxed validation
+func (z *ZUGFeRDExtractor) readPDFContext(rs io.ReadSeeker) (*model.Context, error) {
+	config := z.newConfiguration()
+	config.ValidationMode = model.ValidationRelaxed
+	ctx, err := api.ReadContext(rs, config)
+	if errors.Is(err, pdfcpu.ErrWrongPassword) {
+		return nil, z.encryptedError("PDF ist verschlüsselt, das Passwort fehlt oder ist falsch")
+	}
+	return ctx, err
+}
+
+// readFileSpecs returns the file specifications of the EmbeddedFiles name
+// tree, followed by those only referenced from the catalog's /AF array. The
+// tree is walked directly because pdfcpu only parses name trees during
+// validation, which many invoice generators do not pass.
+func (z *ZUGFeRDExtractor) readFileSpecs(ctx *model.Context) ([]fileSpec, error) {
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []fileSpec
+	seen := make(map[string]bool)
+	add := func(d types.Dict, id string) {
+		spec := fileSpec{name: fileSpecName(ctx.XRefTable, d, id)}
+		if seen[spec.name] {
+			return
+		}
+		seen[spec.name] = true
+		if relationship := d.NameEntry("AFRelationship"); relationship != nil {
+			spec.relationship = *relationship
+		}
+		if stream := embeddedFileStream(ctx.XRefTable, d); stream != nil {
+			spec.mimeType, spec.size = embeddedFileInfo(stream)
+		}
+		specs = append(specs, spec)
+	}
+
+	names, err := dereferenceDictEntry(ctx.XRefTable, catalog, "Names")
+	if err != nil {
+		return nil, err
+	}
+	if names != nil {
+		root, err := dereferenceDictEntry(ctx.XRefTable, names, "EmbeddedFiles")
+		if err != nil {
+			return nil, err
+		}
+		if root != nil {
+			err = walkNameTree(ctx.XRefTable, root, 0, func(key string, value types.Object) error {
+				d, err := ctx.DereferenceDict(value)
+				if err != nil || d == nil {
+					return err
+				}
+				add(d, key)
+				return nil
+			})
+			if errors.Is(err, errNameTreeDepth) {
+				return nil, i18n.Wrap(errNameTreeDepth, z.Lang, "Namensbaum zu tief verschachtelt")
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Associated files without an entry in the name tree
+	if o, found := catalog.Find("AF"); found {
+		associated, err := ctx.DereferenceArray(o)
+		if err != nil {
+			return nil, err
+		}
+		for i, entry := range associated {
+			if d, err := ctx.DereferenceDict(entry); err == nil && d != nil && embeddedFileStream(ctx.XRefTable, d) != nil {
+				add(d, fmt.Sprintf("attachment%d", i+1))
+			}
+		}
+	}
+	return specs, nil
+}
+
+// maxNameTreeDepth guards against cyclic name trees in malformed PDFs
+const maxNameTreeDepth = 32
+
+// errNameTreeDepth is returned by walkNameTree for a name tree nested
+// deeper than maxNameTreeDepth
+var errNameTreeDepth = errors.New("Namensbaum zu tief verschachtelt")
+
+// walkNameTree calls fn for every key/value pair of a name tree node and
+// its kids
+func walkNameTree(xRefTable *model.XRefTable, node types.Dict, depth int, fn func(key string, value types.Object) error) error {
+	if depth > maxNameTreeDepth {
+		return errNameTreeDepth
+	}
+
+	if o, found := node.Find("Names"); found {
+		entries, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(entries); i += 2 {
+			key, err := xRefTable.DereferenceStringOrHexLiteral(entries[i], model.V10, nil)
+			if err != nil {
+				return err
+			}
+			if err := fn(key, entries[i+1]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if o, found := node.Find("Kids"); found {
+		kids, err := xRefTable.DereferenceArray(o)
+		if err != nil {
+			return err
+		}
+		for _, kid := range kids {
+			d, err := xRefTable.DereferenceDict(kid)
+			if err != nil {
+				return err
+			}
+			if d == nil {
+				continue
+			}
+			if err := walkNameTree(xRefTable, d, depth+1, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dereferenceDictEntry returns the dictionary stored under key, or nil if
+// d has no such entry
+func dereferenceDictEntry(xRefTable *model.XRefTable, d types.Dict, key string) (types.Dict, error) {
+	o, found := d.Find(key)
+	if !found {
+		return nil, nil
+	}
+	return xRefTable.DereferenceDict(o)
+}
+
+// fileSpecName returns the filename of a file specification, preferring the
+// Unicode name; the name tree key is the fallback
+func fileSpecName(xRefTable *model.XRefTable, d types.Dict, id string) string {
+	for _, key := range []string{"UF", "F"} {
+		if o, found := d.Find(key); found {
+			if name, err := xRefTable.DereferenceStringOrHexLiteral(o, model.V10, nil); err == nil && name != "" {
+				return attachmentBase(name)
+			}
+		}
+	}
+	return id
+}
+
+// attachmentBase returns the last element of an attachment name. The names
+// come from PDFs created on any system, so / and \ both separate elements,
+// whatever the separator of the running system; a Windows path such as
+// C:\Rechnungen\factur-x.xml yields factur-x.xml on Linux, too.
+func attachmentBase(name string) string {
+	return path.Base(strings.ReplaceAll(name, `\`, "/"))
+}
+
+// embeddedFileStream returns the embedded file stream of a file
+// specification, or nil if it has none
+func embeddedFileStream(xRefTable *model.XRefTable, d types.Dict) *types.StreamDict {
+	ef, err := dereferenceDictEntry(xRefTable, d, "EF")
+	if err != nil || ef == nil {
+		return nil
+	}
+	for _, key := range []string{"UF", "F"} {
+		if o, found := ef.Find(key); found {
+			if stream, _, err := xRefTable.DereferenceStreamDict(o); err == nil && stream != nil {
+				return stream
+			}
+		}
+	}
+	return nil
+}
+
+// embeddedFileInfo returns the MIME type and the uncompressed size of an
+// embedded file stream. The size is taken from the Params dictionary and
+// only computed by decoding the stream if it is not declared.
+func embeddedFileInfo(stream *types.StreamDict) (string, int64) {
+	var mimeType string
+	if subtype := stream.Subtype(); subtype != nil {
+		mimeType = *subtype
+		if decoded, err := types.DecodeName(mimeType); err == nil {
+			mimeType = decoded
+		}
+	}
+
+	if params := stream.DictEntry("Params"); params != nil {
+		if size := params.IntEntry("Size"); size != nil {
+			return mimeType, int64(*size)
+		}
+	}
+	if err := stream.Decode(); err != nil {
+		return mimeType, 0
+	}
+	return mimeType, int64(len(stream.Content))
+}
+
+// isRecommendedRelationship reports whether relationship is allowed for the
+// ZUGFeRD XML by the 2.x specification
+func isRecommendedRelationship(relationship string) bool {
+	return relationship == "Alternative" || relationship == "Data"
+}
@@ -0,0 +1,178 @@
+package extractor
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExpandPattern returns the paths matching pattern. Besides the syntax of
+// filepath.Match it supports {a,b} alternatives and a "**" path element,
+// which matches any number of directories, e.g. "rechnungen/**/*.pdf".
+// The result is sorted and free of duplicates; no match is not an error.
+func ExpandPattern(pattern string) ([]string, error) {
+	seen := make(map[string]bool)
+	var matches []string
+	for _, expanded := range expandBraces(pattern) {
+		var found []string
+		var err error
+		if hasRecursiveElement(expanded) {
+			found, err = globRecursive(expanded)
+		} else {
+			found, err = filepath.Glob(expanded)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range found {
+			if !seen[match] {
+				seen[match] = true
+				matches = append(matches, match)
+			}
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// expandBraces expands the first {a,b,...} group of pattern and, recursively,
+// the groups in the results. Braces without a top-level comma or without a
+// closing brace are kept literally.
+func expandBraces(pattern string) []string {
+	start := strings.IndexByte(pattern, '{')
+	for start != -1 {
+		end, alternatives := braceGroup(pattern[start:])
+		if end != -1 && len(alternatives) > 1 {
+			prefix, suffix := pattern[:start], pattern[start+end+1:]
+			var expanded []string
+			for _, alternative := range alternatives {
+				expanded = append(expanded, expandBraces(prefix+alternative+suffix)...)
+			}
+			return expanded
+		}
+		next := strings.IndexByte(pattern[start+1:], '{')
+		if next == -1 {
+			break
+		}
+		start += next + 1
+	}
+	return []string{pattern}
+}
+
+// braceGroup parses the group at the start of s, which begins with '{'. It
+// returns the index of the closing brace and the top-level alternatives, or
+// -1 if the group is not closed.
+func braceGroup(s string) (int, []string) {
+	depth := 0
+	last := 1
+	var alternatives []string
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, append(alternatives, s[last:i])
+			}
+		case ',':
+			if depth == 1 {
+				alternatives = append(alternatives, s[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return -1, nil
+}
+
+// hasRecursiveElement reports whether pattern contains a "**" path element
+func hasRecursiveElement(pattern string) bool {
+	for _, element := range strings.Split(filepath.ToSlash(pattern), "/") {
+		if element == "**" {
+			return true
+		}
+	}
+	return false
+}
+
+// globRecursive walks the directory in front of the first element with
+// wildcards and matches every entry below it against pattern
+func globRecursive(pattern string) ([]string, error) {
+	// A Windows volume, e.g. C: or \\server\share, is no path element and
+	// must not lose its backslashes
+	volume := filepath.VolumeName(pattern)
+	elements := strings.Split(filepath.ToSlash(pattern[len(volume):]), "/")
+
+	// The leading elements without wildcards name the directory to walk
+	fixed := 0
+	for fixed < len(elements) && !hasMeta(elements[fixed]) {
+		fixed++
+	}
+	root := volume + strings.Join(elements[:fixed], "/")
+	switch {
+	case root == volume && fixed > 0:
+		root = volume + "/"
+	case root == "":
+		root = "."
+	}
+	rest := elements[fixed:]
+
+	// Validate the pattern once so a bad pattern is reported even if the
+	// directory is empty
+	for _, element := range rest {
+		if _, err := filepath.Match(element, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := os.Stat(root); err != nil {
+		return nil, nil
+	}
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped like filepath.Glob does
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		if matchElements(rest, strings.Split(filepath.ToSlash(rel), "/")) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// matchElements matches the path elements against the pattern elements, where
+// a "**" element matches zero or more path elements
+func matchElements(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchElements(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchElements(pattern[1:], path[1:])
+}
+
+// hasMeta reports whether element contains a filepath.Match wildcard
+func hasMeta(element string) bool {
+	return strings.ContainsAny(element, `*?[\`)
+}
@@ -0,0 +1,43 @@
+package extractor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+)
+
+// gzipExtension is appended to the output filename with Gzip
+const gzipExtension = ".gz"
+
+// gzipPath appends the .gz extension to path unless it already has it or
+// path is empty or stdout
+func gzipPath(path string) string {
+	if path == "" || path == StdoutPath || strings.HasSuffix(strings.ToLower(path), gzipExtension) {
+		return path
+	}
+	return path + gzipExtension
+}
+
+// gzipData compresses data with gzip's default level
+func gzipData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gunzipData decompresses gzip compressed data
+func gunzipData(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
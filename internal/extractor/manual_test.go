@@ -0,0 +1,158 @@
+package extractor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeLargePDF writes a PDF-like file of about size bytes to a temporary
+// directory: filler that matches no pattern with an uncompressed invoice
+// XML near the end, as the manual extraction sees a large scanned PDF
+func writeLargePDF(tb testing.TB, size int) string {
+	tb.Helper()
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	line := []byte("0 0 0 rg 10 10 580 820 re f BT /F1 12 Tf (Seite) Tj ET\n")
+	for buf.Len() < size {
+		buf.Write(line)
+	}
+	buf.WriteString("1 0 obj\n<< /Type /EmbeddedFile >>\nstream\n")
+	buf.Write(ciiXML(guidelineEN16931))
+	buf.WriteString("\nendstream\nendobj\n%%EOF\n")
+
+	path := filepath.Join(tb.TempDir(), "large.pdf")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+const largePDFSize = 32 << 20
+
+// BenchmarkManualExtraction measures the streaming manual extraction; its
+// allocations stay near the chunk size however large the PDF is
+func BenchmarkManualExtraction(b *testing.B) {
+	path := writeLargePDF(b, largePDFSize)
+	z := &ZUGFeRDExtractor{InputPath: path}
+
+	b.ReportAllocs()
+	b.SetBytes(largePDFSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		attachments, err := z.extractAttachmentsManual()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(attachments) != 1 {
+			b.Fatalf("found %d attachments, want 1", len(attachments))
+		}
+	}
+}
+
+// BenchmarkManualExtractionReadAll is the former approach for comparison:
+// the whole PDF is read and copied into a string before searching it
+func BenchmarkManualExtractionReadAll(b *testing.B) {
+	path := writeLargePDF(b, largePDFSize)
+	end := "</rsm:CrossIndustryInvoice>"
+
+	b.ReportAllocs()
+	b.SetBytes(largePDFSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		content := string(data)
+		start := strings.Index(content, string(xmlStartPatterns[0]))
+		stop := strings.Index(content[start:], end)
+		if start < 0 || stop < 0 {
+			b.Fatal("XML not found")
+		}
+	}
+}
+
+func TestXMLEndIndex(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"rsm prefix", "<rsm:CrossIndustryInvoice></rsm:CrossIndustryInvoice>", 53},
+		{"uncommon prefix", "<x1:CrossIndustryInvoice></x1:CrossIndustryInvoice>\n", 51},
+		{"no prefix", "<Invoice><ID>1</ID></Invoice>", 29},
+		{"credit note", "<ubl:CreditNote></ubl:CreditNote>", 33},
+		{"earliest end tag", "</Invoice></CreditNote>", 10},
+		{"start tag only", "<rsm:CrossIndustryInvoice>", -1},
+		{"longer local name", "</ram:InvoiceReferencedDocument>", -1},
+		{"name in text", "<cbc:Note>Invoice></cbc:Note>", -1},
+		{"no end tag", "<?xml version=\"1.0\"?><a>", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := xmlEndIndex([]byte(tt.data)); got != tt.want {
+				t.Errorf("xmlEndIndex(%q) = %d, want %d", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsEndTagStart(t *testing.T) {
+	tests := []struct {
+		data string
+		want bool
+	}{
+		{"</", true},
+		{"text </rsm:", true},
+		{"</ns-2.a_b:", true},
+		{"<rsm:", false},
+		{"</:", false},
+		{"rsm:", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isEndTagStart([]byte(tt.data)); got != tt.want {
+			t.Errorf("isEndTagStart(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestScanXMLCandidatesEndAtEOF(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+	}{
+		{
+			name: "no trailing newline",
+			xml:  `<?xml version="1.0" encoding="UTF-8"?><rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"></rsm:CrossIndustryInvoice>`,
+		},
+		{
+			name: "uncommon root prefix",
+			xml:  `<?xml version="1.0" encoding="UTF-8"?><inv:CrossIndustryInvoice xmlns:inv="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"></inv:CrossIndustryInvoice>`,
+		},
+		{
+			name: "unknown root up to the last tag",
+			xml:  `<?xml version="1.0" encoding="UTF-8"?><Rechnung><ID>1</ID></Rechnung>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var found []string
+			err := scanXMLCandidates(strings.NewReader("%PDF-1.7\nstream\n"+tt.xml), func(_ int64, candidate []byte) {
+				found = append(found, string(candidate))
+			})
+			if err != nil {
+				t.Fatalf("scanXMLCandidates: %v", err)
+			}
+			if len(found) != 1 || found[0] != tt.xml {
+				t.Errorf("found %q, want [%q]", found, tt.xml)
+			}
+		})
+	}
+}
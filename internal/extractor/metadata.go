@@ -0,0 +1,136 @@
+package extractor
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// PDFMetadata describes the PDF an invoice was extracted from. The fields
+// are read from the document information dictionary; title, author and
+// creation date fall back to the XMP metadata. Fields the PDF does not
+// declare stay empty.
+type PDFMetadata struct {
+	Pages  int    `json:"pages"`
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+
+	// CreationDate is formatted as RFC 3339; a date pdfcpu cannot parse is
+	// kept as declared
+	CreationDate string `json:"creationDate,omitempty"`
+}
+
+// XMP properties of the Dublin Core and XMP basic schemas, in element or,
+// for the creation date, attribute notation
+var (
+	xmpTitlePattern      = regexp.MustCompile(`(?s)<dc:title>\s*<rdf:Alt>\s*<rdf:li[^>]*>([^<]*)<`)
+	xmpCreatorPattern    = regexp.MustCompile(`(?s)<dc:creator>\s*<rdf:Seq>\s*<rdf:li[^>]*>([^<]*)<`)
+	xmpCreateDatePattern = regexp.MustCompile(`xmp:CreateDate\s*(?:=\s*["']\s*([^"']*)|>\s*([^<]*?)\s*<)`)
+)
+
+// ReadPDFMetadata reads the page count and document information of the
+// PDF. A PDF without information dictionary or XMP metadata is not an
+// error; only a PDF that cannot be read at all is.
+func (z *ZUGFeRDExtractor) ReadPDFMetadata() (*PDFMetadata, error) {
+	input, err := z.openInput()
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	ctx, err := z.readPDFContext(input)
+	if err != nil {
+		return nil, z.errorf(ErrPDFParse, "PDF konnte nicht gelesen werden: %v", err)
+	}
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return nil, z.errorf(ErrPDFParse, "PDF konnte nicht gelesen werden: %v", err)
+	}
+
+	metadata := &PDFMetadata{Pages: pageCount(ctx, catalog)}
+	if ctx.Info != nil {
+		if info, err := ctx.DereferenceDict(*ctx.Info); err == nil && info != nil {
+			metadata.Title = infoString(ctx, info, "Title")
+			metadata.Author = infoString(ctx, info, "Author")
+			metadata.CreationDate = pdfDate(infoString(ctx, info, "CreationDate"))
+		}
+	}
+
+	if metadata.Title == "" || metadata.Author == "" || metadata.CreationDate == "" {
+		if xmp, _, err := readXMP(ctx, catalog); err == nil && xmp != "" {
+			if metadata.Title == "" {
+				metadata.Title = xmpValue(xmpTitlePattern, xmp)
+			}
+			if metadata.Author == "" {
+				metadata.Author = xmpValue(xmpCreatorPattern, xmp)
+			}
+			if metadata.CreationDate == "" {
+				metadata.CreationDate = xmpValue(xmpCreateDatePattern, xmp)
+			}
+		}
+	}
+	return metadata, nil
+}
+
+// pdfMetadata is ReadPDFMetadata for IncludeMetadata: a failure is only a
+// warning, as the invoice itself was extracted
+func (z *ZUGFeRDExtractor) pdfMetadata() *PDFMetadata {
+	metadata, err := z.ReadPDFMetadata()
+	if err != nil {
+		z.warnf("Warnung: PDF-Metadaten konnten nicht gelesen werden: %v", err)
+		return nil
+	}
+	return metadata
+}
+
+// pageCount returns the /Count of the page tree, or zero if it is missing
+func pageCount(ctx *model.Context, catalog types.Dict) int {
+	pages, err := dereferenceDictEntry(ctx.XRefTable, catalog, "Pages")
+	if err != nil || pages == nil {
+		return 0
+	}
+	o, found := pages.Find("Count")
+	if !found {
+		return 0
+	}
+	count, err := ctx.DereferenceInteger(o)
+	if err != nil || count == nil {
+		return 0
+	}
+	return count.Value()
+}
+
+// infoString returns a text entry of the information dictionary, empty if
+// it is missing or not a string
+func infoString(ctx *model.Context, info types.Dict, key string) string {
+	o, found := info.Find(key)
+	if !found {
+		return ""
+	}
+	s, err := ctx.DereferenceStringOrHexLiteral(o, model.V10, nil)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(s)
+}
+
+// pdfDate converts a PDF date such as D:20240301120000+01'00' to RFC 3339
+func pdfDate(s string) string {
+	if s == "" {
+		return ""
+	}
+	if t, ok := types.DateTime(s, true); ok {
+		return t.Format(time.RFC3339)
+	}
+	return s
+}
+
+// xmpValue returns the first non-empty group matched by pattern, with XML
+// entities resolved
+func xmpValue(pattern *regexp.Regexp, xmp string) string {
+	return strings.TrimSpace(html.UnescapeString(firstGroup(pattern.FindStringSubmatch(xmp))))
+}
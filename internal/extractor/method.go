@@ -0,0 +1,111 @@
+package extractor
+
+import (
+	"errors"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// Method selects how the embedded files are read from the PDF
+type Method string
+
+// Extraction methods; the zero value is MethodAuto
+const (
+	// MethodAuto tries the standard, relaxed, AF, annotation and manual
+	// method in turn
+	MethodAuto Method = "auto"
+	// MethodStandard uses pdfcpu with strict validation
+	MethodStandard Method = "standard"
+	// MethodRelaxed uses pdfcpu with relaxed validation
+	MethodRelaxed Method = "relaxed"
+	// MethodAF reads the files of the catalog's /AF array
+	MethodAF Method = "af"
+	// MethodAnnotation reads the files of the FileAttachment annotations
+	// of the pages
+	MethodAnnotation Method = "annotation"
+	// MethodManual scans the raw PDF bytes for the XML
+	MethodManual Method = "manual"
+)
+
+// ParseMethod returns the method named s; the name is compared
+// case-insensitively and an empty name means MethodAuto. The error for an
+// unknown name is translated into lang.
+func ParseMethod(s string, lang string) (Method, error) {
+	switch method := Method(strings.ToLower(strings.TrimSpace(s))); method {
+	case "":
+		return MethodAuto, nil
+	case MethodAuto, MethodStandard, MethodRelaxed, MethodAF, MethodAnnotation, MethodManual:
+		return method, nil
+	}
+	return "", i18n.Errorf(lang, "unbekannte Extraktionsmethode: %s (erlaubt: standard, relaxed, af, annotation, manual, auto)", s)
+}
+
+// Verbatim reports whether the method returns the embedded file streams
+// byte for byte. The manual method reconstructs the XML from the raw PDF
+// bytes, guessing where a document starts and ends, so its result may
+// differ from what was embedded.
+func (m Method) Verbatim() bool {
+	return m != MethodManual
+}
+
+// extractAttachmentsWith reads the embedded files with a single method and
+// returns its error without falling back to another method
+func (z *ZUGFeRDExtractor) extractAttachmentsWith(method Method, encrypted bool) (map[string][]byte, error) {
+	switch method {
+	case MethodStandard:
+		attachments, err := z.extractAttachmentsStandard()
+		if err != nil {
+			return nil, z.methodError(err)
+		}
+		return attachments, nil
+	case MethodRelaxed:
+		attachments, err := z.extractAttachmentsRelaxed()
+		if err != nil {
+			return nil, z.methodError(err)
+		}
+		return attachments, nil
+	case MethodAF:
+		attachments, err := z.extractAttachmentsAF()
+		if err != nil {
+			return nil, z.methodError(err)
+		}
+		return attachments, nil
+	case MethodAnnotation:
+		attachments, err := z.extractAttachmentsAnnotations()
+		if err != nil {
+			return nil, z.methodError(err)
+		}
+		return attachments, nil
+	case MethodManual:
+		if encrypted {
+			return nil, z.encryptedError("PDF ist verschlüsselt, die manuelle Extraktion ist nicht möglich")
+		}
+		attachments, err := z.extractAttachmentsManual()
+		if err != nil {
+			return nil, z.methodError(err)
+		}
+		return attachments, nil
+	}
+	return nil, i18n.Errorf(z.Lang, "unbekannte Extraktionsmethode: %s (erlaubt: standard, relaxed, af, annotation, manual, auto)", method)
+}
+
+// methodError returns err as an ExtractError; the messages of the single
+// methods already name the method that failed
+func (z *ZUGFeRDExtractor) methodError(err error) error {
+	var extractErr *ExtractError
+	if errors.As(err, &extractErr) {
+		return err
+	}
+	return &ExtractError{Kind: ErrPDFParse, Path: z.InputPath, Err: err}
+}
+
+// methodErrorKind returns the kind of an ExtractError, or ErrPDFParse for
+// any other error; an unreadable input is an I/O problem, not a broken PDF
+func methodErrorKind(err error) ErrorKind {
+	var extractErr *ExtractError
+	if errors.As(err, &extractErr) {
+		return extractErr.Kind
+	}
+	return ErrPDFParse
+}
@@ -0,0 +1,43 @@
+package extractor
+
+import (
+	"errors"
+	"mime"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// ErrMimeType is returned with StrictMimeType when the XML attachment
+// declares no XML MIME type
+var ErrMimeType = errors.New("kein XML-MIME-Typ deklariert")
+
+// IsXMLMimeType reports whether mimeType is text/xml, application/xml or an
+// XML based type such as application/vnd.example+xml. Parameters such as a
+// charset are ignored.
+func IsXMLMimeType(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/xml" || mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// checkMimeType warns about or, with StrictMimeType, rejects an XML
+// attachment whose file specification declares a non-XML MIME type such as
+// application/octet-stream, which strict recipients refuse
+func (z *ZUGFeRDExtractor) checkMimeType(spec fileSpec) error {
+	if IsXMLMimeType(spec.mimeType) {
+		return nil
+	}
+
+	declared := spec.mimeType
+	if declared == "" {
+		declared = "-"
+	}
+	if z.StrictMimeType {
+		return i18n.Wrap(ErrMimeType, z.Lang, "%s deklariert den MIME-Typ %s statt text/xml oder application/xml", spec.name, declared)
+	}
+	z.warnf("Warnung: %s deklariert den MIME-Typ %s statt text/xml oder application/xml", spec.name, declared)
+	return nil
+}
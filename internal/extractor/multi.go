@@ -0,0 +1,104 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// ExtractAllXML returns every embedded file that is a well-formed ZUGFeRD
+// XML, keyed by attachment name, for a PDF that carries several invoices.
+// Like for a single invoice, only files with a known ZUGFeRD name or the
+// extension .xml are considered. A PDF without any invoice XML fails
+// with an ExtractError of kind ErrNoZUGFeRDXML.
+func (z *ZUGFeRDExtractor) ExtractAllXML() (map[string][]byte, error) {
+	return z.extractAllXML(context.Background())
+}
+
+// extractAllXML is ExtractAllXML with cancellation support
+func (z *ZUGFeRDExtractor) extractAllXML(ctx context.Context) (map[string][]byte, error) {
+	attachments, _, err := z.extractAttachments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices := make(map[string][]byte)
+	for _, name := range sortedNames(attachments) {
+		data := attachments[name]
+		if !z.isKnownXMLFilename(name) && !strings.HasSuffix(strings.ToLower(name), ".xml") {
+			continue
+		}
+		if !z.isZUGFeRDXML(data) {
+			z.logf("  Kandidat verworfen: %s (kein ZUGFeRD-Inhalt)\n", name)
+			continue
+		}
+		if err := z.checkWellFormed(data); err != nil {
+			z.logf("  Kandidat verworfen: %s (%v)\n", name, err)
+			continue
+		}
+		z.logf("  ZUGFeRD-XML gefunden: %s\n", name)
+		invoices[name] = data
+	}
+
+	if len(invoices) == 0 {
+		return nil, z.errorf(ErrNoZUGFeRDXML, "ZUGFeRD XML nicht gefunden: %v", i18n.Errorf(z.Lang, "kein ZUGFeRD-XML-Anhang gefunden. Verfügbare Anhänge: %v", sortedNames(attachments)))
+	}
+	return invoices, nil
+}
+
+// WriteAllXML writes every invoice XML of ExtractAllXML to a numbered file
+// named after the PDF, e.g. sammelrechnung_1.xml, sammelrechnung_2.xml, in
+// the order of the attachment names. The files are placed in outputDir or,
+// if it is empty, next to the PDF; Pretty, StripBOM, Gzip, NoClobber and
+// DryRun apply as for a single XML. It returns the output paths by
+// attachment name.
+func (z *ZUGFeRDExtractor) WriteAllXML(outputDir string) (map[string]string, error) {
+	invoices, err := z.extractAllXML(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	if outputDir == "" {
+		outputDir = filepath.Dir(z.InputPath)
+	}
+	baseName := strings.TrimSuffix(filepath.Base(z.InputPath), filepath.Ext(z.InputPath))
+
+	written := make(map[string]string, len(invoices))
+	for i, name := range sortedNames(invoices) {
+		outputPath := filepath.Join(outputDir, fmt.Sprintf("%s_%d.xml", baseName, i+1))
+		data, err := z.outputXML(invoices[name])
+		if err != nil {
+			return written, err
+		}
+		if z.Gzip {
+			outputPath = gzipPath(outputPath)
+			if data, err = gzipData(data); err != nil {
+				return written, z.errorf(ErrIO, "Fehler beim Komprimieren der XML: %v", err)
+			}
+		}
+		if z.DryRun {
+			err = z.checkOutputPath(outputPath)
+		} else {
+			err = z.saveXMLToFile(data, outputPath)
+		}
+		if err != nil {
+			return written, z.errorf(ErrIO, "Fehler beim Speichern der XML-Datei: %v", err)
+		}
+		written[name] = outputPath
+	}
+	return written, nil
+}
+
+// sortedNames returns the keys of files in sorted order
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
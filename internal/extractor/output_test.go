@@ -0,0 +1,135 @@
+package extractor
+
+import "testing"
+
+// outputPathTests covers the generated output names; the windows cases use
+// the Windows path syntax, whatever the running system
+var outputPathTests = []struct {
+	name      string
+	windows   bool
+	input     string
+	outputDir string
+	want      string
+}{
+	{name: "next to the PDF", input: "/srv/eingang/rechnung.pdf", want: "/srv/eingang/factur-x.xml"},
+	{name: "output directory", input: "/srv/eingang/rechnung.pdf", outputDir: "/srv/xml", want: "/srv/xml/rechnung.xml"},
+	{name: "upper case extension", input: "/srv/eingang/RECHNUNG.PDF", outputDir: "/srv/xml", want: "/srv/xml/RECHNUNG.xml"},
+	{name: "dots in the name", input: "/srv/eingang/re.2024.01.pdf", outputDir: "/srv/xml", want: "/srv/xml/re.2024.01.xml"},
+	{name: "drive letter next to the PDF", windows: true, input: `C:\Rechnungen\2024\rechnung.pdf`, want: `C:\Rechnungen\2024\factur-x.xml`},
+	{name: "drive letter", windows: true, input: `C:\Rechnungen\2024\rechnung.pdf`, outputDir: `D:\xml`, want: `D:\xml\rechnung.xml`},
+	{name: "drive letter with trailing separator", windows: true, input: `C:\Rechnungen\rechnung.pdf`, outputDir: `D:\xml\`, want: `D:\xml\rechnung.xml`},
+	{name: "mixed separators", windows: true, input: `C:/Rechnungen\2024/rechnung.pdf`, outputDir: `D:\xml`, want: `D:\xml\rechnung.xml`},
+	{name: "UNC share next to the PDF", windows: true, input: `\\server\share\eingang\rechnung.pdf`, want: `\\server\share\eingang\factur-x.xml`},
+	{name: "UNC share as input", windows: true, input: `\\server\share\eingang\rechnung.pdf`, outputDir: `C:\xml`, want: `C:\xml\rechnung.xml`},
+	{name: "UNC share as output", windows: true, input: `C:\Rechnungen\rechnung.pdf`, outputDir: `\\server\share\xml`, want: `\\server\share\xml\rechnung.xml`},
+}
+
+// setPathSyntax makes the output paths use the Windows path syntax or not
+// until the test ends
+func setPathSyntax(t *testing.T, windows bool) {
+	saved := windowsPaths
+	windowsPaths = windows
+	t.Cleanup(func() { windowsPaths = saved })
+}
+
+func TestGenerateOutputPath(t *testing.T) {
+	for _, tt := range outputPathTests {
+		t.Run(tt.name, func(t *testing.T) {
+			setPathSyntax(t, tt.windows)
+			z := &ZUGFeRDExtractor{InputPath: tt.input, OutputDir: tt.outputDir}
+			got, err := z.generateOutputPath("factur-x.xml", nil)
+			if err != nil {
+				t.Fatalf("generateOutputPath: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("generateOutputPath = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOutputBaseMatchesSingleFile checks that a batch run names its outputs
+// like a single file with the same output directory
+func TestOutputBaseMatchesSingleFile(t *testing.T) {
+	for _, tt := range outputPathTests {
+		if tt.outputDir == "" {
+			continue
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			bp := &BatchProcessor{OutputDir: tt.outputDir}
+			setPathSyntax(t, tt.windows)
+			dir, baseName := bp.outputBase(tt.input)
+			if got := joinOutputPath(dir, baseName+".xml", tt.windows); got != tt.want {
+				t.Errorf("outputBase = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentBase(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"factur-x.xml", "factur-x.xml"},
+		{"xml/factur-x.xml", "factur-x.xml"},
+		{`C:\Rechnungen\factur-x.xml`, "factur-x.xml"},
+		{`\\server\share\factur-x.xml`, "factur-x.xml"},
+		{`C:/Rechnungen\2024/factur-x.xml`, "factur-x.xml"},
+	}
+
+	for _, tt := range tests {
+		if got := attachmentBase(tt.name); got != tt.want {
+			t.Errorf("attachmentBase(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitPDFPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		windows  bool
+		dir      string
+		baseName string
+	}{
+		{path: "rechnung.pdf", dir: ".", baseName: "rechnung"},
+		{path: "/rechnung.pdf", dir: "/", baseName: "rechnung"},
+		{path: `/srv/C:\eingang\rechnung.pdf`, dir: "/srv", baseName: `C:\eingang\rechnung`},
+		{path: "rechnung.pdf", windows: true, dir: ".", baseName: "rechnung"},
+		{path: `C:\rechnung.pdf`, windows: true, dir: `C:\`, baseName: "rechnung"},
+		{path: `C:rechnung.pdf`, windows: true, dir: `C:.`, baseName: "rechnung"},
+		{path: `\\server\share\rechnung.pdf`, windows: true, dir: `\\server\share\`, baseName: "rechnung"},
+		{path: `//server/share/eingang/rechnung.pdf`, windows: true, dir: `\\server\share\eingang`, baseName: "rechnung"},
+	}
+
+	for _, tt := range tests {
+		dir, baseName := splitPDFPath(tt.path, tt.windows)
+		if dir != tt.dir || baseName != tt.baseName {
+			t.Errorf("splitPDFPath(%q, %v) = %s, %s, want %s, %s", tt.path, tt.windows, dir, baseName, tt.dir, tt.baseName)
+		}
+	}
+}
+
+func TestJoinOutputPath(t *testing.T) {
+	tests := []struct {
+		dir     string
+		windows bool
+		want    string
+	}{
+		{dir: "", want: "factur-x.xml"},
+		{dir: "/srv/xml/", want: "/srv/xml/factur-x.xml"},
+		{dir: `D:\xml`, want: `D:\xml/factur-x.xml`},
+		{dir: "", windows: true, want: "factur-x.xml"},
+		{dir: `C:`, windows: true, want: `C:factur-x.xml`},
+		{dir: `C:\`, windows: true, want: `C:\factur-x.xml`},
+		{dir: `D:\xml\\2024\..\`, windows: true, want: `D:\xml\factur-x.xml`},
+		{dir: `\\server\share`, windows: true, want: `\\server\share\factur-x.xml`},
+		{dir: `//server/share/xml/`, windows: true, want: `\\server\share\xml\factur-x.xml`},
+	}
+
+	for _, tt := range tests {
+		if got := joinOutputPath(tt.dir, "factur-x.xml", tt.windows); got != tt.want {
+			t.Errorf("joinOutputPath(%q, %v) = %s, want %s", tt.dir, tt.windows, got, tt.want)
+		}
+	}
+}
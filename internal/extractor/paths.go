@@ -0,0 +1,127 @@
+package extractor
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// CollisionPolicy decides what happens when two files of a batch run
+// resolve to the same output path
+type CollisionPolicy string
+
+// Collision policies; the zero value is CollisionRename
+const (
+	// CollisionRename appends a numeric suffix to the later output, e.g.
+	// factur-x_1.xml
+	CollisionRename CollisionPolicy = "rename"
+	// CollisionError fails the later file
+	CollisionError CollisionPolicy = "error"
+	// CollisionOverwrite lets the later file overwrite the earlier output
+	CollisionOverwrite CollisionPolicy = "overwrite"
+)
+
+// ParseCollisionPolicy returns the policy named s; the name is compared
+// case-insensitively and an empty name means CollisionRename. The error for
+// an unknown name is translated into lang.
+func ParseCollisionPolicy(s string, lang string) (CollisionPolicy, error) {
+	switch policy := CollisionPolicy(strings.ToLower(strings.TrimSpace(s))); policy {
+	case "":
+		return CollisionRename, nil
+	case CollisionRename, CollisionError, CollisionOverwrite:
+		return policy, nil
+	}
+	return "", i18n.Errorf(lang, "unbekannte Regel für Namenskonflikte: %s (erlaubt: rename, error, overwrite)", s)
+}
+
+// pathRegistry hands out unique output paths to the workers of a batch run
+type pathRegistry struct {
+	policy CollisionPolicy
+
+	mu sync.Mutex
+	// owner maps every claimed path to the input file it was claimed for
+	owner map[string]string
+	// collided holds the inputs whose output path was already claimed
+	collided map[string]bool
+
+	// existing makes a path that already exists on disk count as claimed,
+	// so the outputs of earlier runs are kept, see Watcher
+	existing bool
+}
+
+func newPathRegistry(policy CollisionPolicy) *pathRegistry {
+	if policy == "" {
+		policy = CollisionRename
+	}
+	return &pathRegistry{policy: policy, owner: make(map[string]string), collided: make(map[string]bool)}
+}
+
+// claim reserves path for the input file owner and returns the path to
+// write to and whether it collided with another input. A path that is
+// claimed for another input or, with existing set, already on disk is
+// resolved by the policy: renamed with a numeric suffix, rejected with an
+// error wrapping fs.ErrExist or handed out again. Claiming the same path
+// for the same input again, e.g. when a file is retried, is no collision.
+func (r *pathRegistry) claim(path, owner, lang string) (string, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	first, taken := r.owner[path]
+	if taken && first == owner {
+		return path, false, nil
+	}
+	if !taken && !r.onDisk(path) {
+		r.owner[path] = owner
+		return path, false, nil
+	}
+	r.collided[owner] = true
+
+	switch r.policy {
+	case CollisionError:
+		if !taken {
+			return "", true, i18n.Wrap(fs.ErrExist, lang, "Ausgabedatei existiert bereits: %s", path)
+		}
+		return "", true, i18n.Wrap(fs.ErrExist, lang, "Ausgabepfad %s wird bereits für %s verwendet", path, first)
+	case CollisionOverwrite:
+		r.owner[path] = owner
+		return path, true, nil
+	}
+
+	// A gzipped output keeps both extensions after the suffix
+	ext := filepath.Ext(path)
+	if strings.EqualFold(ext, ".gz") {
+		ext = filepath.Ext(strings.TrimSuffix(path, ext)) + ext
+	}
+	stem := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", stem, i, ext)
+		claimed, taken := r.owner[candidate]
+		if taken && claimed == owner || !taken && !r.onDisk(candidate) {
+			r.owner[candidate] = owner
+			return candidate, true, nil
+		}
+	}
+}
+
+// onDisk reports whether path already exists on disk and counts as
+// claimed because existing is set
+func (r *pathRegistry) onDisk(path string) bool {
+	if !r.existing {
+		return false
+	}
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// collisionCount returns the number of inputs whose output path collided
+// with that of another input
+func (r *pathRegistry) collisionCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.collided)
+}
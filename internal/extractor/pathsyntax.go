@@ -0,0 +1,72 @@
+package extractor
+
+import (
+	"path"
+	"runtime"
+	"strings"
+)
+
+// windowsPaths selects the path syntax of the output paths, which is that
+// of the running system
+var windowsPaths = runtime.GOOS == "windows"
+
+// splitPDFPath returns the directory of the PDF at p and its name without
+// extension, like filepath.Dir and filepath.Base on a system with the given
+// path syntax. With the Windows syntax / and \ both separate elements, and
+// a drive letter or a \\server\share prefix stays part of the directory,
+// e.g. C:\Rechnungen\rechnung.pdf yields C:\Rechnungen and rechnung.
+func splitPDFPath(p string, windows bool) (dir, baseName string) {
+	volume, slashed := "", p
+	if windows {
+		p = strings.ReplaceAll(p, "/", `\`)
+		volume = p[:windowsVolumeLen(p)]
+		slashed = strings.ReplaceAll(p[len(volume):], `\`, "/")
+	}
+
+	dir = path.Dir(slashed)
+	baseName = path.Base(slashed)
+	baseName = strings.TrimSuffix(baseName, path.Ext(baseName))
+	if windows {
+		dir = volume + strings.ReplaceAll(dir, "/", `\`)
+	}
+	return dir, baseName
+}
+
+// joinOutputPath returns the path of the file name in dir, like
+// filepath.Join on a system with the given path syntax. An output
+// directory such as D:\xml\ or \\server\share yields D:\xml\name or
+// \\server\share\name with the Windows syntax on any system.
+func joinOutputPath(dir, name string, windows bool) string {
+	if !windows {
+		return path.Join(dir, name)
+	}
+
+	dir = strings.ReplaceAll(dir, "/", `\`)
+	volume := dir[:windowsVolumeLen(dir)]
+	slashed := strings.ReplaceAll(dir[len(volume):], `\`, "/")
+	if slashed == "" && strings.HasPrefix(volume, `\\`) {
+		// A share is always a root, \\server\share\name
+		slashed = "/"
+	}
+	return volume + strings.ReplaceAll(path.Join(slashed, name), "/", `\`)
+}
+
+// windowsVolumeLen returns the length of the drive letter (C:) or the
+// \\server\share prefix at the start of a Windows path with \ separators
+func windowsVolumeLen(p string) int {
+	if len(p) >= 2 && p[1] == ':' && ('a' <= p[0] && p[0] <= 'z' || 'A' <= p[0] && p[0] <= 'Z') {
+		return 2
+	}
+	if !strings.HasPrefix(p, `\\`) {
+		return 0
+	}
+	server := strings.IndexByte(p[2:], '\\')
+	if server <= 0 {
+		return 0
+	}
+	share := 2 + server + 1
+	if end := strings.IndexByte(p[share:], '\\'); end != -1 {
+		return share + end
+	}
+	return len(p)
+}
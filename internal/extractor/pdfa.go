@@ -0,0 +1,136 @@
+package extractor
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// XMP properties of the PDF/A identification schema, in element or
+// attribute notation
+var (
+	pdfaPartPattern        = regexp.MustCompile(`pdfaid:part\s*(?:=\s*["']\s*(\d+)|>\s*(\d+)\s*<)`)
+	pdfaConformancePattern = regexp.MustCompile(`pdfaid:conformance\s*(?:=\s*["']\s*([A-Za-z])|>\s*([A-Za-z])\s*<)`)
+)
+
+// CheckPDFA3 reports whether the PDF claims PDF/A-3 conformance. It checks
+// the PDF/A identification in the XMP metadata and the PDF/A output intent
+// and lists every missing marker. This is not a full PDF/A validation; it
+// only verifies what the document declares about itself.
+func CheckPDFA3(pdfPath string) (bool, []string, error) {
+	z := &ZUGFeRDExtractor{InputPath: pdfPath}
+	return z.CheckPDFA3()
+}
+
+// CheckPDFA3 checks the input PDF like the function CheckPDFA3, using the
+// language and password of the extractor
+func (z *ZUGFeRDExtractor) CheckPDFA3() (bool, []string, error) {
+	input, err := z.openInput()
+	if err != nil {
+		return false, nil, err
+	}
+	defer input.Close()
+
+	ctx, err := z.readPDFContext(input)
+	if err != nil {
+		return false, nil, z.errorf(ErrPDFParse, "PDF konnte nicht gelesen werden: %v", err)
+	}
+
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return false, nil, z.errorf(ErrPDFParse, "PDF konnte nicht gelesen werden: %v", err)
+	}
+
+	missing := checkXMPIdentification(ctx, catalog, z.Lang)
+	if !hasPDFAOutputIntent(ctx, catalog) {
+		missing = append(missing, i18n.T(z.Lang, "OutputIntent mit /S /GTS_PDFA1 fehlt"))
+	}
+
+	return len(missing) == 0, missing, nil
+}
+
+// checkXMPIdentification returns the PDF/A-3 markers missing from the XMP
+// metadata of the document catalog, translated into lang
+func checkXMPIdentification(ctx *model.Context, catalog types.Dict, lang string) []string {
+	xmp, found, err := readXMP(ctx, catalog)
+	if !found {
+		return []string{i18n.T(lang, "XMP-Metadaten (/Metadata) fehlen")}
+	}
+	if err != nil {
+		return []string{i18n.T(lang, "XMP-Metadaten (/Metadata) können nicht gelesen werden")}
+	}
+
+	var missing []string
+	switch part := firstGroup(pdfaPartPattern.FindStringSubmatch(xmp)); part {
+	case "":
+		missing = append(missing, i18n.T(lang, "pdfaid:part fehlt in den XMP-Metadaten"))
+	case "3":
+	default:
+		missing = append(missing, i18n.Sprintf(lang, "pdfaid:part ist %s statt 3", part))
+	}
+	if firstGroup(pdfaConformancePattern.FindStringSubmatch(xmp)) == "" {
+		missing = append(missing, i18n.T(lang, "pdfaid:conformance fehlt in den XMP-Metadaten"))
+	}
+	return missing
+}
+
+// readXMP returns the decoded XMP metadata stream of the document catalog;
+// found is false if the catalog has none
+func readXMP(ctx *model.Context, catalog types.Dict) (xmp string, found bool, err error) {
+	obj, found := catalog.Find("Metadata")
+	if !found {
+		return "", false, nil
+	}
+
+	stream, _, err := ctx.DereferenceStreamDict(obj)
+	if err == nil && stream == nil {
+		err = errors.New("/Metadata ist kein Stream")
+	}
+	if err != nil {
+		return "", true, err
+	}
+	if err := stream.Decode(); err != nil {
+		return "", true, err
+	}
+	return string(stream.Content), true, nil
+}
+
+// hasPDFAOutputIntent reports whether the catalog declares a PDF/A output
+// intent
+func hasPDFAOutputIntent(ctx *model.Context, catalog types.Dict) bool {
+	obj, found := catalog.Find("OutputIntents")
+	if !found {
+		return false
+	}
+	intents, err := ctx.DereferenceArray(obj)
+	if err != nil {
+		return false
+	}
+	for _, entry := range intents {
+		intent, err := ctx.DereferenceDict(entry)
+		if err != nil || intent == nil {
+			continue
+		}
+		if subtype := intent.NameEntry("S"); subtype != nil && *subtype == "GTS_PDFA1" {
+			return true
+		}
+	}
+	return false
+}
+
+// firstGroup returns the first non-empty capture group of a match
+func firstGroup(match []string) string {
+	if len(match) == 0 {
+		return ""
+	}
+	for _, group := range match[1:] {
+		if group != "" {
+			return group
+		}
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pdfHeader starts every PDF file
+var pdfHeader = []byte("%PDF-")
+
+// isPortfolio reports whether the catalog of the PDF has a /Collection
+// entry, which makes it a PDF portfolio whose content are its embedded
+// files. A PDF that cannot be read is no portfolio.
+func (z *ZUGFeRDExtractor) isPortfolio() bool {
+	input, err := z.openInput()
+	if err != nil {
+		return false
+	}
+	defer input.Close()
+
+	ctx, err := z.readPDFContext(input)
+	if err != nil {
+		return false
+	}
+	catalog, err := ctx.Catalog()
+	if err != nil {
+		return false
+	}
+	_, found := catalog.Find("Collection")
+	return found
+}
+
+// portfolioPDFs returns the names of the embedded files that are PDFs, by
+// content or by extension, in sorted order
+func portfolioPDFs(attachments map[string][]byte) []string {
+	var names []string
+	for name, data := range attachments {
+		if bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), pdfHeader) || strings.EqualFold(filepath.Ext(name), ".pdf") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// extractFromPortfolio searches the PDFs embedded in a portfolio for the
+// ZUGFeRD XML, in the order of their names, and returns that of the first
+// one that carries it. The inner PDFs are read like the portfolio itself,
+// but are not searched for portfolios of their own. The errors of the
+// single PDFs are only logged; if none carries the XML, the error says so.
+func (z *ZUGFeRDExtractor) extractFromPortfolio(ctx context.Context, attachments map[string][]byte) ([]byte, string, Method, error) {
+	names := portfolioPDFs(attachments)
+	if len(names) == 0 {
+		return nil, "", "", z.errorf(ErrNoZUGFeRDXML, "ZUGFeRD XML nicht gefunden: PDF-Portfolio enthält weder eine ZUGFeRD-XML noch eine PDF-Datei")
+	}
+
+	for _, name := range names {
+		z.event([]any{"event", "portfolio_pdf", "name", name}, "  PDF-Portfolio: durchsuche eingebettete PDF %s\n", name)
+		inner := &ZUGFeRDExtractor{
+			InputPath:           filepath.Join(z.InputPath, attachmentBase(name)),
+			AdditionalFilenames: z.AdditionalFilenames,
+			NoManual:            z.NoManual,
+			MinConfidence:       z.MinConfidence,
+			Method:              z.Method,
+			Password:            z.Password,
+			Lang:                z.Lang,
+			Timeout:             z.Timeout,
+			TempDir:             z.TempDir,
+			KeepTemp:            z.KeepTemp,
+			Logger:              z.Logger,
+			IncludeTimings:      z.IncludeTimings,
+			input:               attachments[name],
+			nested:              true,
+			timings:             z.timings,
+		}
+		xmlData, xmlFilename, method, err := inner.extractXMLData(ctx)
+		if err == nil {
+			z.event([]any{"event", "portfolio_xml", "name", name, "xml", xmlFilename}, "  ZUGFeRD-XML in eingebetteter PDF %s gefunden: %s\n", name, xmlFilename)
+			return xmlData, xmlFilename, method, nil
+		}
+		if ctx.Err() != nil {
+			return nil, "", "", err
+		}
+		z.logf("  %s verworfen: %v\n", name, err)
+	}
+	return nil, "", "", z.errorf(ErrNoZUGFeRDXML, "ZUGFeRD XML nicht gefunden: keine der %d PDF-Dateien des PDF-Portfolios enthält eine ZUGFeRD-XML", len(names))
+}
@@ -0,0 +1,106 @@
+package extractor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+
+	"zugferd-extractor/internal/validation"
+)
+
+// prettyIndent is the indentation per nesting level of PrettyXML
+const prettyIndent = "  "
+
+// PrettyXML re-indents an XML document with two spaces per level. The XML
+// declaration, comments, namespace prefixes and the order of attributes are
+// kept; whitespace between elements is replaced by the new indentation.
+// Text is re-escaped, which does not change its value.
+func PrettyXML(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", prettyIndent)
+
+	depth := 0
+	for {
+		// RawToken leaves the prefixes unresolved, so the encoder does not
+		// invent its own namespace declarations
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			attrs := make([]xml.Attr, len(t.Attr))
+			for i, attr := range t.Attr {
+				attrs[i] = xml.Attr{Name: prefixedName(attr.Name), Value: attr.Value}
+			}
+			token = xml.StartElement{Name: prefixedName(t.Name), Attr: attrs}
+			depth++
+		case xml.EndElement:
+			token = xml.EndElement{Name: prefixedName(t.Name)}
+			depth--
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+		}
+		if err := encoder.EncodeToken(token); err != nil {
+			return nil, err
+		}
+
+		// The encoder only indents elements; the declaration and comments
+		// outside the root element get a line of their own
+		switch token.(type) {
+		case xml.ProcInst, xml.Comment, xml.Directive:
+			if depth == 0 {
+				if err := encoder.Flush(); err != nil {
+					return nil, err
+				}
+				buf.WriteByte('\n')
+			}
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// prefixedName folds the raw namespace prefix into the local name, which
+// the encoder writes as is
+func prefixedName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+	return xml.Name{Local: name.Space + ":" + name.Local}
+}
+
+// formatXML returns the XML as it is written: without a leading byte order
+// mark if StripBOM is set, re-indented if Pretty is set, otherwise
+// unchanged. XML that cannot be re-indented is written unchanged with a
+// warning.
+func (z *ZUGFeRDExtractor) formatXML(xmlData []byte) []byte {
+	if z.StripBOM {
+		xmlData = bytes.TrimPrefix(xmlData, validation.UTF8BOM)
+	}
+	if !z.Pretty {
+		return xmlData
+	}
+	formatted, err := PrettyXML(xmlData)
+	if err != nil {
+		z.warnf("Warnung: XML konnte nicht eingerückt werden, wird unverändert gespeichert: %v", err)
+		return xmlData
+	}
+	return formatted
+}
@@ -0,0 +1,81 @@
+package extractor
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// progress prints the number of processed files during a batch run. On a
+// terminal the counter is updated in place, otherwise a line is printed
+// every few files.
+type progress struct {
+	out      io.Writer
+	total    int
+	done     int
+	tty      bool
+	interval int
+	verbose  bool
+	lang     string
+}
+
+func newProgress(out io.Writer, total int, verbose bool, lang string) *progress {
+	interval := total / 10
+	if interval < 1 {
+		interval = 1
+	}
+	return &progress{
+		out:      out,
+		total:    total,
+		tty:      isTerminal(out),
+		interval: interval,
+		verbose:  verbose,
+		lang:     lang,
+	}
+}
+
+// clear removes the in-place counter so a regular line can be printed
+func (p *progress) clear() {
+	if p.tty && p.done > 0 {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+}
+
+// update counts a processed file and prints the counter
+func (p *progress) update(filename string) {
+	p.done++
+
+	line := i18n.Sprintf(p.lang, "[%d/%d] verarbeitet", p.done, p.total)
+	if p.verbose {
+		line += ": " + filename
+	}
+
+	switch {
+	case p.tty:
+		fmt.Fprintf(p.out, "\r\033[K%s", line)
+	case p.done%p.interval == 0 || p.done == p.total:
+		fmt.Fprintln(p.out, line)
+	}
+}
+
+// finish ends the in-place counter line
+func (p *progress) finish() {
+	if p.tty && p.done > 0 {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// isTerminal reports whether w is a character device such as a terminal
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
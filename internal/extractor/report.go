@@ -0,0 +1,75 @@
+package extractor
+
+import (
+	"encoding/csv"
+	"sort"
+	"strconv"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// reportHeader lists the columns of the batch CSV report
+var reportHeader = []string{"input", "status", "output", "error", "profile", "size", "syntax", "checksum", "duplicate_of", "method", "pages", "title", "author", "created"}
+
+// writeReport writes the batch results as CSV, sorted by input file
+func writeReport(path string, results []ProcessResult, lang string) error {
+	sorted := make([]ProcessResult, len(results))
+	copy(sorted, results)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Filename < sorted[j].Filename
+	})
+
+	file, err := createAtomic(path)
+	if err != nil {
+		return i18n.Errorf(lang, "Fehler beim Erstellen des Berichts: %v", err)
+	}
+	defer file.abort()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(reportHeader); err != nil {
+		return i18n.Errorf(lang, "Fehler beim Schreiben des Berichts: %v", err)
+	}
+
+	for _, result := range sorted {
+		status := "ok"
+		errorMessage := ""
+		if result.Error != nil {
+			status = "failed"
+			errorMessage = result.Error.Error()
+		} else if result.DuplicateOf != "" {
+			status = "duplicate"
+		} else if result.Skipped {
+			status = "skipped"
+		}
+
+		record := []string{
+			result.Filename,
+			status,
+			result.OutputPath,
+			errorMessage,
+			result.Profile,
+			strconv.FormatInt(result.FileSize, 10),
+			result.Syntax,
+			result.Checksum,
+			result.DuplicateOf,
+			string(result.Method),
+		}
+		if metadata := result.Metadata; metadata != nil {
+			record = append(record, strconv.Itoa(metadata.Pages), metadata.Title, metadata.Author, metadata.CreationDate)
+		} else {
+			record = append(record, "", "", "", "")
+		}
+		if err := writer.Write(record); err != nil {
+			return i18n.Errorf(lang, "Fehler beim Schreiben des Berichts: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return i18n.Errorf(lang, "Fehler beim Schreiben des Berichts: %v", err)
+	}
+	if err := file.commit(); err != nil {
+		return i18n.Errorf(lang, "Fehler beim Schreiben des Berichts: %v", err)
+	}
+	return nil
+}
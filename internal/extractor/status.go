@@ -0,0 +1,60 @@
+package extractor
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Status is the outcome a status line reports; on a terminal it decides
+// the color of the line
+type Status int
+
+const (
+	// StatusSuccess lines are green
+	StatusSuccess Status = iota
+	// StatusFailure lines are red
+	StatusFailure
+	// StatusWarning lines are yellow
+	StatusWarning
+)
+
+// ANSI escape sequences of the status colors
+const (
+	colorReset  = "\033[0m"
+	colorGreen  = "\033[32m"
+	colorRed    = "\033[31m"
+	colorYellow = "\033[33m"
+)
+
+func (s Status) color() string {
+	switch s {
+	case StatusFailure:
+		return colorRed
+	case StatusWarning:
+		return colorYellow
+	}
+	return colorGreen
+}
+
+// WriteStatus writes a status line such as "✅ rechnung.pdf -> rechnung.xml"
+// to w. The line is colored by status if w is a terminal, unless the
+// NO_COLOR environment variable is set (https://no-color.org) or TERM is
+// dumb; redirected output never contains escape sequences. A trailing
+// newline is kept outside the color, so the next line starts uncolored.
+func WriteStatus(w io.Writer, status Status, line string) {
+	if !colorEnabled(w) {
+		io.WriteString(w, line)
+		return
+	}
+	text := strings.TrimRight(line, "\n")
+	io.WriteString(w, status.color()+text+colorReset+line[len(text):])
+}
+
+// colorEnabled reports whether status lines written to w are colored
+func colorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(w)
+}
@@ -0,0 +1,51 @@
+package extractor
+
+import (
+	"io"
+	"os"
+)
+
+// StdinPath is the input argument that makes the command line tool read
+// the PDF from standard input
+const StdinPath = "-"
+
+// stdinName is the input name of a PDF read with SpoolInput when no
+// InputPath is set
+const stdinName = "stdin.pdf"
+
+// SpoolInput copies the PDF read from r, e.g. standard input, into a
+// temporary file in TempDir that is read instead of InputPath: pdfcpu needs
+// random access, which a pipe does not offer, and unlike
+// NewExtractorFromReader the PDF is not held in memory. An empty InputPath
+// or StdinPath is set to stdin.pdf, which names the input in messages and
+// places the XML in the current directory unless OutputDir or OutputPath
+// is set. The returned function removes the temporary file and must be
+// called once the extractor is no longer used.
+func (z *ZUGFeRDExtractor) SpoolInput(r io.Reader) (func(), error) {
+	if z.InputPath == "" || z.InputPath == StdinPath {
+		z.InputPath = stdinName
+	}
+
+	file, err := os.CreateTemp(z.TempDir, "zugferd_stdin_*.pdf")
+	if err != nil {
+		return nil, z.errorf(ErrIO, "Fehler beim Erstellen der temporären Datei: %v", err)
+	}
+	cleanup := func() { os.Remove(file.Name()) }
+
+	size, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, z.errorf(ErrIO, "Fehler beim Lesen der PDF: %v", err)
+	}
+	if size == 0 {
+		cleanup()
+		return nil, z.errorf(ErrIO, "Keine PDF-Daten gelesen: die Eingabe ist leer")
+	}
+
+	z.logf("PDF gelesen: %s (%d Bytes, zwischengespeichert in %s)\n", z.InputPath, size, file.Name())
+	z.inputFile = file.Name()
+	return cleanup, nil
+}
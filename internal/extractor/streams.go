@@ -0,0 +1,136 @@
+package extractor
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+)
+
+// maxStreamDictLength bounds the bytes kept in front of a "stream" keyword
+// to find the dictionary of the stream
+const maxStreamDictLength = 4096
+
+// embeddedFileType matches the /Type entry of an embedded file stream but
+// not the /EmbeddedFiles name tree
+var embeddedFileType = regexp.MustCompile(`/Type\s*/EmbeddedFile(?:[\s/<>\[\]()]|$)`)
+
+// scanCompressedEmbeddedFiles reads the PDF from r, inflates every
+// FlateDecode compressed embedded file stream and adds the ZUGFeRD XML
+// found in it to attachments. A stream that fails to inflate is skipped.
+func (z *ZUGFeRDExtractor) scanCompressedEmbeddedFiles(r io.Reader, attachments map[string][]byte) error {
+	counter := &countingReader{r: r}
+	reader := bufio.NewReaderSize(counter, manualChunkSize)
+
+	// window holds the bytes read last, which contain the stream dictionary
+	// once a "stream" keyword has been read
+	window := make([]byte, 0, 2*maxStreamDictLength)
+	for {
+		// Every "stream" keyword ends in 'm'
+		chunk, err := reader.ReadSlice('m')
+		window = append(window, chunk...)
+		if len(window) > maxStreamDictLength {
+			window = append(window[:0], window[len(window)-maxStreamDictLength:]...)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil && err != bufio.ErrBufferFull {
+			return err
+		}
+
+		dict, ok := streamDict(window)
+		if !ok || !embeddedFileType.Match(dict) || !bytes.Contains(dict, []byte("/FlateDecode")) {
+			continue
+		}
+		window = window[:0]
+		if err := skipStreamEOL(reader); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		offset := counter.n - int64(reader.Buffered())
+		if err := z.scanFlateStream(reader, offset, attachments); err != nil {
+			z.logf("  Komprimierter Dateistrom an Position %d konnte nicht entpackt werden: %v\n", offset, err)
+		}
+	}
+}
+
+// scanFlateStream inflates the stream data at the current position of r and
+// scans it for ZUGFeRD XML. The deflate format marks its own end, so the
+// stream /Length, which may be an indirect object, is not needed.
+func (z *ZUGFeRDExtractor) scanFlateStream(r *bufio.Reader, offset int64, attachments map[string][]byte) error {
+	inflated, err := zlib.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer inflated.Close()
+
+	err = scanXMLCandidates(inflated, func(_ int64, candidate []byte) {
+		if _, added := z.addManualCandidate(attachments, candidate); added {
+			z.logf("  XML aus komprimiertem Dateistrom an Position %d extrahiert\n", offset)
+		}
+	})
+	// Some PDF writers store a wrong Adler-32 checksum; the data itself has
+	// been inflated completely at that point
+	if errors.Is(err, zlib.ErrChecksum) {
+		return nil
+	}
+	return err
+}
+
+// streamDict returns the dictionary in front of the "stream" keyword that
+// window ends with; ok is false if window does not end with a stream start
+func streamDict(window []byte) (dict []byte, ok bool) {
+	head, found := bytes.CutSuffix(window, []byte("stream"))
+	if !found || bytes.HasSuffix(head, []byte("end")) {
+		return nil, false
+	}
+	head = bytes.TrimRight(head, " \t\r\n\f\x00")
+	if !bytes.HasSuffix(head, []byte(">>")) {
+		return nil, false
+	}
+	// The dictionary starts behind the "obj" keyword of its object
+	if idx := bytes.LastIndex(head, []byte("obj")); idx != -1 {
+		head = head[idx+len("obj"):]
+	}
+	return head, true
+}
+
+// skipStreamEOL consumes the end-of-line marker after the "stream" keyword
+func skipStreamEOL(r *bufio.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch b {
+	case '\n':
+		return nil
+	case '\r':
+		next, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if next != '\n' {
+			return r.UnreadByte()
+		}
+		return nil
+	}
+	return r.UnreadByte()
+}
+
+// countingReader counts the bytes read from r
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,104 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// panicHandler is a slog handler that panics on the message of a phase
+// holding a temporary directory, standing in for a panic of pdfcpu
+type panicHandler struct{}
+
+func (panicHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (panicHandler) Handle(_ context.Context, r slog.Record) error {
+	if strings.HasPrefix(r.Message, "Verwende temporäres Verzeichnis") {
+		panic("handler panic")
+	}
+	return nil
+}
+
+func (h panicHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h panicHandler) WithGroup(string) slog.Handler      { return h }
+
+// copyTestFiles copies every PDF below test-files n times into a temporary
+// directory and returns the copies
+func copyTestFiles(t *testing.T, n int) []string {
+	t.Helper()
+	sources, err := filepath.Glob(filepath.Join("..", "..", "test-files", "*.pdf"))
+	if err != nil || len(sources) == 0 {
+		t.Fatalf("no test PDFs found: %v", err)
+	}
+	dir := t.TempDir()
+	var files []string
+	for _, source := range sources {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base := strings.TrimSuffix(filepath.Base(source), ".pdf")
+		for i := 0; i < n; i++ {
+			path := filepath.Join(dir, fmt.Sprintf("%s_%d.pdf", base, i))
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+func TestConcurrentExtractionsLeaveNoTempDirs(t *testing.T) {
+	tests := []struct {
+		name   string
+		logger *slog.Logger
+		failed bool
+	}{
+		{name: "success"},
+		{name: "panic", logger: slog.New(panicHandler{}), failed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := copyTestFiles(t, 8)
+			tempDir := t.TempDir()
+			bp := &BatchProcessor{
+				Files:     files,
+				OutputDir: t.TempDir(),
+				Workers:   16,
+				TempDir:   tempDir,
+				Logger:    tt.logger,
+				// Receiving the results silences the failures on stderr
+				OnResult: func(ProcessResult) {},
+			}
+
+			results, err := bp.ProcessBatchResults(context.Background())
+			if err != nil && !tt.failed {
+				t.Fatalf("ProcessBatchResults: %v", err)
+			}
+			if len(results) != len(files) {
+				t.Fatalf("got %d results, want %d", len(results), len(files))
+			}
+			for _, result := range results {
+				if (result.Error != nil) != tt.failed {
+					t.Errorf("%s: error %v, want failure %v", result.Filename, result.Error, tt.failed)
+				}
+			}
+
+			entries, err := os.ReadDir(tempDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), "zugferd_extract_") {
+					t.Errorf("temporary directory left behind: %s", entry.Name())
+				}
+			}
+		})
+	}
+}
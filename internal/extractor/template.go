@@ -0,0 +1,66 @@
+package extractor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/invoice"
+)
+
+// templatePlaceholder matches a {field} placeholder of a name template
+var templatePlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// unsafeFilenameChars are replaced in interpolated values so a field cannot
+// leave the output directory or produce an invalid filename
+var unsafeFilenameChars = strings.NewReplacer(
+	"/", "_", "\\", "_", ":", "_", "*", "_", "?", "_",
+	"\"", "_", "<", "_", ">", "_", "|", "_",
+)
+
+// NameTemplateFields lists the placeholders supported in
+// ZUGFeRDExtractor.NameTemplate
+var NameTemplateFields = []string{"invoiceNumber", "date", "seller", "buyer", "currency", "pdf"}
+
+// templateFields returns the placeholder values for inv and the input PDF
+func (z *ZUGFeRDExtractor) templateFields(inv *invoice.Invoice) map[string]string {
+	return map[string]string{
+		"invoiceNumber": inv.Number,
+		"date":          inv.IssueDate.String(),
+		"seller":        inv.SellerName,
+		"buyer":         inv.BuyerName,
+		"currency":      inv.Currency,
+		"pdf":           strings.TrimSuffix(filepath.Base(z.InputPath), filepath.Ext(z.InputPath)),
+	}
+}
+
+// templateFilename expands NameTemplate with the invoice fields of xmlData.
+// It fails if the invoice cannot be parsed or a placeholder is unknown or
+// has no value.
+func (z *ZUGFeRDExtractor) templateFilename(xmlData []byte) (string, error) {
+	inv, err := invoice.ParseInvoice(xmlData, z.Lang)
+	if err != nil {
+		return "", i18n.Errorf(z.Lang, "Rechnungsdaten konnten nicht gelesen werden: %v", err)
+	}
+	fields := z.templateFields(inv)
+
+	var expandErr error
+	filename := templatePlaceholder.ReplaceAllStringFunc(z.NameTemplate, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		value, known := fields[name]
+		value = strings.TrimSpace(value)
+		switch {
+		case expandErr != nil:
+		case !known:
+			expandErr = i18n.Errorf(z.Lang, "unbekannter Platzhalter %s", placeholder)
+		case value == "":
+			expandErr = i18n.Errorf(z.Lang, "Feld %s ist leer", placeholder)
+		}
+		return unsafeFilenameChars.Replace(value)
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return filename, nil
+}
@@ -0,0 +1,135 @@
+package extractor
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Timings are the durations of the phases of one extraction, to find out
+// where the time goes on slow PDFs. They are only measured with
+// ZUGFeRDExtractor.IncludeTimings; without it no clock is read. A phase
+// that did not run, e.g. the manual method after a successful pdfcpu run,
+// is zero.
+type Timings struct {
+	// PDFCPU is the time spent in pdfcpu by the standard, relaxed, AF and
+	// annotation methods, over all methods tried
+	PDFCPU time.Duration
+
+	// Read is the time spent reading the files pdfcpu extracted from the
+	// temporary directory
+	Read time.Duration
+
+	// Manual is the time the manual method spent scanning the raw PDF
+	Manual time.Duration
+
+	// Validation is the time of the XSD and business rule checks
+	Validation time.Duration
+
+	// Write is the time spent formatting, compressing and writing the XML
+	Write time.Duration
+
+	// Total is the time of the whole extraction, including the phases
+	// above
+	Total time.Duration
+
+	// start is when the extraction started
+	start time.Time
+}
+
+// MarshalJSON writes the durations in milliseconds
+func (t *Timings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		PDFCPU     float64 `json:"pdfcpuMs"`
+		Read       float64 `json:"readMs"`
+		Manual     float64 `json:"manualMs"`
+		Validation float64 `json:"validationMs"`
+		Write      float64 `json:"writeMs"`
+		Total      float64 `json:"totalMs"`
+	}{
+		PDFCPU:     milliseconds(t.PDFCPU),
+		Read:       milliseconds(t.Read),
+		Manual:     milliseconds(t.Manual),
+		Validation: milliseconds(t.Validation),
+		Write:      milliseconds(t.Write),
+		Total:      milliseconds(t.Total),
+	})
+}
+
+// milliseconds converts d to milliseconds with microsecond precision
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Round(time.Microsecond)) / float64(time.Millisecond)
+}
+
+// phase is one of the measured phases of Timings
+type phase int
+
+const (
+	phasePDFCPU phase = iota
+	phaseRead
+	phaseManual
+	phaseValidation
+	phaseWrite
+)
+
+// startTimings begins the measurement of an extraction if IncludeTimings
+// is set. The PDFs inside a portfolio add to the timings of the portfolio.
+func (z *ZUGFeRDExtractor) startTimings() {
+	if z.IncludeTimings && !z.nested {
+		z.timings = &Timings{start: time.Now()}
+	}
+}
+
+// finishTimings sets the total duration and returns the timings; it
+// returns nil without IncludeTimings
+func (z *ZUGFeRDExtractor) finishTimings() *Timings {
+	if z.timings == nil {
+		return nil
+	}
+	z.timings.Total = time.Since(z.timings.start)
+	return z.timings
+}
+
+// Timings returns the timings of the last extraction, nil unless
+// IncludeTimings is set
+func (z *ZUGFeRDExtractor) Timings() *Timings {
+	return z.timings
+}
+
+// startPhase returns the start time of a phase, or the zero time without
+// IncludeTimings; it is meant for defer z.endPhase(p, z.startPhase())
+func (z *ZUGFeRDExtractor) startPhase() time.Time {
+	if z.timings == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// endPhase adds the time since start to the phase p
+func (z *ZUGFeRDExtractor) endPhase(p phase, start time.Time) {
+	if z.timings == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	switch p {
+	case phasePDFCPU:
+		z.timings.PDFCPU += elapsed
+	case phaseRead:
+		z.timings.Read += elapsed
+	case phaseManual:
+		z.timings.Manual += elapsed
+	case phaseValidation:
+		z.timings.Validation += elapsed
+	case phaseWrite:
+		z.timings.Write += elapsed
+	}
+}
+
+// printTimings writes the timings in verbose mode
+func (z *ZUGFeRDExtractor) printTimings(timings *Timings) {
+	if timings == nil || !z.Verbose {
+		return
+	}
+	round := func(d time.Duration) time.Duration { return d.Round(time.Microsecond) }
+	z.printf(z.statusWriter(), "  Zeiten: pdfcpu %s, Lesen %s, manuell %s, Validierung %s, Schreiben %s, gesamt %s\n",
+		round(timings.PDFCPU), round(timings.Read), round(timings.Manual), round(timings.Validation), round(timings.Write), round(timings.Total))
+}
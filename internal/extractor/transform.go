@@ -0,0 +1,53 @@
+package extractor
+
+import (
+	"zugferd-extractor/internal/i18n"
+)
+
+// Transformer rewrites the extracted XML before it is written, e.g. by an
+// XSLT, by redacting personal data or by normalizing field values. It must
+// not modify its input in place, since the checksum and the checks are
+// taken from the XML as extracted.
+type Transformer interface {
+	Transform(xmlData []byte) ([]byte, error)
+}
+
+// TransformerFunc adapts a function to a Transformer
+type TransformerFunc func(xmlData []byte) ([]byte, error)
+
+// Transform calls f
+func (f TransformerFunc) Transform(xmlData []byte) ([]byte, error) {
+	return f(xmlData)
+}
+
+// NopTransformer returns the XML unchanged; an empty
+// ZUGFeRDExtractor.Transformers behaves the same
+type NopTransformer struct{}
+
+// Transform returns xmlData
+func (NopTransformer) Transform(xmlData []byte) ([]byte, error) {
+	return xmlData, nil
+}
+
+// transform runs the XML through Transformers in order; the error of a
+// transformer is wrapped, so callers can still match it
+func (z *ZUGFeRDExtractor) transform(xmlData []byte) ([]byte, error) {
+	for i, transformer := range z.Transformers {
+		transformed, err := transformer.Transform(xmlData)
+		if err != nil {
+			return nil, i18n.Errorf(z.Lang, "Transformation %d der XML fehlgeschlagen: %w", i+1, err)
+		}
+		xmlData = transformed
+	}
+	return xmlData, nil
+}
+
+// outputXML returns the XML as it is written: transformed by Transformers,
+// then formatted as configured, see formatXML
+func (z *ZUGFeRDExtractor) outputXML(xmlData []byte) ([]byte, error) {
+	transformed, err := z.transform(xmlData)
+	if err != nil {
+		return nil, err
+	}
+	return z.formatXML(transformed), nil
+}
@@ -0,0 +1,256 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+	"zugferd-extractor/internal/invoice"
+	"zugferd-extractor/internal/validation"
+)
+
+// Check is one of the checks of Validate
+type Check string
+
+const (
+	// CheckWellFormed parses the complete XML
+	CheckWellFormed Check = "wellformed"
+	// CheckSchema checks the element structure of the XML against the
+	// schema of its version, see ZUGFeRDExtractor.ValidateSchema
+	CheckSchema Check = "xsd"
+	// CheckRules checks the EN16931 business rules and the totals, see
+	// ZUGFeRDExtractor.ValidateRules
+	CheckRules Check = "rules"
+)
+
+// CheckResult is the outcome of one check of Validate
+type CheckResult struct {
+	Check Check
+
+	// Err is why the check failed; nil if it passed
+	Err error
+
+	// Violations are the business rules violated with CheckRules,
+	// warnings included; only those with error severity fail the check
+	Violations []validation.RuleViolation
+}
+
+// Passed reports whether the check passed
+func (c CheckResult) Passed() bool {
+	return c.Err == nil
+}
+
+// ValidationReport is the outcome of Validate
+type ValidationReport struct {
+	// Filename is the name of the embedded XML attachment
+	Filename string
+
+	Profile string
+	Syntax  string
+
+	// Method is the extraction method that found the XML
+	Method Method
+
+	// Checks are the results of the checks in the order they ran; the
+	// checks after a failed well-formedness check do not run
+	Checks []CheckResult
+}
+
+// Valid reports whether all checks passed
+func (r *ValidationReport) Valid() bool {
+	for _, check := range r.Checks {
+		if !check.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate extracts the ZUGFeRD XML in memory and runs the given checks on
+// it; no file is written and ValidateRules, ValidateSchema, OutputPath and
+// the other output options have no effect. A failed check is reported in
+// the ValidationReport; the error is only set if the XML could not be
+// extracted.
+//
+// The extraction only accepts a well-formed XML. With CheckWellFormed, an
+// attachment that has a ZUGFeRD name and content but is not well-formed is
+// reported as a failed check instead of being passed over.
+func (z *ZUGFeRDExtractor) Validate(ctx context.Context, checks ...Check) (*ValidationReport, error) {
+	attachments, method, err := z.extractAttachments(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ValidationReport{Method: method}
+	xmlData, xmlFilename, method, err := z.selectXML(ctx, attachments, method)
+	if err != nil {
+		if !hasCheck(checks, CheckWellFormed) {
+			return nil, err
+		}
+		malformed, found := z.malformedXML(attachments)
+		if !found {
+			return nil, err
+		}
+		report.Filename = malformed
+		report.Checks = append(report.Checks, CheckResult{Check: CheckWellFormed, Err: z.checkWellFormed(attachments[malformed])})
+		return report, nil
+	}
+
+	validator := &validation.Validator{Lang: z.Lang}
+	report.Filename, report.Method = xmlFilename, method
+	report.Profile, _ = validator.DetectProfile(xmlData)
+	report.Syntax, _ = validator.DetectSyntax(xmlData)
+
+	for _, check := range checks {
+		result := CheckResult{Check: check}
+		switch check {
+		case CheckWellFormed:
+			result.Err = z.checkWellFormed(xmlData)
+		case CheckSchema:
+			schemaValidator := &validation.Validator{Lang: z.Lang, SchemaDir: z.SchemaDir, Schemas: z.schemas}
+			result.Err = schemaValidator.ValidateAgainstXSD(xmlData)
+		case CheckRules:
+			result.Violations, result.Err = z.ruleViolations(xmlData)
+			if result.Err == nil && validation.HasErrors(result.Violations) {
+				result.Err = i18n.Wrap(ErrBusinessRules, z.Lang, "Geschäftsregeln verletzt: %s", z.InputPath)
+			}
+		default:
+			result.Err = i18n.Errorf(z.Lang, "unbekannte Prüfung: %s", check)
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report, nil
+}
+
+// malformedXML returns the first attachment, in the order of findZUGFeRDXML,
+// that has the indicators of a ZUGFeRD XML but is not well-formed
+func (z *ZUGFeRDExtractor) malformedXML(attachments map[string][]byte) (string, bool) {
+	names := sortedNames(attachments)
+	var candidates []string
+	for _, knownName := range z.xmlFilenames() {
+		if filename, exists := matchFilename(names, knownName); exists {
+			candidates = append(candidates, filename)
+		}
+	}
+	for _, filename := range names {
+		if !z.isKnownXMLFilename(filename) && strings.HasSuffix(strings.ToLower(filename), ".xml") {
+			candidates = append(candidates, filename)
+		}
+	}
+
+	for _, filename := range candidates {
+		data := attachments[filename]
+		if z.isZUGFeRDXML(data) && z.checkWellFormed(data) != nil {
+			return filename, true
+		}
+	}
+	return "", false
+}
+
+// hasCheck reports whether checks contains check
+func hasCheck(checks []Check, check Check) bool {
+	for _, c := range checks {
+		if c == check {
+			return true
+		}
+	}
+	return false
+}
+
+// Violations are the findings of the checks of ValidateSchema and
+// ValidateRules for outputs that carry them along with the XML, such as
+// the JSON output
+type Violations struct {
+	// Schema lists the element structure violations, see
+	// validation.SchemaError
+	Schema []string `json:"schema,omitempty"`
+
+	// Rules are the violated business rules, warnings included
+	Rules []validation.RuleViolation `json:"rules,omitempty"`
+}
+
+// CheckedXML is an XML extracted in memory together with the outcome of
+// its checks, see ExtractCheckedXML
+type CheckedXML struct {
+	// XML is the XML as extracted
+	XML []byte
+
+	// Output is the XML as it would be written, transformed by
+	// Transformers and formatted as configured
+	Output []byte
+
+	// Filename is the name of the embedded XML attachment
+	Filename string
+
+	// Method is the extraction method that found the XML
+	Method Method
+
+	// Violations are nil unless ValidateSchema or ValidateRules is set
+	Violations *Violations
+}
+
+// ExtractCheckedXML extracts the XML in memory and runs the checks of
+// ValidateSchema and ValidateRules on it like ExtractXMLResult, but
+// returns their findings instead of printing them; no file is written. If
+// a check fails, the CheckedXML is returned together with the error that
+// ExtractXMLResult would return.
+func (z *ZUGFeRDExtractor) ExtractCheckedXML(ctx context.Context) (CheckedXML, error) {
+	xmlData, xmlFilename, method, err := z.extractXMLData(ctx)
+	if err != nil {
+		return CheckedXML{}, err
+	}
+	output, err := z.outputXML(xmlData)
+	if err != nil {
+		return CheckedXML{}, err
+	}
+
+	checked := CheckedXML{XML: xmlData, Output: output, Filename: xmlFilename, Method: method}
+	checked.Violations, err = z.checkViolations(xmlData)
+	z.finishTimings()
+	return checked, err
+}
+
+// ParseInvoice parses the core invoice fields of an XML returned by
+// ExtractCheckedXML, with the warnings of ExtractInvoice
+func (z *ZUGFeRDExtractor) ParseInvoice(xmlData []byte) (*invoice.Invoice, error) {
+	inv, _, err := z.parseInvoice(xmlData)
+	return inv, err
+}
+
+// checkViolations runs the checks of ValidateSchema and ValidateRules
+// without printing their findings. Both checks run; the error is that of
+// ExtractXMLResult, the one of the schema check if both fail.
+func (z *ZUGFeRDExtractor) checkViolations(xmlData []byte) (*Violations, error) {
+	if !z.ValidateSchema && !z.ValidateRules {
+		return nil, nil
+	}
+	defer z.endPhase(phaseValidation, z.startPhase())
+
+	violations := &Violations{}
+	var err error
+	if z.ValidateSchema {
+		validator := &validation.Validator{Lang: z.Lang, SchemaDir: z.SchemaDir, Schemas: z.schemas}
+		if schemaErr := validator.ValidateAgainstXSD(xmlData); schemaErr != nil {
+			var violated *validation.SchemaError
+			if errors.As(schemaErr, &violated) {
+				violations.Schema = violated.Violations
+			}
+			err = i18n.Errorf(z.Lang, "Strukturprüfung fehlgeschlagen: %w", schemaErr)
+		}
+	}
+	if z.ValidateRules {
+		rules, rulesErr := z.ruleViolations(xmlData)
+		violations.Rules = rules
+		if rulesErr == nil && validation.HasErrors(rules) {
+			rulesErr = i18n.Wrap(ErrBusinessRules, z.Lang, "Geschäftsregeln verletzt: %s", z.InputPath)
+		}
+		if err == nil {
+			err = rulesErr
+		}
+	}
+	if len(violations.Schema) == 0 && len(violations.Rules) == 0 {
+		return nil, err
+	}
+	return violations, err
+}
@@ -0,0 +1,31 @@
+package extractor
+
+import (
+	"bytes"
+	"os"
+)
+
+// verifyVerbatim re-reads the written outputPath and compares it with the
+// extracted xmlData. The standard, relaxed, AF and annotation methods hand
+// over the decoded embedded file stream unchanged, so a mismatch means the
+// file was not written completely or was changed meanwhile. The manual
+// method cannot guarantee that the bytes are those that were embedded; this
+// is reported as a warning, the written file is still compared.
+func (z *ZUGFeRDExtractor) verifyVerbatim(xmlData []byte, outputPath string, method Method) error {
+	written, err := os.ReadFile(outputPath)
+	if err != nil {
+		return z.errorf(ErrIO, "Geschriebene XML-Datei konnte nicht erneut gelesen werden: %v", err)
+	}
+	if z.Gzip {
+		if written, err = gunzipData(written); err != nil {
+			return z.errorf(ErrIO, "Geschriebene XML-Datei konnte nicht dekomprimiert werden: %v", err)
+		}
+	}
+	if !bytes.Equal(written, xmlData) {
+		return z.errorf(ErrIO, "Geschriebene XML-Datei %s weicht von der extrahierten XML ab", outputPath)
+	}
+	if !method.Verbatim() {
+		z.warnf("Warnung: XML wurde aus den PDF-Rohdaten rekonstruiert, die Übereinstimmung mit der eingebetteten Datei ist nicht garantiert")
+	}
+	return nil
+}
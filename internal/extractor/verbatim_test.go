@@ -0,0 +1,67 @@
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestStandardExtractionVerbatim embeds an XML with details a serializer
+// would change, a BOM, CRLF line ends, a comment, single-quoted attributes
+// and trailing blanks, and checks that the standard method returns it byte
+// for byte
+func TestStandardExtractionVerbatim(t *testing.T) {
+	embedded := append([]byte("\xef\xbb\xbf"), bytes.ReplaceAll(ciiXML(guidelineEN16931), []byte("><rsm:ExchangedDocument>"),
+		[]byte(">\r\n  <!-- Kommentar -->  \r\n<rsm:ExchangedDocument  xmlns:x='urn:x'  >"))...)
+	embedded = append(embedded, "\r\n\t \r\n"...)
+
+	pdfPath := writeFile(t, "blank.pdf", blankPDF())
+	xmlPath := writeFile(t, "invoice.xml", embedded)
+	dir := t.TempDir()
+	zugferdPath := filepath.Join(dir, "zugferd.pdf")
+	if err := EmbedXML(pdfPath, xmlPath, zugferdPath, ""); err != nil {
+		t.Fatalf("EmbedXML: %v", err)
+	}
+
+	outputPath := filepath.Join(dir, "out.xml")
+	z := &ZUGFeRDExtractor{InputPath: zugferdPath, OutputPath: outputPath, Method: MethodStandard, VerifyVerbatim: true, Quiet: true}
+	result, err := z.ExtractXMLResult(context.Background())
+	if err != nil {
+		t.Fatalf("ExtractXMLResult: %v", err)
+	}
+	if !result.Verbatim {
+		t.Error("Result.Verbatim = false for the standard method")
+	}
+
+	written, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, embedded) {
+		t.Errorf("written XML differs from the embedded bytes:\n got %q\nwant %q", written, embedded)
+	}
+
+	var buf bytes.Buffer
+	streamed := &ZUGFeRDExtractor{InputPath: zugferdPath, Method: MethodStandard}
+	if err := streamed.WriteXML(&buf); err != nil {
+		t.Fatalf("WriteXML: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), embedded) {
+		t.Errorf("WriteXML output differs from the embedded bytes:\n got %q\nwant %q", buf.Bytes(), embedded)
+	}
+}
+
+func TestVerifyVerbatimDetectsChange(t *testing.T) {
+	xmlData := ciiXML(guidelineEN16931)
+	outputPath := writeFile(t, "out.xml", append(bytes.Clone(xmlData), '\n'))
+
+	z := &ZUGFeRDExtractor{}
+	if err := z.verifyVerbatim(xmlData, outputPath, MethodStandard); err == nil {
+		t.Error("verifyVerbatim accepts a file that differs from the extracted XML")
+	}
+	if err := z.verifyVerbatim(xmlData, writeFile(t, "same.xml", xmlData), MethodStandard); err != nil {
+		t.Errorf("verifyVerbatim rejects an identical file: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// defaultWatchInterval is the poll interval if Watcher.Interval is not set
+const defaultWatchInterval = 2 * time.Second
+
+// defaultDoneDir is the directory below Watcher.Dir that receives the
+// processed PDF files if Watcher.DoneDir is not set
+const defaultDoneDir = "done"
+
+// Watcher polls a directory for new PDF files and processes them with a
+// BatchProcessor. A file is only processed once its size and modification
+// time are unchanged for a whole interval, so a PDF that is still being
+// copied into the directory is not read half-written. Processed files are
+// moved into DoneDir; failed files stay where they are and are only tried
+// again once they change. Subdirectories are not watched.
+//
+// Every poll is a batch run of its own, so an output name used by an
+// earlier poll is not overwritten: a PDF that arrives under the name of a
+// file processed before resolves the collision by Batch.OnCollision
+// against the XML already on disk.
+type Watcher struct {
+	// Dir is the watched directory
+	Dir string
+
+	// DoneDir receives the processed PDF files; empty means a directory
+	// named done below Dir. It is created if it does not exist.
+	DoneDir string
+
+	// Interval is the time between two polls; zero means
+	// defaultWatchInterval
+	Interval time.Duration
+
+	// Batch processes the arrived files of every poll; its Files are
+	// replaced each time, InputPattern and Archive must be empty
+	Batch *BatchProcessor
+
+	// seen holds the state of every PDF file of the last poll
+	seen map[string]watchState
+}
+
+// watchState is what a poll saw of a PDF file
+type watchState struct {
+	size    int64
+	modTime time.Time
+
+	// failed is set when the file could not be processed, so it is not
+	// tried again until it changes
+	failed bool
+}
+
+// Watch polls the directory until ctx is cancelled. The files of a poll
+// that are being processed when ctx is cancelled are finished first. Only
+// errors that affect the watcher as a whole, such as an unreadable
+// directory, end it early; failed files do not.
+func (w *Watcher) Watch(ctx context.Context) error {
+	if err := os.MkdirAll(w.doneDir(), 0755); err != nil {
+		return i18n.Errorf(w.Batch.Lang, "Fehler beim Erstellen des Zielverzeichnisses: %v", err)
+	}
+	w.seen = make(map[string]watchState)
+	w.Batch.keepExisting = true
+	defer func() {
+		w.seen = nil
+		w.Batch.keepExisting = false
+	}()
+
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	status := w.Batch.statusWriter()
+	w.Batch.printf(status, "Überwache %s alle %s, verarbeitete PDF-Dateien nach %s (Beenden mit Strg+C)\n", w.Dir, interval, w.doneDir())
+	for {
+		if err := w.poll(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			w.Batch.printf(status, "Überwachung beendet\n")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// doneDir returns DoneDir or its default
+func (w *Watcher) doneDir() string {
+	if w.DoneDir != "" {
+		return w.DoneDir
+	}
+	return filepath.Join(w.Dir, defaultDoneDir)
+}
+
+// poll lists the directory, processes the files that have not changed
+// since the last poll and moves the processed ones into the done directory
+func (w *Watcher) poll(ctx context.Context) error {
+	bp := w.Batch
+	entries, err := os.ReadDir(w.Dir)
+	if err != nil {
+		return i18n.Errorf(bp.Lang, "Fehler beim Lesen des überwachten Verzeichnisses: %v", err)
+	}
+
+	// Files that are gone are forgotten, so they count as new if they come
+	// back
+	current := make(map[string]watchState, len(entries))
+	var ready []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.ToLower(filepath.Ext(entry.Name())) != ".pdf" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		filename := filepath.Join(w.Dir, entry.Name())
+		state := watchState{size: info.Size(), modTime: info.ModTime()}
+		if previous, known := w.seen[filename]; known && previous.size == state.size && previous.modTime.Equal(state.modTime) {
+			state.failed = previous.failed
+			if !state.failed && state.size > 0 {
+				ready = append(ready, filename)
+			}
+		}
+		current[filename] = state
+	}
+	w.seen = current
+	if len(ready) == 0 {
+		return nil
+	}
+
+	bp.Files = ready
+	results, err := bp.ProcessBatchResults(ctx)
+	if err != nil && !errors.Is(err, ErrBatchFailures) && !errors.Is(err, ErrBusinessRules) && ctx.Err() == nil {
+		return err
+	}
+
+	for _, result := range results {
+		state, ok := current[result.Filename]
+		if !ok || errors.Is(result.Error, context.Canceled) {
+			continue
+		}
+		if result.Error != nil {
+			state.failed = true
+			current[result.Filename] = state
+			continue
+		}
+
+		target, err := moveToDir(result.Filename, w.doneDir())
+		if err != nil {
+			bp.statusf(bp.errorWriter(), StatusFailure, "❌ %s: Datei konnte nicht verschoben werden: %v\n", result.Filename, err)
+			state.failed = true
+			current[result.Filename] = state
+			continue
+		}
+		delete(current, result.Filename)
+		if bp.Verbose {
+			bp.printf(bp.statusWriter(), "  Verschoben: %s -> %s\n", result.Filename, target)
+		}
+	}
+	return nil
+}
+
+// moveToDir moves a file into dir and returns its new path; a file of the
+// same name in dir is kept and the moved file gets a numeric suffix, e.g.
+// rechnung_1.pdf
+func moveToDir(filename, dir string) (string, error) {
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	target := filepath.Join(dir, base)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(target); err != nil {
+			break
+		}
+		target = filepath.Join(dir, fmt.Sprintf("%s_%d%s", stem, i, ext))
+	}
+	return target, os.Rename(filename, target)
+}
@@ -0,0 +1,486 @@
+package i18n
+
+// english holds the English translations, keyed by the German message
+var english = map[string]string{
+	// Command line
+	"Fehler beim Suchen von Dateien: %v":                                                           "error searching for files: %v",
+	"Keine Dateien gefunden, die dem Muster '%s' entsprechen":                                      "no files found matching the pattern '%s'",
+	"Ausgabe nach stdout ist nur bei einer einzelnen Datei möglich":                                "output to stdout is only possible for a single file",
+	"Fehler beim Erstellen des Ausgabeverzeichnisses: %v":                                          "error creating the output directory: %v",
+	"Fehler beim Überprüfen des Ausgabepfads: %v":                                                  "error checking the output path: %v",
+	"Ausgabepfad muss ein Verzeichnis sein, wenn mehrere Dateien verarbeitet werden":               "the output path must be a directory when processing multiple files",
+	"Unbekannter Prüfsummen-Algorithmus: %s (erlaubt: sha256, sha1)":                               "unknown checksum algorithm: %s (allowed: sha256, sha1)",
+	"-list und -check-pdfa unterstützen keine ZIP-Archive":                                         "-list and -check-pdfa do not support ZIP archives",
+	"Unbekannte Extraktionsmethode: %s (erlaubt: standard, relaxed, af, annotation, manual, auto)": "unknown extraction method: %s (allowed: standard, relaxed, af, annotation, manual, auto)",
+	"Unbekannte Regel für Namenskonflikte: %s (erlaubt: rename, error, overwrite)":                 "unknown collision policy: %s (allowed: rename, error, overwrite)",
+	"unbekannte Regel für Namenskonflikte: %s (erlaubt: rename, error, overwrite)":                 "unknown collision policy: %s (allowed: rename, error, overwrite)",
+	"Ausgabepfad %s wird bereits für %s verwendet":                                                 "output path %s is already used for %s",
+	"Warnung: Ausgabepfad %s wird bereits verwendet, speichere als %s":                             "Warning: output path %s is already in use, saving as %s",
+	"Warnung: Ausgabepfad %s wird bereits verwendet und überschrieben":                             "Warning: output path %s is already in use and is overwritten",
+	"Namenskonflikte bei Ausgabedateien: %d (Regel: %s)\n":                                         "Output filename collisions: %d (policy: %s)\n",
+	"-json und -jsonl können nicht kombiniert werden":                                              "-json and -jsonl cannot be combined",
+	"Batch-Verarbeitungsfehler: %v":                                                                "batch processing error: %v",
+	"Fehler beim Extrahieren der Anhänge: %v":                                                      "error extracting the attachments: %v",
+	"Fehler beim Extrahieren der Rechnungsdaten: %v":                                               "error extracting the invoice data: %v",
+	"Fehler beim Schreiben der JSON-Ausgabe: %v":                                                   "error writing the JSON output: %v",
+	"⚠ %s: keine PDF/A-3-Konformität angegeben\n":                                                  "⚠ %s: no PDF/A-3 conformance declared\n",
+	"  keine eingebetteten Dateien":                                                                "  no embedded files",
+	"  NAME\tGRÖSSE\tMIME-TYP\tAFRELATIONSHIP":                                                     "  NAME\tSIZE\tMIME TYPE\tAFRELATIONSHIP",
+	"Fehler beim Einbetten der XML: %v":                                                            "error embedding the XML: %v",
+	"✓ XML eingebettet: %s\n":                                                                      "✓ XML embedded: %s\n",
+	"Fehler beim Extrahieren von XML: %v":                                                          "error extracting XML: %v",
+
+	// Usage
+	"Verwendung: zugferd-extractor [optionen] <pfad-zur-zugferd-pdf>":                                            "Usage: zugferd-extractor [options] <path-to-zugferd-pdf>",
+	"           zugferd-extractor [optionen] <http(s)-url-der-pdf>":                                              "           zugferd-extractor [options] <http(s)-url-of-the-pdf>",
+	"           zugferd-extractor [optionen] - < rechnung.pdf":                                                   "           zugferd-extractor [options] - < invoice.pdf",
+	"  -stdin     PDF von der Standardeingabe lesen, wie die Eingabe - (XML ohne -o nach stdout)":                "  -stdin     Read the PDF from standard input, like the input - (XML to stdout without -o)",
+	"  -from-file <liste>  Pfade der PDF-Dateien zeilenweise aus einer Datei lesen, - für stdin":                 "  -from-file <list>  Read the PDF paths from a file, one per line, - for stdin",
+	"  -newer-than <zeit>  Nur PDF-Dateien verarbeiten, die nach dem Zeitpunkt geändert wurden, z.B. 2024-01-01": "  -newer-than <time>  Only process PDF files modified after the time, e.g. 2024-01-01",
+	"  -since <dauer>  Nur PDF-Dateien verarbeiten, die in der Zeitspanne geändert wurden, z.B. 24h":             "  -since <duration>  Only process PDF files modified within the duration, e.g. 24h",
+	"-from-file schließt eine Eingabedatei und -stdin aus":                                                       "-from-file excludes an input file and -stdin",
+	"Dateiliste konnte nicht gelesen werden: %v":                                                                 "could not read the file list: %v",
+	"Dateiliste %s enthält keine Dateien":                                                                        "file list %s contains no files",
+	"-stdin und eine Eingabedatei schließen sich aus":                                                            "-stdin and an input file are mutually exclusive",
+	"-list, -check-pdfa und -jsonl unterstützen keine Eingabe über stdin":                                        "-list, -check-pdfa and -jsonl do not support input from stdin",
+	"Keine PDF auf stdin: die Eingabe muss umgeleitet werden, z.B. < rechnung.pdf":                               "no PDF on stdin: the input must be redirected, e.g. < invoice.pdf",
+	"Fehler beim Lesen der PDF von stdin: %v":                                                                    "error reading the PDF from stdin: %v",
+	"Fehler beim Erstellen der temporären Datei: %v":                                                             "error creating the temporary file: %v",
+	"Keine PDF-Daten gelesen: die Eingabe ist leer":                                                              "no PDF data read: the input is empty",
+	"PDF gelesen: %s (%d Bytes, zwischengespeichert in %s)\n":                                                    "PDF read: %s (%d bytes, buffered in %s)\n",
+	"-list, -check-pdfa und -jsonl unterstützen keine URLs":                                                      "-list, -check-pdfa and -jsonl do not support URLs",
+	"Fehler beim Herunterladen der PDF: %v":                                                                      "error downloading the PDF: %v",
+	"Ungültige URL: %v":                                                                                          "invalid URL: %v",
+	"Zeitüberschreitung beim Herunterladen von %s nach %s":                                                       "timeout downloading %s after %s",
+	"Fehler beim Herunterladen von %s: %v":                                                                       "error downloading %s: %v",
+	"Fehler beim Herunterladen von %s: HTTP-Status %s":                                                           "error downloading %s: HTTP status %s",
+	"PDF heruntergeladen: %s (%d Bytes)\n":                                                                       "PDF downloaded: %s (%d bytes)\n",
+	"           zugferd-extractor version [-json]":                                                               "           zugferd-extractor version [-json]",
+	"  -version   Version anzeigen (mit -json als JSON)":                                                         "  -version   Show the version (as JSON with -json)",
+	"  Commit:      %s\n": "  Commit:      %s\n",
+	"  Build-Datum: %s\n": "  Build date:  %s\n",
+	"  Go-Version:  %s\n": "  Go version:  %s\n",
+	"           zugferd-extractor embed [optionen] <pdf> <xml>":                     "           zugferd-extractor embed [options] <pdf> <xml>",
+	"           zugferd-extractor diff [optionen] <alt.pdf> <neu.pdf>":              "           zugferd-extractor diff [options] <old.pdf> <new.pdf>",
+	"Verwendung: zugferd-extractor diff [optionen] <alt.pdf> <neu.pdf>":             "Usage: zugferd-extractor diff [options] <old.pdf> <new.pdf>",
+	"Vergleicht die Rechnungsdaten zweier ZUGFeRD-Rechnungen Feld für Feld.":        "Compares the invoice data of two ZUGFeRD invoices field by field.",
+	"Positionen werden über ihre Positionsnummer, Steuergruppen über Kategorie und": "Line items are matched by their line ID, tax groups by category and",
+	"Steuersatz zugeordnet. Exit-Code 0 ohne, 1 mit Unterschieden.":                 "rate. Exit code 0 without, 1 with differences.",
+	"  -json      Unterschiede als JSON ausgeben":                                   "  -json      Print the differences as JSON",
+	"%s: Fehler beim Extrahieren der Rechnungsdaten: %v":                            "%s: error extracting the invoice data: %v",
+	"Keine Unterschiede":                                                                                 "No differences",
+	"           zugferd-extractor selftest":                                                              "           zugferd-extractor selftest",
+	"           zugferd-extractor watch [optionen] <verzeichnis>":                                        "           zugferd-extractor watch [options] <directory>",
+	"Verwendung: zugferd-extractor watch [optionen] <verzeichnis>":                                       "Usage: zugferd-extractor watch [options] <directory>",
+	"Überwacht ein Verzeichnis und extrahiert die XML jeder neuen PDF-Datei, sobald":                     "Watches a directory and extracts the XML of every new PDF file once its",
+	"sich ihre Größe ein Intervall lang nicht mehr ändert. Verarbeitete PDF-Dateien":                     "size has not changed for one interval. Processed PDF files are moved",
+	"werden in das Zielverzeichnis verschoben, fehlgeschlagene bleiben liegen und":                       "into the done directory, failed ones are left in place and only tried",
+	"werden erst nach einer Änderung erneut versucht. Beenden mit Strg+C.":                               "again once they change. Stop with Ctrl+C.",
+	"  -o <verzeichnis>  Ausgabeverzeichnis für die XML-Dateien (Standard: neben der PDF)":               "  -o <directory>  Output directory for the XML files (default: next to the PDF)",
+	"  -done <verzeichnis>  Zielverzeichnis für verarbeitete PDF-Dateien (Standard: <verzeichnis>/done)": "  -done <directory>  Directory for processed PDF files (default: <directory>/done)",
+	"  -interval <dauer>  Abstand zwischen zwei Durchläufen, z.B. 10s (Standard: 2s)":                    "  -interval <duration>  Time between two polls, e.g. 10s (default: 2s)",
+	"  -validate  EN16931-Geschäftsregeln prüfen; PDF-Dateien mit Verstößen bleiben liegen":              "  -validate  Check the EN16931 business rules; PDF files with violations are left in place",
+	"  -xsd       Struktur gegen vereinfachtes Schema prüfen; PDF-Dateien mit Verstößen bleiben liegen":  "  -xsd       Check the structure against the simplified schema; PDF files with violations are left in place",
+	"Ungültiges Intervall: %s (muss positiv sein)":                                                       "invalid interval: %s (must be positive)",
+	"Verzeichnis nicht gefunden: %s":                                                                     "directory not found: %s",
+	"%s ist kein Verzeichnis":                                                                            "%s is not a directory",
+	"Fehler bei der Überwachung: %v":                                                                     "error while watching: %v",
+	"PDF-Portfolio erkannt, durchsuche eingebettete PDF-Dateien\n":                                       "PDF portfolio detected, searching the embedded PDF files\n",
+	"  PDF-Portfolio: durchsuche eingebettete PDF %s\n":                                                  "  PDF portfolio: searching embedded PDF %s\n",
+	"  ZUGFeRD-XML in eingebetteter PDF %s gefunden: %s\n":                                               "  ZUGFeRD XML found in embedded PDF %s: %s\n",
+	"  %s verworfen: %v\n": "  %s discarded: %v\n",
+	"ZUGFeRD XML nicht gefunden: PDF-Portfolio enthält weder eine ZUGFeRD-XML noch eine PDF-Datei":     "ZUGFeRD XML not found: the PDF portfolio contains neither a ZUGFeRD XML nor a PDF file",
+	"ZUGFeRD XML nicht gefunden: keine der %d PDF-Dateien des PDF-Portfolios enthält eine ZUGFeRD-XML": "ZUGFeRD XML not found: none of the %d PDF files of the PDF portfolio contains a ZUGFeRD XML",
+	"Abbruch angefordert: laufende Dateien werden abgeschlossen, erneut Strg+C beendet sofort":         "interrupt received: finishing the files in progress, press Ctrl+C again to quit immediately",
+	"Abgebrochen: %d Datei(en) nicht verarbeitet\n":                                                    "Interrupted: %d file(s) not processed\n",
+	"Fehler beim Erstellen des Zielverzeichnisses: %v":                                                 "error creating the done directory: %v",
+	"Fehler beim Lesen des überwachten Verzeichnisses: %v":                                             "error reading the watched directory: %v",
+	"❌ %s: Datei konnte nicht verschoben werden: %v\n":                                                 "❌ %s: file could not be moved: %v\n",
+	"  Verschoben: %s -> %s\n":                                                                         "  Moved: %s -> %s\n",
+	"Überwache %s alle %s, verarbeitete PDF-Dateien nach %s (Beenden mit Strg+C)\n":                    "Watching %s every %s, processed PDF files go to %s (stop with Ctrl+C)\n",
+	"Überwachung beendet\n":                                                                            "Watching stopped\n",
+	"Selbsttest: %d von %d Beispielen bestanden\n":                                                     "Self-test: %d of %d samples passed\n",
+	"Anhang %s statt %s extrahiert":                                                                    "extracted attachment %s instead of %s",
+	"%s ist keine gültige ZUGFeRD-XML":                                                                 "%s is not a valid ZUGFeRD XML",
+	"Profil %s statt %s erkannt":                                                                       "detected profile %s instead of %s",
+	"Verstoß gegen Geschäftsregel: %s":                                                                 "business rule violation: %s",
+	"Verwendung: zugferd-extractor embed [optionen] <pdf> <xml>":                                       "Usage: zugferd-extractor embed [options] <pdf> <xml>",
+	"Bettet eine ZUGFeRD-XML als Anhang (AFRelationship Alternative) in eine PDF ein.":                 "Embeds a ZUGFeRD XML as attachment (AFRelationship Alternative) into a PDF.",
+	"  -o <pfad>  Ausgabepfad für die PDF-Datei (Standard: <name>_zugferd.pdf)":                        "  -o <path>  Output path for the PDF file (default: <name>_zugferd.pdf)",
+	"  -profile <profil>  Profil der XML, z.B. EN16931 oder XRECHNUNG (Standard: erkannt)":             "  -profile <profile>  Profile of the XML, e.g. EN16931 or XRECHNUNG (default: detected)",
+	"Optionen:":                         "Options:",
+	"  -v         Ausführliche Ausgabe": "  -v         Verbose output",
+	"  -q         Nur Fehler ausgeben, keine Erfolgs- und Statusmeldungen (auch -quiet)": "  -q         Print errors only, no success or status messages (also -quiet)",
+	"-q und -v schließen sich aus":                                                                                 "-q and -v are mutually exclusive",
+	"  -o <pfad>  Ausgabepfad für die XML-Datei (\"-\" für stdout)":                                                "  -o <path>  Output path for the XML file (\"-\" for stdout)",
+	"  -validate  EN16931-Geschäftsregeln prüfen (Exit-Code 1 bei Verstößen)":                                      "  -validate  Check EN16931 business rules (exit code 1 on violations)",
+	"  -check-leitweg-id  Käuferreferenz (BT-10) von XRechnungen als Leitweg-ID prüfen, aktiviert -validate":       "  -check-leitweg-id  Check the buyer reference (BT-10) of XRechnung invoices as a Leitweg-ID, implies -validate",
+	"  -xsd       Elementstruktur gegen das vereinfachte Schema der Version prüfen (Exit-Code 1 bei Verstößen)":    "  -xsd       Check the element structure against the simplified schema of its version (exit code 1 on violations)",
+	"  -xsd-dir <verzeichnis>  Eigene XSD-Dateien statt der vereinfachten verwenden, aktiviert -xsd":               "  -xsd-dir <directory>  Use your own XSD files instead of the simplified ones, implies -xsd",
+	"Strukturprüfung fehlgeschlagen: %w":                                                                           "structure check failed: %w",
+	"  ✓ Elementstruktur entspricht dem Schema\n":                                                                  "  ✓ Element structure conforms to the schema\n",
+	"kein Schema für Version %s in %s gefunden, erwartet: %s oder ein Schema, das %s deklariert":                   "no schema for version %s found in %s, expected: %s or a schema declaring %s",
+	"Schema aus %s konnte nicht geladen werden: %v":                                                                "could not load schema from %s: %v",
+	"Käuferreferenz (BT-10, Leitweg-ID) fehlt, sie ist für XRechnung Pflicht":                                      "buyer reference (BT-10, Leitweg-ID) is missing, it is mandatory for XRechnung",
+	"Leitweg-ID %s hat nicht das Format Grobadressierung-Feinadressierung-Prüfziffer, z.B. 04011000-1234512345-06": "Leitweg-ID %s does not have the format coarse address-fine address-check digits, e.g. 04011000-1234512345-06",
+	"Leitweg-ID %s hat eine ungültige Prüfziffer":                                                                  "Leitweg-ID %s has invalid check digits",
+	"  -json      Rechnungsdaten als JSON nach stdout ausgeben statt XML zu speichern":                             "  -json      Print the invoice data as JSON to stdout instead of saving the XML",
+	"  -jsonl     Je Datei eine JSON-Zeile mit Pfad, Profil und Rechnungsdaten nach stdout":                        "  -jsonl     One JSON line per file with path, profile and invoice data to stdout",
+	"  -base64    XML base64-kodiert nach stdout ausgeben; mit -json/-jsonl als Feld xmlBase64":                    "  -base64    Write the XML base64-encoded to stdout; with -json/-jsonl as field xmlBase64",
+	"-base64 und -all können nicht kombiniert werden":                                                              "-base64 and -all cannot be combined",
+	"-base64 ist bei mehreren Dateien nur mit -json oder -jsonl möglich":                                           "with several files, -base64 requires -json or -jsonl",
+	"  -all       Alle eingebetteten Dateien extrahieren (-o gibt das Verzeichnis an)":                             "  -all       Extract all embedded files (-o sets the directory)",
+	"  -multi     Alle ZUGFeRD-XML-Anhänge einer Sammel-PDF als <name>_1.xml, <name>_2.xml, ... speichern":         "  -multi     Save every ZUGFeRD XML attachment of a multi-invoice PDF as <name>_1.xml, <name>_2.xml, ...",
+	"-multi ist nur bei einer einzelnen Datei möglich":                                                             "-multi is only possible with a single file",
+	"-multi kann nicht mit -all, -json, -base64 oder -o - kombiniert werden":                                       "-multi cannot be combined with -all, -json, -base64 or -o -",
+	"-combine kann nicht mit -o, -json, -jsonl, -base64, -all oder -multi kombiniert werden":                       "-combine cannot be combined with -o, -json, -jsonl, -base64, -all or -multi",
+	"-newer-than und -since schließen sich aus":                                                                    "-newer-than and -since are mutually exclusive",
+	"Ungültiger Zeitpunkt für -newer-than: %s (erwartet z.B. 2024-01-01 oder 2024-01-01T00:00:00Z)":                "invalid time for -newer-than: %s (expected e.g. 2024-01-01 or 2024-01-01T00:00:00Z)",
+	"-since muss positiv sein: %s":                                                                                 "-since must be positive: %s",
+	"-newer-than und -since unterstützen keine Eingabe über stdin oder URLs":                                       "-newer-than and -since do not support input from stdin or URLs",
+	"✓ %d ZUGFeRD-XML-Dateien gefunden\n":                                                                          "✓ %d ZUGFeRD XML files found\n",
+	"  ZUGFeRD-XML gefunden: %s\n":                                                                                 "  ZUGFeRD XML found: %s\n",
+	"  -r         Verzeichnis rekursiv nach PDF-Dateien durchsuchen (auch -recursive)":                             "  -r         Search the directory recursively for PDF files (also -recursive)",
+	"  -name-template <vorlage>  Dateiname aus Rechnungsfeldern, z.B. \"{invoiceNumber}_{date}.xml\"":              "  -name-template <template>  Filename from invoice fields, e.g. \"{invoiceNumber}_{date}.xml\"",
+	"  -filename-match <namen>  Weitere Dateinamen des XML-Anhangs, z.B. einvoice.xml,rechnung.xml":                "  -filename-match <names>  Additional names of the XML attachment, e.g. einvoice.xml,invoice.xml",
+	"  -min-confidence N  XML erst ab N erkannten ZUGFeRD-Indikatoren akzeptieren (Standard: 1)":                   "  -min-confidence N  Only accept XML matching at least N ZUGFeRD indicators (default: 1)",
+	"    Konfidenz %d unter dem Mindestwert %d\n":                                                                  "    Confidence %d below the minimum of %d\n",
+	"  Konfidenz: %d (%s)\n":                                                                                           "  Confidence: %d (%s)\n",
+	"  ZUGFeRD-XML mit zusätzlichem Dateinamen gefunden: %s\n":                                                         "  ZUGFeRD XML found under additional filename: %s\n",
+	"  -keepname  Originalen Dateinamen des XML-Anhangs beibehalten":                                                   "  -keepname  Keep the original filename of the XML attachment",
+	"  -workers N Anzahl paralleler Worker (0 oder negativ = Anzahl CPU-Kerne)":                                        "  -workers N Number of parallel workers (0 or negative = number of CPU cores)",
+	"  -skip-existing  Dateien überspringen, deren XML im Ausgabeverzeichnis neuer als die PDF ist":                    "  -skip-existing  Skip files whose XML in the output directory is newer than the PDF",
+	"  -no-clobber  Fehler statt Überschreiben, wenn die Ausgabedatei bereits existiert":                               "  -no-clobber  Fail instead of overwriting an existing output file",
+	"  -on-collision <regel>  Gleiche Ausgabepfade im Batch: rename (Nummer anhängen, Standard), error oder overwrite": "  -on-collision <policy>  Same output path in a batch: rename (append a number, default), error or overwrite",
+	"  -dedupe    Byte-identische PDF-Dateien nur einmal verarbeiten":                                                  "  -dedupe    Process byte-identical PDF files only once",
+	"  -dry-run   Extraktion nur simulieren, keine Dateien schreiben":                                                  "  -dry-run   Only simulate the extraction, write no files",
+	"  -method <methode>  Nur diese Extraktionsmethode verwenden: standard, relaxed, af, annotation, manual oder auto": "  -method <method>  Use only this extraction method: standard, relaxed, af, annotation, manual or auto",
+	"  -no-manual  Keine manuelle Extraktion aus den PDF-Rohdaten, nur byte-genaue Methoden verwenden":                 "  -no-manual  No manual extraction from the raw PDF bytes, only use verbatim methods",
+	"-no-manual und -method manual schließen sich aus":                                                                 "-no-manual and -method manual are mutually exclusive",
+	"Manuelle Extraktion deaktiviert\n":                                                                                "Manual extraction disabled\n",
+	"Extraktion fehlgeschlagen, manuelle Extraktion deaktiviert: %v":                                                   "extraction failed, manual extraction disabled: %v",
+	"  -pretty    Extrahierte XML mit zwei Leerzeichen einrücken":                                                      "  -pretty    Indent the extracted XML with two spaces",
+	"  -strip-bom  UTF-8-BOM am Anfang der XML vor dem Speichern entfernen":                                            "  -strip-bom  Remove a UTF-8 BOM at the start of the XML before saving",
+	"-verify-verbatim und -strip-bom schließen sich aus":                                                               "-verify-verbatim and -strip-bom are mutually exclusive",
+	"VerifyVerbatim und StripBOM schließen sich aus":                                                                   "VerifyVerbatim and StripBOM are mutually exclusive",
+	"VerifyVerbatim und Transformers schließen sich aus":                                                               "VerifyVerbatim and Transformers are mutually exclusive",
+	"Transformation %d der XML fehlgeschlagen: %w":                                                                     "transformation %d of the XML failed: %w",
+	"Warnung: XML deklariert die Kodierung %s, ist aber %s kodiert":                                                    "Warning: the XML declares the encoding %s but is encoded as %s",
+	"Warnung: XML beginnt mit einem UTF-8-BOM":                                                                         "Warning: the XML starts with a UTF-8 BOM",
+	"Warnung: Steuerwährung (BT-6) %s weicht von der Rechnungswährung (BT-5) %s ab":                                    "Warning: VAT accounting currency (BT-6) %s differs from the invoice currency (BT-5) %s",
+	"  Währung: %s\n":                          "  Currency: %s\n",
+	"  Kodierung: deklariert %s, erkannt %s\n": "  Encoding: declared %s, detected %s\n",
+	"  UTF-8-BOM entfernt\n":                   "  UTF-8 BOM removed\n",
+	"  ⚠ XML beginnt mit einem UTF-8-BOM\n":    "  ⚠ The XML starts with a UTF-8 BOM\n",
+	"  -gzip      Extrahierte XML gzip-komprimiert als <name>.xml.gz speichern":                "  -gzip      Save the extracted XML gzip-compressed as <name>.xml.gz",
+	"Fehler beim Komprimieren der XML: %v":                                                     "error compressing the XML: %v",
+	"Geschriebene XML-Datei konnte nicht dekomprimiert werden: %v":                             "could not decompress the written XML file: %v",
+	"  XML-Größe: %d Bytes, komprimiert %d Bytes\n":                                            "  XML size: %d bytes, compressed %d bytes\n",
+	"  -verify-verbatim  Geschriebene XML-Datei erneut lesen und byte-genau vergleichen":       "  -verify-verbatim  Re-read the written XML file and compare it byte for byte",
+	"  -strict-mime  Fehler statt Warnung, wenn der XML-Anhang keinen XML-MIME-Typ deklariert": "  -strict-mime  Fail instead of warning if the XML attachment declares no XML MIME type",
+	"%s deklariert den MIME-Typ %s statt text/xml oder application/xml":                        "%s declares the MIME type %s instead of text/xml or application/xml",
+	"Warnung: %s deklariert den MIME-Typ %s statt text/xml oder application/xml":               "Warning: %s declares the MIME type %s instead of text/xml or application/xml",
+	"  MIME-Typ: %s\n": "  MIME type: %s\n",
+	"  ⚠ Dateiname %s passt nicht zur Version, erwartet: %s\n":                                            "  ⚠ Filename %s does not match the version, expected: %s\n",
+	"  -checksum <alg>  Prüfsumme der extrahierten XML ausgeben: sha256 oder sha1":                        "  -checksum <alg>  Print the checksum of the extracted XML: sha256 or sha1",
+	"  -pdf-metadata  Seitenzahl, Titel, Autor und Erstellungsdatum der PDF mit ausgeben (-v, JSON, CSV)": "  -pdf-metadata  Also output page count, title, author and creation date of the PDF (-v, JSON, CSV)",
+	"  -list      Eingebettete Dateien als Tabelle auflisten":                                             "  -list      List the embedded files as a table",
+	"  -check-pdfa  PDF/A-3-Angaben (XMP, OutputIntent) prüfen statt XML zu extrahieren":                  "  -check-pdfa  Check the PDF/A-3 markers (XMP, OutputIntent) instead of extracting XML",
+	"  -lang <code>  Sprache der Meldungen: de oder en (Standard: $ZUGFERD_LANG, sonst de)":               "  -lang <code>  Language of messages: de or en (default: $ZUGFERD_LANG, else de)",
+	"  -password <passwort>  Passwort für verschlüsselte PDF-Dateien":                                     "  -password <password>  Password for encrypted PDF files",
+	"  -timeout <dauer>  Maximale Dauer von Download und Extraktion pro Datei, z.B. 30s (0 = unbegrenzt)": "  -timeout <duration>  Maximum time for download and extraction per file, e.g. 30s (0 = unlimited)",
+	"  -tmp <verzeichnis>  Verzeichnis für temporäre Dateien (Standard: $TMPDIR bzw. /tmp)":               "  -tmp <directory>  Directory for temporary files (default: $TMPDIR or /tmp)",
+	"  -keep-temp  Temporäre Verzeichnisse zur Fehlersuche nicht löschen und ihre Pfade ausgeben":         "  -keep-temp  Keep the temporary directories for debugging and print their paths",
+	"Temporäres Verzeichnis behalten: %s":                                                                 "Temporary directory kept: %s",
+	"Temporäres Verzeichnis nicht verwendbar: %v":                                                         "temporary directory not usable: %v",
+	"Temporäres Verzeichnis nicht verwendbar: %s ist kein Verzeichnis":                                    "temporary directory not usable: %s is not a directory",
+	"XSD-Verzeichnis nicht verwendbar: %v":                                                                "XSD directory not usable: %v",
+	"XSD-Verzeichnis nicht verwendbar: %s ist kein Verzeichnis":                                           "XSD directory not usable: %s is not a directory",
+	"interner Fehler beim Lesen der PDF: %v":                                                              "internal error reading the PDF: %v",
+	"interner Fehler bei der Verarbeitung: %v":                                                            "internal error during processing: %v",
+	"  -retries N  Datei bei vorübergehenden E/A-Fehlern bis zu N-mal erneut verarbeiten (Batch)":         "  -retries N  Process a file up to N more times after transient I/O errors (batch)",
+	"  -fail-fast  Batch nach der ersten fehlgeschlagenen Datei abbrechen":                                "  -fail-fast  Stop the batch after the first failed file",
+	"  -retry-backoff <dauer>  Wartezeit vor der ersten Wiederholung, danach verdoppelt (Standard 500ms)": "  -retry-backoff <duration>  Wait before the first retry, doubled afterwards (default 500ms)",
+	"  -report <datei>  CSV-Bericht der Batch-Verarbeitung schreiben":                                     "  -report <file>  Write a CSV report of the batch run",
+	"  -combine <datei>  Alle extrahierten XML mit ihrer Quelldatei in eine Sammeldatei schreiben":        "  -combine <file>  Write all extracted XML with their source file into one combined file",
+	"  -no-config  Keine Konfigurationsdatei lesen":                                                       "  -no-config  Do not read a config file",
+	"Konfiguration: zugferd.yaml oder .zugferdrc im Home- und im Arbeitsverzeichnis":                      "Configuration: zugferd.yaml or .zugferdrc in the home and the working directory",
+	"setzt Standardwerte, eine Option je Zeile (z.B. workers: 8). Vorrang haben":                          "sets defaults, one option per line (e.g. workers: 8). Precedence:",
+	"Kommandozeile, Arbeitsverzeichnis, Home-Verzeichnis, eingebaute Standardwerte.":                      "command line, working directory, home directory, built-in defaults.",
+	"Fehler in der Konfigurationsdatei: %v":                                                               "error in config file: %v",
+	"Konfigurationsdatei konnte nicht gelesen werden: %v":                                                 "config file could not be read: %v",
+	"%s, Zeile %d: erwartet wird name: wert":                                                              "%s, line %d: expected name: value",
+	"%s, Zeile %d: unbekannte Option %s":                                                                  "%s, line %d: unknown option %s",
+	"%s, Zeile %d: ungültiger Wert für %s: %v":                                                            "%s, line %d: invalid value for %s: %v",
+	"Konfiguration gelesen: %s":                                                                           "Configuration read: %s",
+	"  -h         Diese Hilfe anzeigen":                                                                   "  -h         Show this help",
+	"Dateinamen: -o hat Vorrang vor -name-template, das Vorrang vor -keepname hat.":                       "Filenames: -o takes precedence over -name-template, which takes precedence over -keepname.",
+	"Platzhalter: {invoiceNumber}, {date}, {seller}, {buyer}, {currency}, {pdf}.":                         "Placeholders: {invoiceNumber}, {date}, {seller}, {buyer}, {currency}, {pdf}.",
+	"Ohne diese Optionen gilt der Standardname des Anhangs, z.B. factur-x.xml.":                           "Without these options the standard name of the attachment is used, e.g. factur-x.xml.",
+	"Sonst, und in einem Ausgabeverzeichnis immer, gilt der Name der PDF.":                                "Otherwise, and always in an output directory, the name of the PDF is used.",
+	"Muster: *, ? und [a-z] wie bei der Shell, {a,b} für Alternativen und ** für":                         "Patterns: *, ? and [a-z] as in the shell, {a,b} for alternatives and ** for",
+	"beliebig viele Verzeichnisebenen. Muster in Anführungszeichen setzen, damit die":                     "any number of directory levels. Quote patterns so that the shell does not",
+	"Shell sie nicht selbst auflöst.":                                                                     "expand them itself.",
+	"Beispiele:":                                                                                          "Examples:",
+	"Exit-Codes bei einer einzelnen Datei:":                                                               "Exit codes for a single file:",
+	"Im Batch endet jede fehlgeschlagene Datei mit Exit-Code 1, mit -fail-fast mit":                       "In a batch, any failed file results in exit code 1, with -fail-fast in",
+	"dem Code der ersten fehlgeschlagenen Datei.":                                                         "the code of the first failed file.",
+	"  0  Erfolg": "  0  Success",
+	"  1  Sonstiger Fehler, z.B. beschädigte PDF oder Verstöße gegen Geschäftsregeln": "  1  Other error, e.g. a damaged PDF or business rule violations",
+	"  2  Keine ZUGFeRD-XML in der PDF gefunden":                                      "  2  No ZUGFeRD XML found in the PDF",
+	"  3  E/A-Fehler: Datei nicht gefunden, nicht lesbar oder nicht schreibbar":       "  3  I/O error: file not found, not readable or not writable",
+	"  4  Ungültige Argumente":                                                        "  4  Invalid arguments",
+	"Unterstützte Formate:":                                                           "Supported formats:",
+	"  - XRechnung (CII und UBL)":                                                     "  - XRechnung (CII and UBL)",
+
+	// Extraction
+	"unbekannte Extraktionsmethode: %s (erlaubt: standard, relaxed, af, annotation, manual, auto)": "unknown extraction method: %s (allowed: standard, relaxed, af, annotation, manual, auto)",
+	"PDF ist verschlüsselt, die manuelle Extraktion ist nicht möglich":                             "the PDF is encrypted, manual extraction is not possible",
+	"Fehler beim Lesen der PDF: %v":                                   "error reading the PDF: %v",
+	"Fehler beim Öffnen der PDF: %v":                                  "error opening the PDF: %v",
+	"Fehler beim Speichern der XML-Datei: %v":                         "error saving the XML file: %v",
+	"✓ XML erfolgreich extrahiert nach: %s\n":                         "✓ XML successfully extracted to: %s\n",
+	"✓ XML gefunden: %s (Profil %s), würde gespeichert nach: %s\n":    "✓ XML found: %s (profile %s), would be saved to: %s\n",
+	"  Prüfsumme (%s): %s\n":                                          "  Checksum (%s): %s\n",
+	"unbekannter Prüfsummen-Algorithmus: %s (erlaubt: sha256, sha1)":  "unknown checksum algorithm: %s (allowed: sha256, sha1)",
+	"Warnung: Namensvorlage nicht anwendbar, verwende PDF-Namen: %v":  "Warning: name template not applicable, using the PDF name: %v",
+	"unbekannter Platzhalter %s":                                      "unknown placeholder %s",
+	"Feld %s ist leer":                                                "field %s is empty",
+	"PDF ist verschlüsselt\n":                                         "PDF is encrypted\n",
+	"PDF ist verschlüsselt, das Passwort fehlt oder ist falsch":       "PDF is encrypted, the password is missing or wrong",
+	"PDF ist verschlüsselt und konnte nicht entschlüsselt werden: %v": "PDF is encrypted and could not be decrypted: %v",
+	"  %s gefunden, aber verworfen: %v\n":                             "  %s found, but rejected: %v\n",
+	"  Kandidat verworfen: %s (%v)\n":                                 "  Candidate rejected: %s (%v)\n",
+	"  Originaler XML-Dateiname: %s\n":                                "  Original XML filename: %s\n",
+	"  XML-Größe: %d Bytes\n":                                         "  XML size: %d bytes\n",
+	"  Extraktionsmethode: %s\n":                                      "  Extraction method: %s\n",
+	"  ✓ XML scheint ein gültiges ZUGFeRD-Format zu sein\n":           "  ✓ XML appears to be a valid ZUGFeRD format\n",
+	"  ⚠ Warnung: XML könnte kein gültiges ZUGFeRD-Format sein\n":     "  ⚠ Warning: XML might not be a valid ZUGFeRD format\n",
+	"  Profil: %s\n":                                          "  Profile: %s\n",
+	"  ⚠ Profil nicht erkannt: %v\n":                          "  ⚠ Profile not recognized: %v\n",
+	"  ⚠ AFRelationship fehlt in der Dateispezifikation\n":    "  ⚠ AFRelationship is missing from the file specification\n",
+	"  ⚠ AFRelationship ist %s statt Alternative oder Data\n": "  ⚠ AFRelationship is %s instead of Alternative or Data\n",
+	"Namensbaum zu tief verschachtelt":                        "name tree nested too deeply",
+	"kein XML-Anhang gefunden":                                "no XML attachment found",
+	"Geschäftsregeln konnten nicht geprüft werden: %v":        "business rules could not be checked: %v",
+	"Geschäftsregeln verletzt: %s":                            "business rules violated: %s",
+	"  ✓ Geschäftsregeln erfüllt\n":                           "  ✓ Business rules satisfied\n",
+	"ZUGFeRD XML nicht gefunden: %v":                          "ZUGFeRD XML not found: %v",
+	"Zeitüberschreitung bei der Extraktion nach %s: %s":       "extraction timed out after %s: %s",
+	"Verarbeite PDF: %s\n":                                    "Processing PDF: %s\n",
+	"Standard-Extraktion fehlgeschlagen: %v\n":                "Standard extraction failed: %v\n",
+	"Versuche relaxierte Extraktion...\n":                     "Trying relaxed extraction...\n",
+	"Versuche Standard-Extraktion...\n":                       "Trying standard extraction...\n",
+	"Versuche Extraktionsmethode %s...\n":                     "Trying extraction method %s...\n",
+	"Unbekanntes Protokollformat: %s (erlaubt: text, json)":   "unknown log format: %s (allowed: text, json)",
+	"  -log-format <format>  Protokoll als text (Standard) oder json: ein JSON-Ereignis je Zeile auf stderr":                      "  -log-format <format>  Log as text (default) or json: one JSON event per line on stderr",
+	"Relaxierte Extraktion fehlgeschlagen: %v\n":                                                                                  "Relaxed extraction failed: %v\n",
+	"Versuche Extraktion über das /AF-Array...\n":                                                                                 "Trying extraction via the /AF array...\n",
+	"Extraktion über das /AF-Array fehlgeschlagen: %v\n":                                                                          "Extraction via the /AF array failed: %v\n",
+	"Katalog enthält kein /AF-Array":                                                                                              "the catalog contains no /AF array",
+	"/AF-Array konnte nicht gelesen werden: %v":                                                                                   "the /AF array could not be read: %v",
+	"/AF-Array enthält keine eingebetteten Dateien":                                                                               "the /AF array contains no embedded files",
+	"Versuche Extraktion über Dateianhang-Annotationen...\n":                                                                      "Trying extraction via file attachment annotations...\n",
+	"Extraktion über Dateianhang-Annotationen fehlgeschlagen: %v\n":                                                               "Extraction via file attachment annotations failed: %v\n",
+	"Seitenbaum der PDF konnte nicht gelesen werden":                                                                              "the page tree of the PDF could not be read",
+	"keine Dateianhang-Annotationen mit eingebetteten Dateien gefunden":                                                           "no file attachment annotations with embedded files found",
+	"Warnung: Dateianhang-Annotation %s auf Seite %d hat keine Dateispezifikation":                                                "Warning: file attachment annotation %s on page %d has no file specification",
+	"Warnung: Dateianhang-Annotation %s auf Seite %d enthält keine eingebettete Datei":                                            "Warning: file attachment annotation %s on page %d contains no embedded file",
+	"  Anhang gelesen: %s (%d Bytes, Seite %d)\n":                                                                                 "  Attachment read: %s (%d bytes, page %d)\n",
+	"Warnung: /AF-Eintrag %d ist keine Dateispezifikation":                                                                        "Warning: /AF entry %d is not a file specification",
+	"Warnung: XML konnte nicht eingerückt werden, wird unverändert gespeichert: %v":                                               "Warning: could not indent the XML, saving it unchanged: %v",
+	"Extrahierte XML mit zwei Leerzeichen einrücken":                                                                              "Indent the extracted XML with two spaces",
+	"Geschriebene XML-Datei byte-genau mit der extrahierten XML vergleichen":                                                      "Compare the written XML file byte for byte with the extracted XML",
+	"-verify-verbatim und -pretty schließen sich aus":                                                                             "-verify-verbatim and -pretty are mutually exclusive",
+	"VerifyVerbatim und Pretty schließen sich aus":                                                                                "VerifyVerbatim and Pretty are mutually exclusive",
+	"Geschriebene XML-Datei konnte nicht erneut gelesen werden: %v":                                                               "could not re-read the written XML file: %v",
+	"Geschriebene XML-Datei %s weicht von der extrahierten XML ab":                                                                "written XML file %s differs from the extracted XML",
+	"Warnung: XML wurde aus den PDF-Rohdaten rekonstruiert, die Übereinstimmung mit der eingebetteten Datei ist nicht garantiert": "Warning: the XML was reconstructed from the raw PDF bytes, it is not guaranteed to match the embedded file",
+	"  ⚠ XML aus den PDF-Rohdaten rekonstruiert, nicht byte-genau\n":                                                              "  ⚠ XML reconstructed from the raw PDF bytes, not verbatim\n",
+	"Warnung: /AF-Eintrag %s enthält keine eingebettete Datei":                                                                    "Warning: /AF entry %s contains no embedded file",
+	"Versuche manuelle Extraktion...\n":                                                                                           "Trying manual extraction...\n",
+	"alle Extraktionsmethoden fehlgeschlagen: %v":                                                                                 "all extraction methods failed: %v",
+	"keine eingebetteten Dateien im PDF gefunden":                                                                                 "no embedded files found in the PDF",
+	"Gefunden: %d Anhang/Anhänge (Methode %s)\n":                                                                                  "Found: %d attachment(s) (method %s)\n",
+	"Rechnungsdaten konnten nicht gelesen werden: %v":                                                                             "invoice data could not be read: %v",
+	"Fehler beim Erstellen des temporären Verzeichnisses: %v":                                                                     "error creating the temporary directory: %v",
+	"Verwende temporäres Verzeichnis: %s\n":                                                                                       "Using temporary directory: %s\n",
+	"pdfcpu-Extraktion fehlgeschlagen: %v":                                                                                        "pdfcpu extraction failed: %v",
+	"relaxierte pdfcpu-Extraktion fehlgeschlagen: %v":                                                                             "relaxed pdfcpu extraction failed: %v",
+	"  XML manuell extrahiert von Position %d\n":                                                                                  "  XML extracted manually from position %d\n",
+	"  XML aus komprimiertem Dateistrom an Position %d extrahiert\n":                                                              "  XML extracted from compressed file stream at position %d\n",
+	"  Komprimierter Dateistrom an Position %d konnte nicht entpackt werden: %v\n":                                                "  Compressed file stream at position %d could not be inflated: %v\n",
+	"manuelle Extraktion fand keine XML-Anhänge":                                                                                  "manual extraction found no XML attachments",
+	"Fehler beim Lesen des temporären Verzeichnisses: %v":                                                                         "error reading the temporary directory: %v",
+	"Warnung: Konnte Datei nicht lesen %s: %v":                                                                                    "Warning: could not read file %s: %v",
+	"  Anhang gelesen: %s (%d Bytes)\n":                                                                                           "  Attachment read: %s (%d bytes)\n",
+	"  Anhang gespeichert: %s -> %s\n":                                                                                            "  Attachment saved: %s -> %s\n",
+	"Fehler beim Schreiben des Anhangs %s: %v":                                                                                    "error writing attachment %s: %v",
+	"  Standard-ZUGFeRD-XML gefunden: %s\n":                                                                                       "  Standard ZUGFeRD XML found: %s\n",
+	"  %s gefunden, aber Inhalt scheint keine ZUGFeRD-XML zu sein\n":                                                              "  %s found, but the content does not appear to be ZUGFeRD XML\n",
+	"  ZUGFeRD-XML in nicht-standardisiertem Dateinamen gefunden: %s\n":                                                           "  ZUGFeRD XML found in non-standard filename: %s\n",
+	"  Kandidat verworfen: %s (kein ZUGFeRD-Inhalt)\n":                                                                            "  Candidate rejected: %s (no ZUGFeRD content)\n",
+	"  Kandidat verworfen: %s (gleichwertig mit %s, das alphabetisch zuerst kommt)\n":                                             "  Candidate rejected: %s (equivalent to %s, which sorts first)\n",
+	"  Kandidat verworfen: %s (weniger vollständig als %s)\n":                                                                     "  Candidate rejected: %s (less complete than %s)\n",
+	"kein ZUGFeRD-XML-Anhang gefunden. Verfügbare Anhänge: %v":                                                                    "no ZUGFeRD XML attachment found. Available attachments: %v",
+	"    Indikator gefunden: %s\n":                                                                                                "    Indicator found: %s\n",
+	"Fehler beim Schreiben der XML-Daten: %v":                                                                                     "error writing the XML data: %v",
+	"Ausgabedatei existiert bereits: %s":                                                                                          "output file already exists: %s",
+	"Warnung: Vorübergehender Fehler bei %s, Wiederholung %d von %d in %s: %v":                                                    "Warning: transient error for %s, retry %d of %d in %s: %v",
+	"  Warnung: Ausgabedatei existiert bereits und wird überschrieben: %s\n":                                                      "  Warning: output file already exists and will be overwritten: %s\n",
+
+	// Embedding
+	"Fehler beim Lesen der XML-Datei: %v":           "error reading the XML file: %v",
+	"%s ist keine ZUGFeRD-XML":                      "%s is not a ZUGFeRD XML",
+	"unbekanntes Profil: %s":                        "unknown profile: %s",
+	"Profil %s passt nicht zum Profil der XML (%s)": "profile %s does not match the profile of the XML (%s)",
+	"PDF enthält bereits einen Anhang %s":           "the PDF already contains an attachment %s",
+	"PDF enthält bereits eine ZUGFeRD-XML: %s":      "the PDF already contains a ZUGFeRD XML: %s",
+	"Anhang konnte nicht eingebettet werden: %v":    "the attachment could not be embedded: %v",
+	"Fehler beim Schreiben der PDF: %v":             "error writing the PDF: %v",
+
+	// PDF/A-3 check
+	"PDF konnte nicht gelesen werden: %v":                   "PDF could not be read: %v",
+	"OutputIntent mit /S /GTS_PDFA1 fehlt":                  "OutputIntent with /S /GTS_PDFA1 is missing",
+	"XMP-Metadaten (/Metadata) fehlen":                      "XMP metadata (/Metadata) is missing",
+	"XMP-Metadaten (/Metadata) können nicht gelesen werden": "XMP metadata (/Metadata) cannot be read",
+	"pdfaid:part fehlt in den XMP-Metadaten":                "pdfaid:part is missing from the XMP metadata",
+	"pdfaid:part ist %s statt 3":                            "pdfaid:part is %s instead of 3",
+	"pdfaid:conformance fehlt in den XMP-Metadaten":         "pdfaid:conformance is missing from the XMP metadata",
+
+	// Batch processing
+	"Fehler beim Öffnen des Archivs: %v":                                                                     "error opening the archive: %v",
+	"Keine PDF-Dateien im Archiv gefunden: %s":                                                               "no PDF files found in the archive: %s",
+	"Keine PDF-Dateien gefunden, die dem Muster entsprechen: %s":                                             "no PDF files found matching the pattern: %s",
+	"Keine PDF-Dateien in der Dateiliste gefunden (%d Einträge)":                                             "no PDF files found in the file list (%d entries)",
+	"Gefunden: %d PDF-Dateien zur Verarbeitung\n":                                                            "Found: %d PDF files to process\n",
+	"⏭ %s: übersprungen, %s ist aktuell\n":                                                                   "⏭ %s: skipped, %s is up to date\n",
+	"Probelauf: es wurden keine Dateien geschrieben\n":                                                       "Dry run: no files were written\n",
+	"⏭ %s: Duplikat von %s\n":                                                                                "⏭ %s: duplicate of %s\n",
+	"\nBatch-Verarbeitung abgeschlossen: %d erfolgreich, %d fehlgeschlagen, %d übersprungen, %d Duplikate\n": "\nBatch processing finished: %d successful, %d failed, %d skipped, %d duplicates\n",
+	"Bericht geschrieben: %s\n":                                                                              "Report written: %s\n",
+	"\nBatch-Verarbeitung abgeschlossen: %d erfolgreich, %d fehlgeschlagen, %d übersprungen\n":               "\nBatch processing finished: %d successful, %d failed, %d skipped\n",
+	"Batch-Verarbeitung abgebrochen: %w":                                                                     "batch processing cancelled: %w",
+	"Geschäftsregeln verletzt in %d Datei(en)":                                                               "business rules violated in %d file(s)",
+	"Fehler beim Erstellen der Sammeldatei: %v":                                                              "error creating the combined file: %v",
+	"Fehler beim Schreiben der Sammeldatei: %v":                                                              "error writing the combined file: %v",
+	"Sammeldatei geschrieben: %s\n":                                                                          "Combined file written: %s\n",
+	"Warnung: PDF-Metadaten konnten nicht gelesen werden: %v":                                                "Warning: could not read the PDF metadata: %v",
+	"  Seiten: %d\n":   "  Pages: %d\n",
+	"  Titel: %s\n":    "  Title: %s\n",
+	"  Autor: %s\n":    "  Author: %s\n",
+	"  Erstellt: %s\n": "  Created: %s\n",
+	"Nicht geändert seit %s: %d Datei(en) ausgelassen\n":                                   "Not modified since %s: %d file(s) left out\n",
+	"XML ist nicht UTF-8-kodiert (%s) und kann nicht in die Sammeldatei übernommen werden": "XML is not UTF-8 encoded (%s) and cannot be added to the combined file",
+	"XML enthält kein Wurzelelement":                                                       "XML has no root element",
+	"%d von %d Datei(en) fehlgeschlagen":                                                   "%d of %d file(s) failed",
+	"Batch-Verarbeitung nach dem ersten Fehler abgebrochen: %s: %w":                        "batch processing stopped after the first failure: %s: %w",
+	"nicht verarbeitet, Batch-Verarbeitung nach dem ersten Fehler abgebrochen":             "not processed, batch processing stopped after the first failure",
+	"Nach dem ersten Fehler abgebrochen: %d Datei(en) nicht verarbeitet\n":                 "Stopped after the first failure: %d file(s) not processed\n",
+	"Fehler beim Durchsuchen des Verzeichnisses: %v":                                       "error walking the directory: %v",
+	"[%d/%d] verarbeitet":                    "[%d/%d] processed",
+	"Fehler beim Erstellen des Berichts: %v": "error creating the report: %v",
+	"Fehler beim Schreiben des Berichts: %v": "error writing the report: %v",
+
+	// Profile and version detection
+	"unbekannter Profil-Bezeichner: %s":                                   "unknown profile identifier: %s",
+	"XML konnte nicht gelesen werden: %v":                                 "XML could not be read: %v",
+	"kein Profil-Bezeichner (%s) gefunden":                                "no profile identifier (%s) found",
+	"unbekanntes Wurzelelement: %s":                                       "unknown root element: %s",
+	"Version konnte nicht aus dem Profil-Bezeichner ermittelt werden: %s": "version could not be determined from the profile identifier: %s",
+	"kein Wurzelelement gefunden":                                         "no root element found",
+
+	// Business rules
+	"Geschäftsregeln können nur für CII-Dokumente geprüft werden":                                                        "business rules can only be checked for CII documents",
+	"Spezifikationskennung (BT-24) fehlt":                                                                                "specification identifier (BT-24) is missing",
+	"Rechnungsnummer (BT-1) fehlt":                                                                                       "invoice number (BT-1) is missing",
+	"Rechnungsdatum (BT-2) fehlt":                                                                                        "invoice issue date (BT-2) is missing",
+	"Rechnungstyp (BT-3) fehlt":                                                                                          "invoice type code (BT-3) is missing",
+	"Handelstransaktion (SupplyChainTradeTransaction) fehlt":                                                             "trade transaction (SupplyChainTradeTransaction) is missing",
+	"Zahlungsinformationen (ApplicableHeaderTradeSettlement) fehlen":                                                     "settlement information (ApplicableHeaderTradeSettlement) is missing",
+	"Rechnungswährung (BT-5) fehlt":                                                                                      "invoice currency code (BT-5) is missing",
+	"Rechnungssummen (BG-22) fehlen":                                                                                     "document totals (BG-22) are missing",
+	"Gesamtbetrag einschließlich Umsatzsteuer (BT-112) fehlt":                                                            "invoice total amount with VAT (BT-112) is missing",
+	"Fälliger Zahlungsbetrag (BT-115) fehlt":                                                                             "amount due for payment (BT-115) is missing",
+	"Summe der Positionsnettobeträge (BT-106) %.2f entspricht nicht der Summe der Positionen %.2f":                       "sum of invoice line net amounts (BT-106) %.2f does not match the sum of the lines %.2f",
+	"Summe der Nachlässe (BT-107) %.2f entspricht nicht der Summe der Nachlässe auf Dokumentebene %.2f":                  "sum of allowances (BT-107) %.2f does not match the sum of document level allowances %.2f",
+	"Summe der Zuschläge (BT-108) %.2f entspricht nicht der Summe der Zuschläge auf Dokumentebene %.2f":                  "sum of charges (BT-108) %.2f does not match the sum of document level charges %.2f",
+	"Gesamtbetrag ohne Umsatzsteuer (BT-109) %.2f entspricht nicht BT-106 - BT-107 + BT-108 = %.2f":                      "invoice total amount without VAT (BT-109) %.2f does not match BT-106 - BT-107 + BT-108 = %.2f",
+	"Umsatzsteuergesamtbetrag (BT-110) %.2f entspricht nicht der Summe der Steuerbeträge %.2f":                           "invoice total VAT amount (BT-110) %.2f does not match the sum of the VAT amounts %.2f",
+	"Gesamtbetrag einschließlich Umsatzsteuer (BT-112) %.2f entspricht nicht BT-109 + BT-110 = %.2f":                     "invoice total amount with VAT (BT-112) %.2f does not match BT-109 + BT-110 = %.2f",
+	"Fälliger Zahlungsbetrag (BT-115) %.2f entspricht nicht BT-112 - BT-113 + BT-114 = %.2f":                             "amount due for payment (BT-115) %.2f does not match BT-112 - BT-113 + BT-114 = %.2f",
+	"Steuerbetrag der Kategorie %s (%.2f %%) %.2f entspricht nicht Basis × Satz = %.2f":                                  "VAT amount of category %s (%.2f %%) %.2f does not match basis × rate = %.2f",
+	"Gesamtbetrag (BT-112) %.2f entspricht nicht der Summe aus Positionen, Umsatzsteuer, Nachlässen und Zuschlägen %.2f": "grand total (BT-112) %.2f does not match the sum of lines, VAT, allowances and charges %.2f",
+
+	// Schema validation
+	"XML entspricht nicht dem Schema %s:\n  - %s":          "XML does not conform to schema %s:\n  - %s",
+	"Strukturprüfung nicht möglich, Version unbekannt: %v": "structure check not possible, unknown version: %v",
+	"kein Schema für Version %s vorhanden":                 "no schema available for version %s",
+	"XML ist nicht wohlgeformt: %v":                        "XML is not well-formed: %v",
+	"Wurzelelement %s ist im Schema nicht definiert":       "root element %s is not defined in the schema",
+	"%s: unerwartetes Kindelement %s":                      "%s: unexpected child element %s",
+	"%s: unerwartetes Element %s":                          "%s: unexpected element %s",
+	"%s: %s fehlt":                                         "%s: %s is missing",
+	"ein beliebiges Element":                               "any element",
+	"eines von (%s)":                                       "one of (%s)",
+	"Inhalt":                                               "content",
+
+	// Timings
+	"  -timings   Dauer von pdfcpu, Lesen, manueller Extraktion, Validierung und Schreiben messen (-v, JSON)": "  -timings   Measure the time of pdfcpu, reading, manual extraction, validation and writing (-v, JSON)",
+	"  Zeiten: pdfcpu %s, Lesen %s, manuell %s, Validierung %s, Schreiben %s, gesamt %s\n":                    "  Timings: pdfcpu %s, read %s, manual %s, validation %s, write %s, total %s\n",
+
+	// Validate subcommand
+	"Verwendung: zugferd-extractor validate [optionen] <pdf>...":                        "Usage: zugferd-extractor validate [options] <pdf>...",
+	"           zugferd-extractor validate [optionen] <pdf>...":                         "           zugferd-extractor validate [options] <pdf>...",
+	"Extrahiert die ZUGFeRD-XML jeder PDF im Speicher und prüft Wohlgeformtheit,":       "Extracts the ZUGFeRD XML of every PDF in memory and checks well-formedness,",
+	"Elementstruktur und EN16931-Geschäftsregeln, ohne eine Datei zu schreiben.":        "element structure and EN16931 business rules without writing any file.",
+	"Exit-Code 0, wenn alle Prüfungen bestanden sind, 1 bei einer fehlgeschlagenen":     "Exit code 0 if all checks pass, 1 if a check fails, otherwise the code of",
+	"Prüfung, sonst der Code der ersten PDF, deren XML nicht extrahiert werden konnte.": "the first PDF whose XML could not be extracted.",
+	"  -no-wellformed  Wohlgeformtheit der XML nicht prüfen":                            "  -no-wellformed  Do not check that the XML is well-formed",
+	"  -no-xsd    Elementstruktur nicht gegen das vereinfachte Schema prüfen":           "  -no-xsd    Do not check the element structure against the simplified schema",
+	"  -no-rules  EN16931-Geschäftsregeln nicht prüfen":                                 "  -no-rules  Do not check the EN16931 business rules",
+	"  -xsd-dir <verzeichnis>  Eigene XSD-Dateien statt der vereinfachten verwenden":    "  -xsd-dir <directory>  Use custom XSD files instead of the simplified ones",
+	"  -check-leitweg-id  Käuferreferenz (BT-10) von XRechnungen als Leitweg-ID prüfen": "  -check-leitweg-id  Check the buyer reference (BT-10) of XRechnung documents as Leitweg-ID",
+	"  -q         Nur fehlgeschlagene Prüfungen ausgeben (auch -quiet)":                 "  -q         Print only failed checks (also -quiet)",
+	"-no-wellformed, -no-xsd und -no-rules zusammen lassen keine Prüfung übrig":         "-no-wellformed, -no-xsd and -no-rules together leave no check",
+	"  XML-Anhang: %s (Methode %s)\n":                                                   "  XML attachment: %s (method %s)\n",
+	"Wohlgeformtheit":                                                                   "Well-formedness",
+	"Elementstruktur":                                                                   "Element structure",
+	"Geschäftsregeln":                                                                   "Business rules",
+	"❌ %s ist ungültig\n":                                                               "❌ %s is invalid\n",
+	"✅ %s ist gültig\n":                                                                 "✅ %s is valid\n",
+	"unbekannte Prüfung: %s":                                                            "unknown check: %s",
+
+	// Invoice parsing
+	"keine Rechnungswährung (BT-5) gefunden":                "no invoice currency (BT-5) found",
+	"ungültiges Rechnungsdatum: %v":                         "invalid invoice date: %v",
+	"ungültiger Gesamtbetrag: %v":                           "invalid grand total: %v",
+	"ungültiger Steuergesamtbetrag: %v":                     "invalid tax total: %v",
+	"ungültige Summe der Nachlässe: %v":                     "invalid allowance total: %v",
+	"ungültige Summe der Zuschläge: %v":                     "invalid charge total: %v",
+	"Nachlass/Zuschlag %d: ungültiger Indikator: %s":        "allowance/charge %d: invalid indicator: %s",
+	"Nachlass/Zuschlag %d: ungültiger Betrag: %v":           "allowance/charge %d: invalid amount: %v",
+	"Steuerkategorie %s: ungültiger Steuersatz: %s":         "tax category %s: invalid tax rate: %s",
+	"Steuerkategorie %s: ungültiger Basisbetrag: %v":        "tax category %s: invalid basis amount: %v",
+	"Steuerkategorie %s: ungültiger Steuerbetrag: %v":       "tax category %s: invalid tax amount: %v",
+	"Zahlungsbedingung %d: ungültiges Fälligkeitsdatum: %v": "payment terms %d: invalid due date: %v",
+	"Position %s: ungültige Menge: %v":                      "line %s: invalid quantity: %v",
+	"Position %s: ungültiger Einzelpreis: %v":               "line %s: invalid unit price: %v",
+	"Position %s: ungültiger Nettobetrag: %v":               "line %s: invalid net amount: %v",
+	"keine Zahl: %s":               "not a number: %s",
+	"unbekanntes Datumsformat: %s": "unknown date format: %s",
+	"ungültiger Betrag: %s":        "invalid amount: %s",
+
+	// Schema loading
+	"Schemadatei nicht gefunden: %s":                 "schema file not found: %s",
+	"Schemadatei %s konnte nicht gelesen werden: %v": "could not read schema file %s: %v",
+	"%s ist kein XML-Schema":                         "%s is not an XML schema",
+	"Element %s ist im Schema nicht definiert":       "element %s is not defined in the schema",
+	"Typ %s ist im Schema nicht definiert":           "type %s is not defined in the schema",
+	"Gruppe %s ist im Schema nicht definiert":        "group %s is not defined in the schema",
+	"Gruppe %s enthält kein Inhaltsmodell":           "group %s has no content model",
+
+	// Schema directory search
+	"Schemaverzeichnis %s konnte nicht durchsucht werden: %v":                      "could not search schema directory %s: %v",
+	"mehrere Schemas in %s deklarieren %s, keines eindeutig für das Profil %s: %s": "several schemas in %s declare %s, none unambiguously for profile %s: %s",
+}
@@ -0,0 +1,76 @@
+// Package i18n translates the user-facing messages of the extractor. The
+// German texts serve as message keys, so a message without a translation
+// falls back to German.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Supported languages
+const (
+	German  = "de"
+	English = "en"
+)
+
+// EnvVar selects the language when none is configured explicitly
+const EnvVar = "ZUGFERD_LANG"
+
+// catalogs maps a language to its translations of the German messages
+var catalogs = map[string]map[string]string{
+	German:  nil,
+	English: english,
+}
+
+// Resolve returns the supported language for lang. An empty lang is read
+// from ZUGFERD_LANG; locale forms like "en_US.UTF-8" are accepted and
+// anything unknown falls back to German.
+func Resolve(lang string) string {
+	if lang == "" {
+		lang = os.Getenv(EnvVar)
+	}
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return German
+}
+
+// T translates a German message into lang
+func T(lang, message string) string {
+	if translated, ok := catalogs[Resolve(lang)][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf formats the translation of a German format string
+func Sprintf(lang, format string, args ...any) string {
+	return fmt.Sprintf(T(lang, format), args...)
+}
+
+// Errorf is like fmt.Errorf with a translated format string
+func Errorf(lang, format string, args ...any) error {
+	return fmt.Errorf(T(lang, format), args...)
+}
+
+// Wrap returns an error with a translated message that matches target
+// with errors.Is. It is used for sentinel errors, whose own text cannot be
+// translated.
+func Wrap(target error, lang, format string, args ...any) error {
+	return &wrapped{target: target, message: Sprintf(lang, format, args...)}
+}
+
+type wrapped struct {
+	target  error
+	message string
+}
+
+func (e *wrapped) Error() string { return e.message }
+
+func (e *wrapped) Unwrap() error { return e.target }
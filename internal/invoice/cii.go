@@ -0,0 +1,267 @@
+package invoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// The raw types mirror the CII element structure. ZUGFeRD 1.0 uses
+// different container names than 2.x for the same content, which is
+// covered by the Legacy* fields.
+
+// rawAmount is a monetary amount with its optional currency attribute
+type rawAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"currencyID,attr"`
+}
+
+// rawDateTime is a udt:DateTimeType element
+type rawDateTime struct {
+	Value  string `xml:",chardata"`
+	Format string `xml:"format,attr"`
+}
+
+// rawTaxRegistration is a SpecifiedTaxRegistration; the scheme tells a VAT
+// ID (VA) from a local tax number (FC)
+type rawTaxRegistration struct {
+	ID       string `xml:",chardata"`
+	SchemeID string `xml:"schemeID,attr"`
+}
+
+// rawParty is a seller or buyer trade party
+type rawParty struct {
+	Name             string               `xml:"Name"`
+	TaxRegistrations []rawTaxRegistration `xml:"SpecifiedTaxRegistration>ID"`
+}
+
+// taxRegistration returns the first registration ID of the given scheme
+func (p rawParty) taxRegistration(scheme string) string {
+	for _, registration := range p.TaxRegistrations {
+		if strings.EqualFold(strings.TrimSpace(registration.SchemeID), scheme) {
+			if id := strings.TrimSpace(registration.ID); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// rawAgreement is the header trade agreement
+type rawAgreement struct {
+	BuyerReference string   `xml:"BuyerReference"`
+	Seller         rawParty `xml:"SellerTradeParty"`
+	Buyer          rawParty `xml:"BuyerTradeParty"`
+}
+
+// rawSummation holds the document totals
+type rawSummation struct {
+	AllowanceTotal []rawAmount `xml:"AllowanceTotalAmount"`
+	ChargeTotal    []rawAmount `xml:"ChargeTotalAmount"`
+	TaxTotal       []rawAmount `xml:"TaxTotalAmount"`
+	GrandTotal     []rawAmount `xml:"GrandTotalAmount"`
+}
+
+// rawTax is an ApplicableTradeTax entry of the header settlement; ZUGFeRD
+// 1.0 names the rate ApplicablePercent and has no exemption reason code
+type rawTax struct {
+	Calculated          []rawAmount `xml:"CalculatedAmount"`
+	TypeCode            string      `xml:"TypeCode"`
+	ExemptionReason     string      `xml:"ExemptionReason"`
+	Basis               []rawAmount `xml:"BasisAmount"`
+	CategoryCode        string      `xml:"CategoryCode"`
+	ExemptionReasonCode string      `xml:"ExemptionReasonCode"`
+	Rate                string      `xml:"RateApplicablePercent"`
+	LegacyRate          string      `xml:"ApplicablePercent"`
+}
+
+// rawPaymentTerms is a SpecifiedTradePaymentTerms block; ZUGFeRD 1.0
+// allows several descriptions per block
+type rawPaymentTerms struct {
+	Descriptions []string    `xml:"Description"`
+	DueDate      rawDateTime `xml:"DueDateDateTime>DateTimeString"`
+	MandateID    string      `xml:"DirectDebitMandateID"`
+}
+
+// rawPaymentMeans is a SpecifiedTradeSettlementPaymentMeans entry
+type rawPaymentMeans struct {
+	TypeCode    string `xml:"TypeCode"`
+	Information string `xml:"Information"`
+	PayerIBAN   string `xml:"PayerPartyDebtorFinancialAccount>IBANID"`
+	PayeeIBAN   string `xml:"PayeePartyCreditorFinancialAccount>IBANID"`
+	AccountName string `xml:"PayeePartyCreditorFinancialAccount>AccountName"`
+	PayeeBIC    string `xml:"PayeeSpecifiedCreditorFinancialInstitution>BICID"`
+}
+
+// rawAllowanceCharge is a document level SpecifiedTradeAllowanceCharge;
+// the indicator is true for a charge and false for an allowance
+type rawAllowanceCharge struct {
+	ChargeIndicator string      `xml:"ChargeIndicator>Indicator"`
+	Actual          []rawAmount `xml:"ActualAmount"`
+	ReasonCode      string      `xml:"ReasonCode"`
+	Reason          string      `xml:"Reason"`
+}
+
+// rawSettlement is the header trade settlement
+type rawSettlement struct {
+	Currency         string               `xml:"InvoiceCurrencyCode"`
+	TaxCurrency      string               `xml:"TaxCurrencyCode"`
+	Taxes            []rawTax             `xml:"ApplicableTradeTax"`
+	AllowanceCharges []rawAllowanceCharge `xml:"SpecifiedTradeAllowanceCharge"`
+	PaymentTerms     []rawPaymentTerms    `xml:"SpecifiedTradePaymentTerms"`
+	PaymentMeans     []rawPaymentMeans    `xml:"SpecifiedTradeSettlementPaymentMeans"`
+	CreditorID       string               `xml:"CreditorReferenceID"`
+	Summation        *rawSummation        `xml:"SpecifiedTradeSettlementHeaderMonetarySummation"`
+	LegacySummation  *rawSummation        `xml:"SpecifiedTradeSettlementMonetarySummation"`
+}
+
+// summation returns the document totals of either version
+func (s rawSettlement) summation() rawSummation {
+	switch {
+	case s.Summation != nil:
+		return *s.Summation
+	case s.LegacySummation != nil:
+		return *s.LegacySummation
+	}
+	return rawSummation{}
+}
+
+// rawQuantity is a quantity with its unit code (UN/ECE Recommendation 20)
+type rawQuantity struct {
+	Value    string `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr"`
+}
+
+// rawLineAgreement is the line trade agreement
+type rawLineAgreement struct {
+	NetPrice []rawAmount `xml:"NetPriceProductTradePrice>ChargeAmount"`
+}
+
+// rawLineDelivery is the line trade delivery
+type rawLineDelivery struct {
+	BilledQuantity rawQuantity `xml:"BilledQuantity"`
+}
+
+// rawLineSettlement is the line trade settlement
+type rawLineSettlement struct {
+	LineTotal       []rawAmount `xml:"SpecifiedTradeSettlementLineMonetarySummation>LineTotalAmount"`
+	LegacyLineTotal []rawAmount `xml:"SpecifiedTradeSettlementMonetarySummation>LineTotalAmount"`
+}
+
+// lineTotal returns the line net amount of either version
+func (s rawLineSettlement) lineTotal() []rawAmount {
+	if len(s.LineTotal) > 0 {
+		return s.LineTotal
+	}
+	return s.LegacyLineTotal
+}
+
+// rawLineItem is an IncludedSupplyChainTradeLineItem
+type rawLineItem struct {
+	LineID           string            `xml:"AssociatedDocumentLineDocument>LineID"`
+	Name             string            `xml:"SpecifiedTradeProduct>Name"`
+	Agreement        rawLineAgreement  `xml:"SpecifiedLineTradeAgreement"`
+	LegacyAgreement  rawLineAgreement  `xml:"SpecifiedSupplyChainTradeAgreement"`
+	Delivery         rawLineDelivery   `xml:"SpecifiedLineTradeDelivery"`
+	LegacyDelivery   rawLineDelivery   `xml:"SpecifiedSupplyChainTradeDelivery"`
+	Settlement       rawLineSettlement `xml:"SpecifiedLineTradeSettlement"`
+	LegacySettlement rawLineSettlement `xml:"SpecifiedSupplyChainTradeSettlement"`
+}
+
+// agreement returns the line trade agreement of the given version
+func (l rawLineItem) agreement(legacy bool) rawLineAgreement {
+	if legacy {
+		return l.LegacyAgreement
+	}
+	return l.Agreement
+}
+
+// delivery returns the line trade delivery of the given version
+func (l rawLineItem) delivery(legacy bool) rawLineDelivery {
+	if legacy {
+		return l.LegacyDelivery
+	}
+	return l.Delivery
+}
+
+// settlement returns the line trade settlement of the given version
+func (l rawLineItem) settlement(legacy bool) rawLineSettlement {
+	if legacy {
+		return l.LegacySettlement
+	}
+	return l.Settlement
+}
+
+// rawTransaction is the supply chain trade transaction
+type rawTransaction struct {
+	LineItems        []rawLineItem `xml:"IncludedSupplyChainTradeLineItem"`
+	Agreement        rawAgreement  `xml:"ApplicableHeaderTradeAgreement"`
+	LegacyAgreement  rawAgreement  `xml:"ApplicableSupplyChainTradeAgreement"`
+	Settlement       rawSettlement `xml:"ApplicableHeaderTradeSettlement"`
+	LegacySettlement rawSettlement `xml:"ApplicableSupplyChainTradeSettlement"`
+}
+
+// agreement returns the trade agreement of the given version
+func (t rawTransaction) agreement(legacy bool) rawAgreement {
+	if legacy {
+		return t.LegacyAgreement
+	}
+	return t.Agreement
+}
+
+// settlement returns the trade settlement of the given version
+func (t rawTransaction) settlement(legacy bool) rawSettlement {
+	if legacy {
+		return t.LegacySettlement
+	}
+	return t.Settlement
+}
+
+// rawDocument is the exchanged document header
+type rawDocument struct {
+	ID        string      `xml:"ID"`
+	TypeCode  string      `xml:"TypeCode"`
+	IssueDate rawDateTime `xml:"IssueDateTime>DateTimeString"`
+}
+
+// rawInvoice covers the CrossIndustryInvoice (2.x) and CrossIndustryDocument
+// (1.0) roots
+type rawInvoice struct {
+	XMLName           xml.Name
+	Document          rawDocument    `xml:"ExchangedDocument"`
+	LegacyDocument    rawDocument    `xml:"HeaderExchangedDocument"`
+	Transaction       rawTransaction `xml:"SupplyChainTradeTransaction"`
+	LegacyTransaction rawTransaction `xml:"SpecifiedSupplyChainTradeTransaction"`
+}
+
+// legacy reports whether the document uses the ZUGFeRD 1.0 structure
+func (r *rawInvoice) legacy() bool {
+	return r.XMLName.Local == rootZUGFeRD1
+}
+
+// document returns the document header of either version
+func (r *rawInvoice) document() rawDocument {
+	if r.legacy() {
+		return r.LegacyDocument
+	}
+	return r.Document
+}
+
+// transaction returns the trade transaction of either version
+func (r *rawInvoice) transaction() rawTransaction {
+	if r.legacy() {
+		return r.LegacyTransaction
+	}
+	return r.Transaction
+}
+
+// newDecoder creates a lenient XML decoder that accepts any declared encoding
+func newDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
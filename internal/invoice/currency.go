@@ -0,0 +1,87 @@
+package invoice
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// Elements of the document currency (BT-5) in CII and UBL and of the VAT
+// accounting currency (BT-6), which has the same name in both syntaxes
+const (
+	currencyElementCII = "InvoiceCurrencyCode"
+	currencyElementUBL = "DocumentCurrencyCode"
+	taxCurrencyElement = "TaxCurrencyCode"
+)
+
+// DetectCurrency returns the invoice currency (BT-5) of a CII or UBL
+// document without parsing the invoice, see DetectCurrencies. A document
+// without currency is an error.
+func DetectCurrency(data []byte, lang string) (string, error) {
+	currency, _, err := DetectCurrencies(data, lang)
+	if err != nil {
+		return "", err
+	}
+	if currency == "" {
+		return "", i18n.Errorf(lang, "keine Rechnungswährung (BT-5) gefunden")
+	}
+	return currency, nil
+}
+
+// DetectCurrencies returns the invoice currency (BT-5) and the VAT
+// accounting currency (BT-6) of a CII or UBL document. The elements are
+// found by a token scan that stops as soon as both are known, so it is
+// much cheaper than ParseInvoice. A missing element is an empty string;
+// BT-6 is only present if the VAT is accounted in another currency.
+func DetectCurrencies(data []byte, lang string) (currency, taxCurrency string, err error) {
+	decoder := newDecoder(data)
+	currencyElement := ""
+	for currency == "" || taxCurrency == "" {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", "", i18n.Errorf(lang, "XML konnte nicht gelesen werden: %v", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if currencyElement == "" {
+			switch start.Name.Local {
+			case rootCII, rootZUGFeRD1:
+				currencyElement = currencyElementCII
+			case rootUBLInvoice, rootUBLCreditNote:
+				currencyElement = currencyElementUBL
+			default:
+				return "", "", i18n.Errorf(lang, "unbekanntes Wurzelelement: %s", start.Name.Local)
+			}
+			continue
+		}
+
+		var target *string
+		switch start.Name.Local {
+		case currencyElement:
+			target = &currency
+		case taxCurrencyElement:
+			target = &taxCurrency
+		default:
+			continue
+		}
+		var value string
+		if err := decoder.DecodeElement(&value, &start); err != nil {
+			return "", "", i18n.Errorf(lang, "XML konnte nicht gelesen werden: %v", err)
+		}
+		if *target == "" {
+			*target = strings.TrimSpace(value)
+		}
+	}
+	if currencyElement == "" {
+		return "", "", i18n.Errorf(lang, "XML enthält kein Wurzelelement")
+	}
+	return currency, taxCurrency, nil
+}
@@ -0,0 +1,222 @@
+package invoice
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kinds of a Difference
+const (
+	DiffChanged = "changed"
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+)
+
+// Difference is a field that differs between two invoices. Field is the
+// JSON path of the field, e.g. grandTotal, lineItems[2].quantity or
+// taxBreakdown[S 19%].taxAmount; Old and New are the formatted values, Old
+// is empty for an added and New for a removed entry.
+type Difference struct {
+	Kind  string `json:"kind"`
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+func (d Difference) String() string {
+	switch d.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("+ %s: %s", d.Field, d.New)
+	case DiffRemoved:
+		return fmt.Sprintf("- %s: %s", d.Field, d.Old)
+	}
+	return fmt.Sprintf("~ %s: %s -> %s", d.Field, d.Old, d.New)
+}
+
+// Diff compares the fields of two invoices and returns their differences
+// in the order of the Invoice fields; a field that is empty on one side is
+// added or removed. Line items are matched by their ID
+// and tax groups by category and rate, so a removed line does not show as
+// a change of all following lines; allowances and charges, payment terms
+// and means are compared by position.
+func Diff(a, b *Invoice) []Difference {
+	var diffs []Difference
+	field := func(name, before, after string) {
+		switch {
+		case before == after:
+		case before == "":
+			diffs = append(diffs, Difference{Kind: DiffAdded, Field: name, New: after})
+		case after == "":
+			diffs = append(diffs, Difference{Kind: DiffRemoved, Field: name, Old: before})
+		default:
+			diffs = append(diffs, Difference{Kind: DiffChanged, Field: name, Old: before, New: after})
+		}
+	}
+
+	field("invoiceNumber", a.Number, b.Number)
+	field("issueDate", a.IssueDate.String(), b.IssueDate.String())
+	field("sellerName", a.SellerName, b.SellerName)
+	field("buyerName", a.BuyerName, b.BuyerName)
+	field("currency", a.Currency, b.Currency)
+	field("taxCurrency", a.TaxCurrency, b.TaxCurrency)
+	field("grandTotal", a.GrandTotal.String(), b.GrandTotal.String())
+	field("documentTypeCode", a.DocumentTypeCode, b.DocumentTypeCode)
+	field("sellerVatId", a.SellerVATID, b.SellerVATID)
+	field("buyerVatId", a.BuyerVATID, b.BuyerVATID)
+	field("sellerTaxNumber", a.SellerTaxNumber, b.SellerTaxNumber)
+	field("buyerReference", a.BuyerReference, b.BuyerReference)
+	field("taxTotal", a.TaxTotal.String(), b.TaxTotal.String())
+	field("allowanceTotal", a.AllowanceTotal.String(), b.AllowanceTotal.String())
+	field("chargeTotal", a.ChargeTotal.String(), b.ChargeTotal.String())
+
+	diffs = append(diffs, diffKeyed("allowancesCharges", allowanceChargeEntries(a.AllowancesCharges), allowanceChargeEntries(b.AllowancesCharges))...)
+	diffs = append(diffs, diffKeyed("taxBreakdown", taxGroupEntries(a.TaxBreakdown), taxGroupEntries(b.TaxBreakdown))...)
+	diffs = append(diffs, diffKeyed("lineItems", lineItemEntries(a.LineItems), lineItemEntries(b.LineItems))...)
+	diffs = append(diffs, diffKeyed("paymentTerms", paymentTermsEntries(a.PaymentTerms), paymentTermsEntries(b.PaymentTerms))...)
+	diffs = append(diffs, diffKeyed("paymentMeans", paymentMeansEntries(a.PaymentMeans), paymentMeansEntries(b.PaymentMeans))...)
+	return diffs
+}
+
+// diffEntry is an element of a list field with the key it is matched by,
+// a summary for added and removed entries and its compared fields
+type diffEntry struct {
+	key     string
+	summary string
+	fields  [][2]string
+}
+
+// diffKeyed compares two lists of entries by key: entries missing in b are
+// removed, entries missing in a are added, and entries in both are
+// compared field by field. The result follows the order of a, then the
+// added entries in the order of b.
+func diffKeyed(name string, a, b []diffEntry) []Difference {
+	index := make(map[string]diffEntry, len(b))
+	for _, entry := range b {
+		index[entry.key] = entry
+	}
+
+	var diffs []Difference
+	seen := make(map[string]bool, len(a))
+	for _, before := range a {
+		seen[before.key] = true
+		path := fmt.Sprintf("%s[%s]", name, before.key)
+		after, ok := index[before.key]
+		if !ok {
+			diffs = append(diffs, Difference{Kind: DiffRemoved, Field: path, Old: before.summary})
+			continue
+		}
+		for i, f := range before.fields {
+			if f[1] != after.fields[i][1] {
+				diffs = append(diffs, Difference{Kind: DiffChanged, Field: path + "." + f[0], Old: f[1], New: after.fields[i][1]})
+			}
+		}
+	}
+	for _, after := range b {
+		if !seen[after.key] {
+			diffs = append(diffs, Difference{Kind: DiffAdded, Field: fmt.Sprintf("%s[%s]", name, after.key), New: after.summary})
+		}
+	}
+	return diffs
+}
+
+// uniqueKeys numbers repeated keys, e.g. two lines with ID 1 become 1 and
+// 1#2, so that every entry can be matched
+func uniqueKeys(entries []diffEntry) []diffEntry {
+	counts := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		counts[entry.key]++
+		if n := counts[entry.key]; n > 1 {
+			entries[i].key = entry.key + "#" + strconv.Itoa(n)
+		}
+	}
+	return entries
+}
+
+func lineItemEntries(items []LineItem) []diffEntry {
+	entries := make([]diffEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, diffEntry{
+			key:     item.ID,
+			summary: strings.TrimSpace(fmt.Sprintf("%s, %s, %s", item.Name, item.Quantity, item.NetAmount)),
+			fields: [][2]string{
+				{"name", item.Name},
+				{"quantity", item.Quantity.String()},
+				{"unitPrice", item.UnitPrice.String()},
+				{"netAmount", item.NetAmount.String()},
+			},
+		})
+	}
+	return uniqueKeys(entries)
+}
+
+func allowanceChargeEntries(entries []AllowanceCharge) []diffEntry {
+	result := make([]diffEntry, 0, len(entries))
+	for i, entry := range entries {
+		kind := "allowance"
+		if entry.Charge {
+			kind = "charge"
+		}
+		result = append(result, diffEntry{
+			key:     strconv.Itoa(i + 1),
+			summary: strings.TrimSpace(fmt.Sprintf("%s %s %s", kind, entry.Amount, firstNonEmpty(entry.Reason, entry.ReasonCode))),
+			fields: [][2]string{
+				{"charge", strconv.FormatBool(entry.Charge)},
+				{"amount", entry.Amount.String()},
+				{"reasonCode", entry.ReasonCode},
+				{"reason", entry.Reason},
+			},
+		})
+	}
+	return result
+}
+
+func taxGroupEntries(groups []TaxGroup) []diffEntry {
+	entries := make([]diffEntry, 0, len(groups))
+	for _, group := range groups {
+		entries = append(entries, diffEntry{
+			key:     strings.TrimSpace(group.CategoryCode + " " + strconv.FormatFloat(group.RatePercent, 'f', -1, 64) + "%"),
+			summary: fmt.Sprintf("%s / %s", group.BasisAmount, group.TaxAmount),
+			fields: [][2]string{
+				{"basisAmount", group.BasisAmount.String()},
+				{"taxAmount", group.TaxAmount.String()},
+				{"exemptionReason", group.ExemptionReason},
+				{"exemptionReasonCode", group.ExemptionReasonCode},
+			},
+		})
+	}
+	return uniqueKeys(entries)
+}
+
+func paymentTermsEntries(terms []PaymentTerms) []diffEntry {
+	entries := make([]diffEntry, 0, len(terms))
+	for i, entry := range terms {
+		entries = append(entries, diffEntry{
+			key:     strconv.Itoa(i + 1),
+			summary: strings.TrimSpace(entry.DueDate.String() + " " + entry.Description),
+			fields: [][2]string{
+				{"description", entry.Description},
+				{"dueDate", entry.DueDate.String()},
+			},
+		})
+	}
+	return entries
+}
+
+func paymentMeansEntries(means []PaymentMeans) []diffEntry {
+	entries := make([]diffEntry, 0, len(means))
+	for i, entry := range means {
+		entries = append(entries, diffEntry{
+			key:     strconv.Itoa(i + 1),
+			summary: strings.TrimSpace(entry.TypeCode + " " + firstNonEmpty(entry.PayeeIBAN, entry.PayerIBAN)),
+			fields: [][2]string{
+				{"typeCode", entry.TypeCode},
+				{"payeeIban", entry.PayeeIBAN},
+				{"payeeBic", entry.PayeeBIC},
+				{"payerIban", entry.PayerIBAN},
+				{"mandateReference", entry.MandateReference},
+			},
+		})
+	}
+	return entries
+}
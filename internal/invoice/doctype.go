@@ -0,0 +1,105 @@
+package invoice
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// Invoice type codes (UNTDID 1001) of the ZUGFeRD and XRechnung profiles
+const (
+	TypeCodeInvoice          = "380"
+	TypeCodeCreditNote       = "381"
+	TypeCodeCorrectedInvoice = "384"
+)
+
+// documentTypes names the invoice type codes permitted by EN16931
+var documentTypes = map[string]string{
+	"71":                     "Request for payment",
+	"80":                     "Debit note related to goods or services",
+	"81":                     "Credit note related to goods or services",
+	"82":                     "Metered services invoice",
+	"83":                     "Credit note related to financial adjustments",
+	"84":                     "Debit note related to financial adjustments",
+	"102":                    "Tax notification",
+	"218":                    "Final payment request based on completion of work",
+	"219":                    "Payment request for completed units",
+	"261":                    "Self billed credit note",
+	"262":                    "Consolidated credit note - goods and services",
+	"295":                    "Price variation invoice",
+	"296":                    "Credit note for price variation",
+	"308":                    "Delcredere credit note",
+	"325":                    "Proforma invoice",
+	"326":                    "Partial invoice",
+	TypeCodeInvoice:          "Commercial invoice",
+	TypeCodeCreditNote:       "Credit note",
+	"383":                    "Debit note",
+	TypeCodeCorrectedInvoice: "Corrected invoice",
+	"385":                    "Consolidated invoice",
+	"386":                    "Prepayment invoice",
+	"387":                    "Hire invoice",
+	"388":                    "Tax invoice",
+	"389":                    "Self-billed invoice",
+	"390":                    "Delcredere invoice",
+	"393":                    "Factored invoice",
+	"394":                    "Lease invoice",
+	"395":                    "Consignment invoice",
+	"396":                    "Factored credit note",
+	"420":                    "Optical Character Reading (OCR) payment credit note",
+	"456":                    "Debit advice",
+	"457":                    "Reversal of debit",
+	"458":                    "Reversal of credit",
+	"527":                    "Self billed debit note",
+	"532":                    "Forwarder's credit note",
+	"553":                    "Forwarder's invoice discrepancy report",
+	"575":                    "Insurer's invoice",
+	"623":                    "Forwarder's invoice",
+	"633":                    "Port charges documents",
+	"751":                    "Invoice information for accounting purposes",
+	"780":                    "Freight invoice",
+	"817":                    "Claim notification",
+	"870":                    "Consular invoice",
+	"875":                    "Partial construction invoice",
+	"876":                    "Partial final construction invoice",
+	"877":                    "Final construction invoice",
+	"935":                    "Customs invoice",
+}
+
+// DocumentTypeName returns the UNTDID 1001 name of an invoice type code, or
+// an empty string for an unknown code
+func DocumentTypeName(code string) string {
+	return documentTypes[strings.TrimSpace(code)]
+}
+
+// rawUBLDocument holds the type code of a UBL Invoice or CreditNote
+type rawUBLDocument struct {
+	XMLName            xml.Name
+	InvoiceTypeCode    string `xml:"InvoiceTypeCode"`
+	CreditNoteTypeCode string `xml:"CreditNoteTypeCode"`
+}
+
+// DocumentTypeCode returns the invoice type code (BT-3) of a CII or UBL
+// document. ParseInvoice reads CII documents only; for UBL this is the
+// type code element of the root, and a CreditNote root without one is a
+// credit note (381), an Invoice root a commercial invoice (380).
+func DocumentTypeCode(data []byte, lang string) (string, error) {
+	var raw rawUBLDocument
+	if err := newDecoder(data).Decode(&raw); err != nil {
+		return "", i18n.Errorf(lang, "XML konnte nicht gelesen werden: %v", err)
+	}
+
+	switch raw.XMLName.Local {
+	case rootUBLInvoice:
+		return firstNonEmpty(strings.TrimSpace(raw.InvoiceTypeCode), TypeCodeInvoice), nil
+	case rootUBLCreditNote:
+		return firstNonEmpty(strings.TrimSpace(raw.CreditNoteTypeCode), TypeCodeCreditNote), nil
+	case rootCII, rootZUGFeRD1:
+		inv, err := ParseInvoice(data, lang)
+		if err != nil {
+			return "", err
+		}
+		return inv.DocumentTypeCode, nil
+	}
+	return "", i18n.Errorf(lang, "unbekanntes Wurzelelement: %s", raw.XMLName.Local)
+}
@@ -0,0 +1,118 @@
+package invoice_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"zugferd-extractor/internal/extractor"
+	"zugferd-extractor/internal/invoice"
+)
+
+// extractXML returns the ZUGFeRD XML of a PDF below test-files
+func extractXML(t *testing.T, name string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	z := &extractor.ZUGFeRDExtractor{InputPath: filepath.Join("..", "..", "test-files", name)}
+	if err := z.WriteXML(&buf); err != nil {
+		t.Fatalf("WriteXML %s: %v", name, err)
+	}
+	return buf.Bytes()
+}
+
+// EN16931_Gutschrift.pdf is a credit note in the German sense of the
+// self-billing procedure, so its type code is 389 rather than 381
+func TestDocumentTypeSelfBilledFixture(t *testing.T) {
+	xmlData := extractXML(t, "EN16931_Gutschrift.pdf")
+
+	inv, err := invoice.ParseInvoice(xmlData, "")
+	if err != nil {
+		t.Fatalf("ParseInvoice: %v", err)
+	}
+	if inv.DocumentTypeCode != "389" {
+		t.Errorf("DocumentTypeCode = %q, want %q", inv.DocumentTypeCode, "389")
+	}
+	if inv.DocumentType != "Self-billed invoice" {
+		t.Errorf("DocumentType = %q, want %q", inv.DocumentType, "Self-billed invoice")
+	}
+
+	code, err := invoice.DocumentTypeCode(xmlData, "")
+	if err != nil {
+		t.Fatalf("DocumentTypeCode: %v", err)
+	}
+	if code != "389" {
+		t.Errorf("DocumentTypeCode() = %q, want %q", code, "389")
+	}
+}
+
+func TestDocumentTypeCIICreditNote(t *testing.T) {
+	const xmlData = `<rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100" xmlns:ram="urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100">` +
+		`<rsm:ExchangedDocument><ram:ID>GS-1</ram:ID><ram:TypeCode>381</ram:TypeCode></rsm:ExchangedDocument></rsm:CrossIndustryInvoice>`
+
+	inv, err := invoice.ParseInvoice([]byte(xmlData), "")
+	if err != nil {
+		t.Fatalf("ParseInvoice: %v", err)
+	}
+	if inv.DocumentTypeCode != invoice.TypeCodeCreditNote {
+		t.Errorf("DocumentTypeCode = %q, want %q", inv.DocumentTypeCode, invoice.TypeCodeCreditNote)
+	}
+	if inv.DocumentType != "Credit note" {
+		t.Errorf("DocumentType = %q, want %q", inv.DocumentType, "Credit note")
+	}
+}
+
+func TestDocumentTypeInvoiceFixture(t *testing.T) {
+	inv, err := invoice.ParseInvoice(extractXML(t, "EN16931_Einfach.pdf"), "")
+	if err != nil {
+		t.Fatalf("ParseInvoice: %v", err)
+	}
+	if inv.DocumentTypeCode != invoice.TypeCodeInvoice {
+		t.Errorf("DocumentTypeCode = %q, want %q", inv.DocumentTypeCode, invoice.TypeCodeInvoice)
+	}
+}
+
+func TestDocumentTypeCodeUBL(t *testing.T) {
+	const (
+		invoiceNS    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+		creditNoteNS = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+		basicNS      = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+	)
+	tests := []struct {
+		name string
+		xml  string
+		want string
+	}{
+		{
+			name: "credit note root without type code",
+			xml:  `<CreditNote xmlns="` + creditNoteNS + `" xmlns:cbc="` + basicNS + `"><cbc:ID>GS-1</cbc:ID></CreditNote>`,
+			want: invoice.TypeCodeCreditNote,
+		},
+		{
+			name: "credit note root with type code",
+			xml:  `<ubl:CreditNote xmlns:ubl="` + creditNoteNS + `" xmlns:cbc="` + basicNS + `"><cbc:CreditNoteTypeCode>381</cbc:CreditNoteTypeCode></ubl:CreditNote>`,
+			want: invoice.TypeCodeCreditNote,
+		},
+		{
+			name: "invoice root without type code",
+			xml:  `<Invoice xmlns="` + invoiceNS + `"/>`,
+			want: invoice.TypeCodeInvoice,
+		},
+		{
+			name: "corrected invoice",
+			xml:  `<Invoice xmlns="` + invoiceNS + `" xmlns:cbc="` + basicNS + `"><cbc:InvoiceTypeCode>384</cbc:InvoiceTypeCode></Invoice>`,
+			want: invoice.TypeCodeCorrectedInvoice,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := invoice.DocumentTypeCode([]byte(tt.xml), "")
+			if err != nil {
+				t.Fatalf("DocumentTypeCode: %v", err)
+			}
+			if code != tt.want {
+				t.Errorf("DocumentTypeCode = %q, want %q", code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,534 @@
+package invoice
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// Root element names of the supported CII documents
+const (
+	rootZUGFeRD1 = "CrossIndustryDocument"
+	rootCII      = "CrossIndustryInvoice"
+
+	rootUBLInvoice    = "Invoice"
+	rootUBLCreditNote = "CreditNote"
+)
+
+// paymentMeansDirectDebit is the UNTDID 4461 code of a SEPA direct debit
+const paymentMeansDirectDebit = "59"
+
+// Schemes of SpecifiedTaxRegistration
+const (
+	schemeVATID     = "VA"
+	schemeTaxNumber = "FC"
+)
+
+// Invoice holds the core fields of a ZUGFeRD invoice
+type Invoice struct {
+	Number     string `json:"invoiceNumber"`
+	IssueDate  Date   `json:"issueDate"`
+	SellerName string `json:"sellerName"`
+	BuyerName  string `json:"buyerName"`
+	Currency   string `json:"currency"`
+	GrandTotal Amount `json:"grandTotal"`
+
+	// TaxCurrency is the VAT accounting currency (BT-6); it is only set if
+	// it differs from the invoice currency, which makes the VAT total
+	// appear a second time in that currency
+	TaxCurrency string `json:"taxCurrency,omitempty"`
+
+	// DocumentTypeCode is the invoice type code (BT-3, UNTDID 1001), e.g.
+	// 380 for a commercial invoice or 381 for a credit note; DocumentType
+	// is its name, or empty for an unknown code
+	DocumentTypeCode string `json:"documentTypeCode"`
+	DocumentType     string `json:"documentType,omitempty"`
+
+	// SellerVATID (BT-31) and BuyerVATID (BT-48) are the VAT IDs of scheme
+	// VA; SellerTaxNumber (BT-32) is the local tax number of scheme FC
+	SellerVATID     string `json:"sellerVatId,omitempty"`
+	BuyerVATID      string `json:"buyerVatId,omitempty"`
+	SellerTaxNumber string `json:"sellerTaxNumber,omitempty"`
+
+	// BuyerReference is the reference assigned by the buyer (BT-10); for
+	// German public-sector buyers it is the Leitweg-ID, which XRechnung
+	// requires for routing the invoice
+	BuyerReference string `json:"buyerReference,omitempty"`
+
+	// TaxTotal is the invoice total VAT amount (BT-110)
+	TaxTotal Amount `json:"taxTotal"`
+
+	// AllowanceTotal (BT-107) and ChargeTotal (BT-108) are the sums of the
+	// document level allowances and charges
+	AllowanceTotal Amount `json:"allowanceTotal"`
+	ChargeTotal    Amount `json:"chargeTotal"`
+
+	// AllowancesCharges holds the document level allowances (BG-20) and
+	// charges (BG-21) in document order
+	AllowancesCharges []AllowanceCharge `json:"allowancesCharges,omitempty"`
+
+	// TaxBreakdown holds one group per VAT category and rate (BG-23)
+	TaxBreakdown []TaxGroup `json:"taxBreakdown,omitempty"`
+
+	// LineItems holds the invoice lines in document order
+	LineItems []LineItem `json:"lineItems,omitempty"`
+
+	// PaymentTerms holds the payment terms blocks in document order
+	PaymentTerms []PaymentTerms `json:"paymentTerms,omitempty"`
+
+	// PaymentMeans holds the payment instructions in document order (BG-16)
+	PaymentMeans []PaymentMeans `json:"paymentMeans,omitempty"`
+}
+
+// PaymentMeans is a payment instruction: its type code (BT-81, UNTDID
+// 4461, e.g. 58 SEPA credit transfer, 59 SEPA direct debit) with the
+// payee account for a transfer or, for a direct debit, the debited account
+// with the mandate reference (BT-89) and the creditor ID (BT-90)
+type PaymentMeans struct {
+	TypeCode         string `json:"typeCode"`
+	Information      string `json:"information,omitempty"`
+	PayeeIBAN        string `json:"payeeIban,omitempty"`
+	PayeeBIC         string `json:"payeeBic,omitempty"`
+	AccountName      string `json:"accountName,omitempty"`
+	PayerIBAN        string `json:"payerIban,omitempty"`
+	MandateReference string `json:"mandateReference,omitempty"`
+	CreditorID       string `json:"creditorId,omitempty"`
+}
+
+// PaymentTerms is a payment terms block with its description (BT-20) and
+// due date (BT-9). Without a DueDateDateTime the latest date named in the
+// description is taken as the due date, e.g. 04.04.2018 from "Zahlbar
+// innerhalb 30 Tagen netto bis 04.04.2018, 3% Skonto innerhalb 10 Tagen
+// bis 15.03.2018", and DueDateFromDescription is set.
+type PaymentTerms struct {
+	Description            string `json:"description,omitempty"`
+	DueDate                Date   `json:"dueDate"`
+	DueDateFromDescription bool   `json:"dueDateFromDescription,omitempty"`
+}
+
+// AllowanceCharge is a document level allowance or, if Charge is set, a
+// charge with its amount (BT-92/BT-99) and reason as code (BT-98/BT-105,
+// UNTDID 5189 for allowances, 7161 for charges) and text (BT-97/BT-104)
+type AllowanceCharge struct {
+	Charge     bool   `json:"charge"`
+	Amount     Amount `json:"amount"`
+	ReasonCode string `json:"reasonCode,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// LineItem is a single invoice line (BG-25)
+type LineItem struct {
+	ID        string   `json:"lineId"`
+	Name      string   `json:"name"`
+	Quantity  Quantity `json:"quantity"`
+	UnitPrice Amount   `json:"unitPrice"`
+	NetAmount Amount   `json:"netAmount"`
+}
+
+// TaxGroup is the VAT breakdown of one category and rate. Exempt and
+// zero-rated groups carry the reason for the exemption.
+type TaxGroup struct {
+	CategoryCode        string  `json:"categoryCode"`
+	RatePercent         float64 `json:"ratePercent"`
+	BasisAmount         Amount  `json:"basisAmount"`
+	TaxAmount           Amount  `json:"taxAmount"`
+	ExemptionReason     string  `json:"exemptionReason,omitempty"`
+	ExemptionReasonCode string  `json:"exemptionReasonCode,omitempty"`
+}
+
+// Quantity is an amount of goods or services in a unit of measure
+type Quantity struct {
+	Value    float64 `json:"value"`
+	UnitCode string  `json:"unitCode"`
+}
+
+func (q Quantity) String() string {
+	return strings.TrimSpace(strconv.FormatFloat(q.Value, 'f', -1, 64) + " " + q.UnitCode)
+}
+
+// Amount is a monetary amount in a currency
+type Amount struct {
+	Value    float64 `json:"value"`
+	Currency string  `json:"currency"`
+}
+
+func (a Amount) String() string {
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", a.Value, a.Currency))
+}
+
+// Date is a calendar date without time of day
+type Date struct {
+	time.Time
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format("2006-01-02")
+}
+
+// MarshalJSON encodes the date in ISO 8601 format, or null if it is unset
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// ParseInvoice reads the core invoice fields from a ZUGFeRD 1.0
+// CrossIndustryDocument or a ZUGFeRD 2.x CrossIndustryInvoice. Errors are
+// in lang, see i18n.Resolve; the other functions of the package take lang
+// the same way.
+func ParseInvoice(data []byte, lang string) (*Invoice, error) {
+	var raw rawInvoice
+	if err := newDecoder(data).Decode(&raw); err != nil {
+		return nil, i18n.Errorf(lang, "XML konnte nicht gelesen werden: %v", err)
+	}
+	if raw.XMLName.Local != rootCII && raw.XMLName.Local != rootZUGFeRD1 {
+		return nil, i18n.Errorf(lang, "unbekanntes Wurzelelement: %s", raw.XMLName.Local)
+	}
+
+	legacy := raw.legacy()
+	document := raw.document()
+	transaction := raw.transaction()
+	agreement := transaction.agreement(legacy)
+	settlement := transaction.settlement(legacy)
+
+	issueDate, err := parseDate(document.IssueDate, lang)
+	if err != nil {
+		return nil, i18n.Errorf(lang, "ungültiges Rechnungsdatum: %v", err)
+	}
+
+	currency := strings.TrimSpace(settlement.Currency)
+	taxCurrency := strings.TrimSpace(settlement.TaxCurrency)
+	if taxCurrency == currency {
+		taxCurrency = ""
+	}
+	summation := settlement.summation()
+	grandTotal, err := parseAmount(summation.GrandTotal, currency, lang)
+	if err != nil {
+		return nil, i18n.Errorf(lang, "ungültiger Gesamtbetrag: %v", err)
+	}
+	taxTotal, err := parseAmount(summation.TaxTotal, currency, lang)
+	if err != nil {
+		return nil, i18n.Errorf(lang, "ungültiger Steuergesamtbetrag: %v", err)
+	}
+	allowanceTotal, err := parseAmount(summation.AllowanceTotal, currency, lang)
+	if err != nil {
+		return nil, i18n.Errorf(lang, "ungültige Summe der Nachlässe: %v", err)
+	}
+	chargeTotal, err := parseAmount(summation.ChargeTotal, currency, lang)
+	if err != nil {
+		return nil, i18n.Errorf(lang, "ungültige Summe der Zuschläge: %v", err)
+	}
+	allowancesCharges, err := parseAllowanceCharges(settlement.AllowanceCharges, currency, lang)
+	if err != nil {
+		return nil, err
+	}
+	taxBreakdown, err := parseTaxBreakdown(settlement.Taxes, currency, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	lineItems, err := parseLineItems(transaction.LineItems, legacy, currency, lang)
+	if err != nil {
+		return nil, err
+	}
+	paymentTerms, err := parsePaymentTerms(settlement.PaymentTerms, lang)
+	if err != nil {
+		return nil, err
+	}
+	paymentMeans := parsePaymentMeans(settlement)
+
+	typeCode := strings.TrimSpace(document.TypeCode)
+	return &Invoice{
+		Number:            strings.TrimSpace(document.ID),
+		DocumentTypeCode:  typeCode,
+		DocumentType:      DocumentTypeName(typeCode),
+		IssueDate:         issueDate,
+		SellerName:        strings.TrimSpace(agreement.Seller.Name),
+		BuyerName:         strings.TrimSpace(agreement.Buyer.Name),
+		Currency:          currency,
+		TaxCurrency:       taxCurrency,
+		GrandTotal:        grandTotal,
+		SellerVATID:       agreement.Seller.taxRegistration(schemeVATID),
+		BuyerVATID:        agreement.Buyer.taxRegistration(schemeVATID),
+		SellerTaxNumber:   agreement.Seller.taxRegistration(schemeTaxNumber),
+		BuyerReference:    strings.TrimSpace(agreement.BuyerReference),
+		TaxTotal:          taxTotal,
+		AllowanceTotal:    allowanceTotal,
+		ChargeTotal:       chargeTotal,
+		AllowancesCharges: allowancesCharges,
+		TaxBreakdown:      taxBreakdown,
+		LineItems:         lineItems,
+		PaymentTerms:      paymentTerms,
+		PaymentMeans:      paymentMeans,
+	}, nil
+}
+
+// parseAllowanceCharges converts the document level allowances and
+// charges; the indicator is an xs:boolean, anything else is an error, as
+// the entry would otherwise be counted on the wrong side
+func parseAllowanceCharges(raw []rawAllowanceCharge, currency, lang string) ([]AllowanceCharge, error) {
+	var entries []AllowanceCharge
+	for i, ac := range raw {
+		var charge bool
+		switch indicator := strings.TrimSpace(ac.ChargeIndicator); indicator {
+		case "true", "1":
+			charge = true
+		case "false", "0":
+		default:
+			return nil, i18n.Errorf(lang, "Nachlass/Zuschlag %d: ungültiger Indikator: %s", i+1, indicator)
+		}
+		amount, err := parseAmount(ac.Actual, currency, lang)
+		if err != nil {
+			return nil, i18n.Errorf(lang, "Nachlass/Zuschlag %d: ungültiger Betrag: %v", i+1, err)
+		}
+
+		entries = append(entries, AllowanceCharge{
+			Charge:     charge,
+			Amount:     amount,
+			ReasonCode: strings.TrimSpace(ac.ReasonCode),
+			Reason:     strings.TrimSpace(ac.Reason),
+		})
+	}
+	return entries, nil
+}
+
+// parseTaxBreakdown converts the VAT breakdown of the header settlement; a
+// missing rate, as on some exempt groups, is zero
+func parseTaxBreakdown(raw []rawTax, currency, lang string) ([]TaxGroup, error) {
+	var groups []TaxGroup
+	for _, tax := range raw {
+		category := strings.TrimSpace(tax.CategoryCode)
+
+		var rate float64
+		if value := strings.TrimSpace(firstNonEmpty(tax.Rate, tax.LegacyRate)); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, i18n.Errorf(lang, "Steuerkategorie %s: ungültiger Steuersatz: %s", category, value)
+			}
+			rate = parsed
+		}
+		basis, err := parseAmount(tax.Basis, currency, lang)
+		if err != nil {
+			return nil, i18n.Errorf(lang, "Steuerkategorie %s: ungültiger Basisbetrag: %v", category, err)
+		}
+		amount, err := parseAmount(tax.Calculated, currency, lang)
+		if err != nil {
+			return nil, i18n.Errorf(lang, "Steuerkategorie %s: ungültiger Steuerbetrag: %v", category, err)
+		}
+
+		groups = append(groups, TaxGroup{
+			CategoryCode:        category,
+			RatePercent:         rate,
+			BasisAmount:         basis,
+			TaxAmount:           amount,
+			ExemptionReason:     strings.TrimSpace(tax.ExemptionReason),
+			ExemptionReasonCode: strings.TrimSpace(tax.ExemptionReasonCode),
+		})
+	}
+	return groups, nil
+}
+
+// parsePaymentMeans converts the payment instructions. The mandate
+// reference is part of the payment terms and the creditor ID of the
+// settlement; both are added to direct debit entries only.
+func parsePaymentMeans(settlement rawSettlement) []PaymentMeans {
+	var mandate string
+	for _, terms := range settlement.PaymentTerms {
+		if mandate = strings.TrimSpace(terms.MandateID); mandate != "" {
+			break
+		}
+	}
+
+	var means []PaymentMeans
+	for _, raw := range settlement.PaymentMeans {
+		entry := PaymentMeans{
+			TypeCode:    strings.TrimSpace(raw.TypeCode),
+			Information: strings.TrimSpace(raw.Information),
+			PayeeIBAN:   normalizeAccount(raw.PayeeIBAN),
+			PayeeBIC:    normalizeAccount(raw.PayeeBIC),
+			AccountName: strings.TrimSpace(raw.AccountName),
+			PayerIBAN:   normalizeAccount(raw.PayerIBAN),
+		}
+		if entry.TypeCode == paymentMeansDirectDebit {
+			entry.MandateReference = mandate
+			entry.CreditorID = strings.TrimSpace(settlement.CreditorID)
+		}
+		means = append(means, entry)
+	}
+	return means
+}
+
+// normalizeAccount removes the blanks of an IBAN or BIC written in groups
+func normalizeAccount(value string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(value), ""))
+}
+
+// descriptionDate matches a date in a payment terms description, either
+// German (15.03.2018) or ISO 8601 (2018-03-15)
+var descriptionDate = regexp.MustCompile(`\b(\d{1,2}\.\d{1,2}\.\d{4}|\d{4}-\d{2}-\d{2})\b`)
+
+// parsePaymentTerms converts the payment terms blocks; blocks without a
+// description and due date are left out
+func parsePaymentTerms(raw []rawPaymentTerms, lang string) ([]PaymentTerms, error) {
+	var terms []PaymentTerms
+	for i, block := range raw {
+		var descriptions []string
+		for _, description := range block.Descriptions {
+			if description = strings.TrimSpace(description); description != "" {
+				descriptions = append(descriptions, description)
+			}
+		}
+
+		dueDate, err := parseDate(block.DueDate, lang)
+		if err != nil {
+			return nil, i18n.Errorf(lang, "Zahlungsbedingung %d: ungültiges Fälligkeitsdatum: %v", i+1, err)
+		}
+		entry := PaymentTerms{Description: strings.Join(descriptions, "\n"), DueDate: dueDate}
+		if entry.DueDate.IsZero() {
+			entry.DueDate = dateFromDescription(entry.Description)
+			entry.DueDateFromDescription = !entry.DueDate.IsZero()
+		}
+		if entry.Description == "" && entry.DueDate.IsZero() {
+			continue
+		}
+		terms = append(terms, entry)
+	}
+	return terms, nil
+}
+
+// dateFromDescription returns the latest valid date named in a payment
+// terms description; earlier dates are usually discount deadlines
+func dateFromDescription(description string) Date {
+	var latest Date
+	for _, match := range descriptionDate.FindAllString(description, -1) {
+		for _, layout := range []string{"2.1.2006", "2006-01-02"} {
+			if t, err := time.Parse(layout, match); err == nil {
+				if t.After(latest.Time) {
+					latest = Date{t}
+				}
+				break
+			}
+		}
+	}
+	return latest
+}
+
+// firstNonEmpty returns the first value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseLineItems converts the raw invoice lines; amounts without a
+// currency attribute are in the invoice currency
+func parseLineItems(raw []rawLineItem, legacy bool, currency, lang string) ([]LineItem, error) {
+	var items []LineItem
+	for i, line := range raw {
+		item, err := parseLineItem(line, i, legacy, currency, lang)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseLineItem converts the invoice line at index; a line without ID is
+// numbered from 1
+func parseLineItem(line rawLineItem, index int, legacy bool, currency, lang string) (LineItem, error) {
+	id := strings.TrimSpace(line.LineID)
+	if id == "" {
+		id = strconv.Itoa(index + 1)
+	}
+
+	quantity, err := parseQuantity(line.delivery(legacy).BilledQuantity, lang)
+	if err != nil {
+		return LineItem{}, i18n.Errorf(lang, "Position %s: ungültige Menge: %v", id, err)
+	}
+	unitPrice, err := parseAmount(line.agreement(legacy).NetPrice, currency, lang)
+	if err != nil {
+		return LineItem{}, i18n.Errorf(lang, "Position %s: ungültiger Einzelpreis: %v", id, err)
+	}
+	netAmount, err := parseAmount(line.settlement(legacy).lineTotal(), currency, lang)
+	if err != nil {
+		return LineItem{}, i18n.Errorf(lang, "Position %s: ungültiger Nettobetrag: %v", id, err)
+	}
+
+	return LineItem{
+		ID:        id,
+		Name:      strings.TrimSpace(line.Name),
+		Quantity:  quantity,
+		UnitPrice: unitPrice,
+		NetAmount: netAmount,
+	}, nil
+}
+
+// parseQuantity parses a quantity; a missing quantity is zero
+func parseQuantity(raw rawQuantity, lang string) (Quantity, error) {
+	quantity := Quantity{UnitCode: strings.TrimSpace(raw.UnitCode)}
+	value := strings.TrimSpace(raw.Value)
+	if value == "" {
+		return quantity, nil
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Quantity{}, i18n.Errorf(lang, "keine Zahl: %s", raw.Value)
+	}
+	quantity.Value = parsed
+	return quantity, nil
+}
+
+// parseDate parses a udt:DateTimeString; format 102 (YYYYMMDD) is the
+// only format permitted by EN16931, ISO dates are accepted as well
+func parseDate(raw rawDateTime, lang string) (Date, error) {
+	value := strings.TrimSpace(raw.Value)
+	if value == "" {
+		return Date{}, nil
+	}
+
+	for _, layout := range []string{"20060102", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return Date{t}, nil
+		}
+	}
+	return Date{}, i18n.Errorf(lang, "unbekanntes Datumsformat: %s", value)
+}
+
+// parseAmount returns the amount in the given currency, falling back to the
+// first amount when no currency matches. A missing amount is zero.
+func parseAmount(amounts []rawAmount, currency, lang string) (Amount, error) {
+	if len(amounts) == 0 {
+		return Amount{Currency: currency}, nil
+	}
+
+	selected := amounts[0]
+	for _, amount := range amounts {
+		if amount.Currency == currency {
+			selected = amount
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(selected.Value), 64)
+	if err != nil {
+		return Amount{}, i18n.Errorf(lang, "ungültiger Betrag: %s", selected.Value)
+	}
+
+	if selected.Currency != "" {
+		currency = selected.Currency
+	}
+	return Amount{Value: value, Currency: currency}, nil
+}
@@ -0,0 +1,96 @@
+package invoice
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// lineItemElement is the element of an invoice line in ZUGFeRD 1.0 and 2.x
+const lineItemElement = "IncludedSupplyChainTradeLineItem"
+
+// LineHandler receives the invoice lines of StreamInvoice one at a time;
+// an error stops the stream and is returned by StreamInvoice
+type LineHandler func(item LineItem) error
+
+// StreamInvoice calls handler for every invoice line in document order.
+// Unlike ParseInvoice it decodes one line at a time, so the memory needed
+// does not grow with the number of lines, e.g. for EXTENDED invoices with
+// thousands of positions. The invoice currency follows the lines in the
+// document and is read in a first pass over data.
+func StreamInvoice(data []byte, handler LineHandler, lang string) error {
+	root, currency, err := scanHeader(data, lang)
+	if err != nil {
+		return err
+	}
+	legacy := root == rootZUGFeRD1
+
+	decoder := newDecoder(data)
+	index := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return i18n.Errorf(lang, "XML konnte nicht gelesen werden: %v", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != lineItemElement {
+			continue
+		}
+		var line rawLineItem
+		if err := decoder.DecodeElement(&line, &start); err != nil {
+			return i18n.Errorf(lang, "XML konnte nicht gelesen werden: %v", err)
+		}
+		item, err := parseLineItem(line, index, legacy, currency, lang)
+		if err != nil {
+			return err
+		}
+		if err := handler(item); err != nil {
+			return err
+		}
+		index++
+	}
+}
+
+// scanHeader returns the root element name and the invoice currency
+// without decoding the document into structs
+func scanHeader(data []byte, lang string) (root, currency string, err error) {
+	decoder := newDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", "", i18n.Errorf(lang, "XML konnte nicht gelesen werden: %v", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root == "" {
+			root = start.Name.Local
+			if root != rootCII && root != rootZUGFeRD1 {
+				return "", "", i18n.Errorf(lang, "unbekanntes Wurzelelement: %s", root)
+			}
+			continue
+		}
+		if start.Name.Local == "InvoiceCurrencyCode" {
+			var value string
+			if err := decoder.DecodeElement(&value, &start); err != nil {
+				return "", "", i18n.Errorf(lang, "XML konnte nicht gelesen werden: %v", err)
+			}
+			return root, strings.TrimSpace(value), nil
+		}
+	}
+	if root == "" {
+		return "", "", i18n.Errorf(lang, "XML enthält kein Wurzelelement")
+	}
+	return root, "", nil
+}
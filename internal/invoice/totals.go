@@ -0,0 +1,41 @@
+package invoice
+
+import "math"
+
+// totalsTolerance is the accepted rounding difference between the stated
+// and the recomputed grand total
+const totalsTolerance = 0.005
+
+// CheckTotals recomputes the grand total (BT-112) as the sum of the line
+// net amounts plus the total VAT, minus the document level allowances,
+// plus the charges, and compares it with the stated grand total. A
+// mismatch beyond rounding is a common error of invoice generators. The
+// allowances and charges are summed from their document level entries if
+// there are any, so a wrong BT-107 or BT-108 shows as well; otherwise the
+// stated sums are used. An invoice without lines, e.g. of the MINIMUM
+// profile, cannot be recomputed and is reported as ok with the stated
+// total as computed.
+func CheckTotals(inv *Invoice) (ok bool, stated, computed float64) {
+	stated = inv.GrandTotal.Value
+	if len(inv.LineItems) == 0 {
+		return true, stated, stated
+	}
+
+	for _, item := range inv.LineItems {
+		computed += item.NetAmount.Value
+	}
+	allowances, charges := inv.AllowanceTotal.Value, inv.ChargeTotal.Value
+	if len(inv.AllowancesCharges) > 0 {
+		allowances, charges = 0, 0
+		for _, ac := range inv.AllowancesCharges {
+			if ac.Charge {
+				charges += ac.Amount.Value
+			} else {
+				allowances += ac.Amount.Value
+			}
+		}
+	}
+	computed += inv.TaxTotal.Value - allowances + charges
+	computed = math.Round(computed*100) / 100
+	return math.Abs(stated-computed) < totalsTolerance, stated, computed
+}
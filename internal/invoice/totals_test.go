@@ -0,0 +1,58 @@
+package invoice
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// parseTestdata parses an invoice below testdata
+func parseTestdata(t *testing.T, name string) *Invoice {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv, err := ParseInvoice(data, "")
+	if err != nil {
+		t.Fatalf("ParseInvoice: %v", err)
+	}
+	return inv
+}
+
+func TestParseAllowancesCharges(t *testing.T) {
+	inv := parseTestdata(t, "allowance-charge.xml")
+
+	want := []AllowanceCharge{
+		{Charge: false, Amount: Amount{Value: 10, Currency: "EUR"}, ReasonCode: "95", Reason: "Treuerabatt"},
+		{Charge: true, Amount: Amount{Value: 5, Currency: "EUR"}, ReasonCode: "FC", Reason: "Versandkosten"},
+	}
+	if len(inv.AllowancesCharges) != len(want) {
+		t.Fatalf("got %d allowances and charges, want %d: %+v", len(inv.AllowancesCharges), len(want), inv.AllowancesCharges)
+	}
+	for i, ac := range inv.AllowancesCharges {
+		if ac != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, ac, want[i])
+		}
+	}
+}
+
+func TestCheckTotalsAllowancesCharges(t *testing.T) {
+	inv := parseTestdata(t, "allowance-charge.xml")
+
+	ok, stated, computed := CheckTotals(inv)
+	if !ok || stated != 113.05 || computed != 113.05 {
+		t.Errorf("CheckTotals = %v, %v, %v, want true, 113.05, 113.05", ok, stated, computed)
+	}
+
+	// The entries count, not the stated BT-107 and BT-108
+	inv.AllowanceTotal.Value, inv.ChargeTotal.Value = 0, 0
+	if ok, _, computed := CheckTotals(inv); !ok {
+		t.Errorf("CheckTotals with zero stated sums computed %v, want 113.05 from the entries", computed)
+	}
+
+	inv.AllowancesCharges = inv.AllowancesCharges[:1]
+	if ok, _, computed := CheckTotals(inv); ok || computed != 108.05 {
+		t.Errorf("CheckTotals without the charge = %v, %v, want false, 108.05", ok, computed)
+	}
+}
@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"fmt"
+)
+
+// Classify combines the detection heuristics into one call for XML that
+// did not come from a PDF. isZUGFeRD reports that data matches at least
+// one of the Indicators and has a CII or UBL root element; syntax, profile
+// and version are the results of DetectSyntax, DetectProfile and
+// DetectVersion, the version as "major.minor". What cannot be determined
+// is left empty, e.g. the version of an UBL document.
+func Classify(data []byte) (isZUGFeRD bool, syntax, profile, version string) {
+	v := &Validator{}
+	syntax, _ = v.DetectSyntax(data)
+	isZUGFeRD = syntax != "" && v.IsZUGFeRDXML(data)
+	profile, _ = v.DetectProfile(data)
+	if major, minor, err := v.DetectVersion(data); err == nil {
+		version = fmt.Sprintf("%d.%d", major, minor)
+	}
+	return isZUGFeRD, syntax, profile, version
+}
@@ -0,0 +1,114 @@
+package validation
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Encodings reported by DetectEncoding
+const (
+	EncodingASCII = "US-ASCII"
+	EncodingUTF8  = "UTF-8"
+	EncodingUTF16 = "UTF-16"
+	// Encoding8Bit is content that is not valid UTF-8, e.g. ISO-8859-1 or
+	// Windows-1252; the two cannot be told apart from the bytes
+	Encoding8Bit = "8-Bit"
+)
+
+// UTF8BOM is the byte order mark some generators put before the XML
+// declaration
+var UTF8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// xmlDeclEncoding matches the encoding pseudo-attribute of the XML
+// declaration
+var xmlDeclEncoding = regexp.MustCompile(`^<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// EncodingInfo compares the encoding declared by an XML document with the
+// encoding of its bytes
+type EncodingInfo struct {
+	// Declared is the encoding of the XML declaration, empty if there is
+	// none, which means UTF-8
+	Declared string
+	// Detected is one of the Encoding constants
+	Detected string
+	// BOM reports a leading UTF-8 byte order mark
+	BOM bool
+}
+
+// Mismatch reports whether the declaration contradicts the bytes, e.g. a
+// document declared as ISO-8859-1 that contains UTF-8 umlauts, or one
+// declared as UTF-8 that is not valid UTF-8. ASCII content matches every
+// ASCII compatible declaration.
+func (e EncodingInfo) Mismatch() bool {
+	declared := normalizeEncoding(e.Declared)
+	if e.Detected == EncodingUTF16 {
+		return e.Declared != "" && !strings.HasPrefix(declared, "UTF16")
+	}
+	if strings.HasPrefix(declared, "UTF16") || strings.HasPrefix(declared, "UTF32") {
+		return true
+	}
+	if e.BOM && declared != "UTF8" {
+		return true
+	}
+
+	switch e.Detected {
+	case EncodingUTF8:
+		return declared != "UTF8"
+	case Encoding8Bit:
+		return declared == "UTF8"
+	}
+	return false
+}
+
+// normalizeEncoding upper-cases an encoding name and removes separators,
+// so that utf-8 and UTF8 compare equal; no declaration means UTF-8
+func normalizeEncoding(name string) string {
+	if name == "" {
+		return "UTF8"
+	}
+	name = strings.ToUpper(name)
+	return strings.NewReplacer("-", "", "_", "", " ", "").Replace(name)
+}
+
+// DetectEncoding reads the encoding declaration and determines the
+// encoding of the bytes: UTF-16 by its byte order mark or the zero bytes
+// of "<?", otherwise UTF-8 if the bytes are valid UTF-8 with characters
+// beyond ASCII, US-ASCII without them and 8-Bit if they are not UTF-8
+func (v *Validator) DetectEncoding(data []byte) EncodingInfo {
+	var info EncodingInfo
+	if bytes.HasPrefix(data, []byte{0xFE, 0xFF}) || bytes.HasPrefix(data, []byte{0xFF, 0xFE}) ||
+		bytes.HasPrefix(data, []byte{0x00, '<', 0x00, '?'}) || bytes.HasPrefix(data, []byte{'<', 0x00, '?', 0x00}) {
+		info.Detected = EncodingUTF16
+		return info
+	}
+
+	if bytes.HasPrefix(data, UTF8BOM) {
+		info.BOM = true
+		data = data[len(UTF8BOM):]
+	}
+	if match := xmlDeclEncoding.FindSubmatch(data); match != nil {
+		info.Declared = string(match[1])
+	}
+
+	switch {
+	case !utf8.Valid(data):
+		info.Detected = Encoding8Bit
+	case isASCII(data):
+		info.Detected = EncodingASCII
+	default:
+		info.Detected = EncodingUTF8
+	}
+	return info
+}
+
+// isASCII reports whether data contains only 7-bit characters
+func isASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
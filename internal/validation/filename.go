@@ -0,0 +1,45 @@
+package validation
+
+// Attachment names the ZUGFeRD versions prescribe for the XML
+const (
+	FilenameZUGFeRD1  = "ZUGFeRD-invoice.xml"
+	FilenameZUGFeRD20 = "zugferd-invoice.xml"
+	FilenameFacturX   = "factur-x.xml"
+	FilenameXRechnung = "xrechnung.xml"
+)
+
+// CheckFilenameConsistency reports whether filename is the attachment name
+// prescribed for the version of data and returns the prescribed name:
+// ZUGFeRD-invoice.xml for 1.0, zugferd-invoice.xml for 2.0, factur-x.xml
+// from 2.1 on and xrechnung.xml for the XRECHNUNG profile. Names are
+// compared exactly. A document whose version cannot be determined, such as
+// a UBL invoice, is accepted with an empty expected name.
+func (v *Validator) CheckFilenameConsistency(filename string, data []byte) (bool, string) {
+	expected := v.expectedFilename(data)
+	if expected == "" {
+		return true, ""
+	}
+	return filename == expected, expected
+}
+
+// expectedFilename returns the prescribed attachment name for data, or ""
+// if there is none
+func (v *Validator) expectedFilename(data []byte) string {
+	if syntax, err := v.DetectSyntax(data); err != nil || syntax != SyntaxCII {
+		return ""
+	}
+	if profile, err := v.DetectProfile(data); err == nil && profile == ProfileXRechnung {
+		return FilenameXRechnung
+	}
+
+	major, minor, err := v.DetectVersion(data)
+	switch {
+	case err != nil:
+		return ""
+	case major == 1:
+		return FilenameZUGFeRD1
+	case major == 2 && minor == 0:
+		return FilenameZUGFeRD20
+	}
+	return FilenameFacturX
+}
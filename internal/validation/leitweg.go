@@ -0,0 +1,42 @@
+package validation
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// leitwegIDPattern is the structure of a Leitweg-ID: the coarse address
+// (Grobadressierung, 2 to 12 digits), an optional fine address
+// (Feinadressierung, up to 30 letters and digits) and two check digits
+var leitwegIDPattern = regexp.MustCompile(`^([0-9]{2,12})(?:-([0-9A-Z]{1,30}))?-([0-9]{2})$`)
+
+// ValidateLeitwegID checks the structure and the check digits of a
+// Leitweg-ID, the routing ID of German public-sector buyers carried in the
+// buyer reference (BT-10). The check digits follow ISO/IEC 7064 MOD 97-10
+// like an IBAN: with letters replaced by 10 to 35, the digits of all parts
+// read as one number must leave a remainder of 1 when divided by 97.
+// Letters are compared case-insensitively.
+func (v *Validator) ValidateLeitwegID(id string) error {
+	normalized := strings.ToUpper(strings.TrimSpace(id))
+	if !leitwegIDPattern.MatchString(normalized) {
+		return i18n.Errorf(v.Lang, "Leitweg-ID %s hat nicht das Format Grobadressierung-Feinadressierung-Prüfziffer, z.B. 04011000-1234512345-06", id)
+	}
+
+	var digits strings.Builder
+	for _, r := range strings.ReplaceAll(normalized, "-", "") {
+		if r >= 'A' && r <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		} else {
+			digits.WriteRune(r)
+		}
+	}
+	number, _ := new(big.Int).SetString(digits.String(), 10)
+	if new(big.Int).Mod(number, big.NewInt(97)).Int64() != 1 {
+		return i18n.Errorf(v.Lang, "Leitweg-ID %s hat eine ungültige Prüfziffer", id)
+	}
+	return nil
+}
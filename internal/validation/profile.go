@@ -0,0 +1,138 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// Normalized ZUGFeRD/Factur-X conformance profiles
+const (
+	ProfileMinimum   = "MINIMUM"
+	ProfileBasicWL   = "BASIC WL"
+	ProfileBasic     = "BASIC"
+	ProfileComfort   = "COMFORT" // ZUGFeRD 1.0
+	ProfileEN16931   = "EN16931"
+	ProfileExtended  = "EXTENDED"
+	ProfileXRechnung = "XRECHNUNG"
+)
+
+// DetectProfile reads the guideline ID from the document context and returns
+// the normalized conformance profile
+func (v *Validator) DetectProfile(data []byte) (string, error) {
+	guideline, err := v.GuidelineID(data)
+	if err != nil {
+		return "", err
+	}
+
+	profile := profileFromGuideline(guideline)
+	if profile == "" {
+		return "", i18n.Errorf(v.Lang, "unbekannter Profil-Bezeichner: %s", guideline)
+	}
+	return profile, nil
+}
+
+// GuidelineID returns the content of GuidelineSpecifiedDocumentContextParameter/ID,
+// or of CustomizationID for UBL documents
+func (v *Validator) GuidelineID(data []byte) (string, error) {
+	path := []string{"GuidelineSpecifiedDocumentContextParameter", "ID"}
+	if root, err := rootElement(data, v.Lang); err == nil && isUBLRoot(root) {
+		path = []string{root.Local, "CustomizationID"}
+	}
+
+	id, found, err := findElementText(data, path...)
+	if err != nil {
+		return "", i18n.Errorf(v.Lang, "XML konnte nicht gelesen werden: %v", err)
+	}
+	if !found || id == "" {
+		return "", i18n.Errorf(v.Lang, "kein Profil-Bezeichner (%s) gefunden", strings.Join(path, "/"))
+	}
+	return id, nil
+}
+
+// profileFromGuideline maps a guideline URN to a profile constant. The most
+// specific part of the URN comes last, so extensions are matched first.
+func profileFromGuideline(guideline string) string {
+	urn := strings.ToLower(guideline)
+
+	switch {
+	case strings.Contains(urn, "xrechnung"):
+		return ProfileXRechnung
+	case strings.Contains(urn, "extended"):
+		return ProfileExtended
+	case strings.Contains(urn, "basicwl"):
+		return ProfileBasicWL
+	case strings.Contains(urn, "basic"):
+		return ProfileBasic
+	case strings.Contains(urn, "minimum"):
+		return ProfileMinimum
+	case strings.Contains(urn, "comfort"):
+		return ProfileComfort
+	case strings.Contains(urn, "urn:cen.eu:en16931"):
+		return ProfileEN16931
+	}
+
+	return ""
+}
+
+// findElementText returns the character data of the first element whose
+// local names end with the given path. Namespace prefixes are ignored.
+func findElementText(data []byte, path ...string) (string, bool, error) {
+	decoder := newDecoder(data)
+
+	var stack []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if hasPathSuffix(stack, path) {
+				var text string
+				if err := decoder.DecodeElement(&text, &t); err != nil {
+					return "", false, err
+				}
+				return strings.TrimSpace(text), true, nil
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+// newDecoder creates a lenient XML decoder that accepts any declared encoding.
+// Element names and URNs are ASCII, so reading non-UTF-8 input as-is is fine
+// for detection purposes.
+func newDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
+
+// hasPathSuffix reports whether stack ends with path
+func hasPathSuffix(stack, path []string) bool {
+	if len(path) > len(stack) {
+		return false
+	}
+	offset := len(stack) - len(path)
+	for i, name := range path {
+		if stack[offset+i] != name {
+			return false
+		}
+	}
+	return true
+}
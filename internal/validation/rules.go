@@ -0,0 +1,350 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// Severities of business rule violations
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// RuleViolation describes a failed EN16931 business rule
+type RuleViolation struct {
+	RuleID   string `json:"ruleId"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (r RuleViolation) String() string {
+	return fmt.Sprintf("[%s] %s: %s", r.RuleID, r.Severity, r.Message)
+}
+
+// amountTolerance is the accepted rounding difference between amounts
+const amountTolerance = 0.005
+
+// ruleAmount is a monetary amount with its optional currency attribute
+type ruleAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"currencyID,attr"`
+}
+
+// ruleSummation holds the document totals (BG-22)
+type ruleSummation struct {
+	LineTotal      []ruleAmount `xml:"LineTotalAmount"`
+	ChargeTotal    []ruleAmount `xml:"ChargeTotalAmount"`
+	AllowanceTotal []ruleAmount `xml:"AllowanceTotalAmount"`
+	TaxBasisTotal  []ruleAmount `xml:"TaxBasisTotalAmount"`
+	TaxTotal       []ruleAmount `xml:"TaxTotalAmount"`
+	Rounding       []ruleAmount `xml:"RoundingAmount"`
+	GrandTotal     []ruleAmount `xml:"GrandTotalAmount"`
+	TotalPrepaid   []ruleAmount `xml:"TotalPrepaidAmount"`
+	DuePayable     []ruleAmount `xml:"DuePayableAmount"`
+}
+
+// ruleTax is one VAT breakdown entry (BG-23)
+type ruleTax struct {
+	Calculated []ruleAmount `xml:"CalculatedAmount"`
+	Basis      []ruleAmount `xml:"BasisAmount"`
+	Category   string       `xml:"CategoryCode"`
+	Rate       string       `xml:"RateApplicablePercent"`
+	LegacyRate string       `xml:"ApplicablePercent"`
+}
+
+// ruleAllowanceCharge is a document level allowance or charge (BG-20/BG-21)
+type ruleAllowanceCharge struct {
+	ChargeIndicator string       `xml:"ChargeIndicator>Indicator"`
+	Actual          []ruleAmount `xml:"ActualAmount"`
+}
+
+// ruleSettlement covers the header settlement of ZUGFeRD 2.x and 1.0
+type ruleSettlement struct {
+	Currency         string                `xml:"InvoiceCurrencyCode"`
+	Taxes            []ruleTax             `xml:"ApplicableTradeTax"`
+	AllowanceCharges []ruleAllowanceCharge `xml:"SpecifiedTradeAllowanceCharge"`
+	Summation        *ruleSummation        `xml:"SpecifiedTradeSettlementHeaderMonetarySummation"`
+	LegacySummation  *ruleSummation        `xml:"SpecifiedTradeSettlementMonetarySummation"`
+}
+
+// ruleLine is a single invoice line
+type ruleLine struct {
+	Summation       *ruleSummation `xml:"SpecifiedLineTradeSettlement>SpecifiedTradeSettlementLineMonetarySummation"`
+	LegacySummation *ruleSummation `xml:"SpecifiedSupplyChainTradeSettlement>SpecifiedTradeSettlementMonetarySummation"`
+}
+
+// ruleAgreement is the header trade agreement
+type ruleAgreement struct {
+	BuyerReference string `xml:"BuyerReference"`
+}
+
+// ruleTransaction covers SupplyChainTradeTransaction of ZUGFeRD 2.x and 1.0
+type ruleTransaction struct {
+	Lines            []ruleLine      `xml:"IncludedSupplyChainTradeLineItem"`
+	Agreement        *ruleAgreement  `xml:"ApplicableHeaderTradeAgreement"`
+	LegacyAgreement  *ruleAgreement  `xml:"ApplicableSupplyChainTradeAgreement"`
+	Settlement       *ruleSettlement `xml:"ApplicableHeaderTradeSettlement"`
+	LegacySettlement *ruleSettlement `xml:"ApplicableSupplyChainTradeSettlement"`
+}
+
+// ruleDocument is the subset of a CII document needed for the rule checks
+type ruleDocument struct {
+	Guideline         string           `xml:"ExchangedDocumentContext>GuidelineSpecifiedDocumentContextParameter>ID"`
+	LegacyGuideline   string           `xml:"SpecifiedExchangedDocumentContext>GuidelineSpecifiedDocumentContextParameter>ID"`
+	ID                string           `xml:"ExchangedDocument>ID"`
+	LegacyID          string           `xml:"HeaderExchangedDocument>ID"`
+	TypeCode          string           `xml:"ExchangedDocument>TypeCode"`
+	LegacyTypeCode    string           `xml:"HeaderExchangedDocument>TypeCode"`
+	IssueDate         string           `xml:"ExchangedDocument>IssueDateTime>DateTimeString"`
+	LegacyIssueDate   string           `xml:"HeaderExchangedDocument>IssueDateTime>DateTimeString"`
+	Transaction       *ruleTransaction `xml:"SupplyChainTradeTransaction"`
+	LegacyTransaction *ruleTransaction `xml:"SpecifiedSupplyChainTradeTransaction"`
+}
+
+// ValidateBusinessRules checks a subset of the EN16931 business rules,
+// mainly the mandatory header fields and the arithmetic consistency of
+// the document totals (BR-CO-10 to BR-CO-16). XRechnung documents without
+// a buyer reference get a BR-DE-15 warning; with CheckLeitwegID a buyer
+// reference that is no valid Leitweg-ID is reported as well.
+func (v *Validator) ValidateBusinessRules(data []byte) ([]RuleViolation, error) {
+	if syntax, err := v.DetectSyntax(data); err == nil && syntax == SyntaxUBL {
+		return nil, i18n.Errorf(v.Lang, "Geschäftsregeln können nur für CII-Dokumente geprüft werden")
+	}
+
+	var doc ruleDocument
+	decoder := newDecoder(data)
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, i18n.Errorf(v.Lang, "XML konnte nicht gelesen werden: %v", err)
+	}
+
+	var violations []RuleViolation
+	add := func(ruleID, severity, format string, args ...any) {
+		violations = append(violations, RuleViolation{
+			RuleID:   ruleID,
+			Severity: severity,
+			Message:  i18n.Sprintf(v.Lang, format, args...),
+		})
+	}
+
+	if firstNonEmpty(doc.Guideline, doc.LegacyGuideline) == "" {
+		add("BR-01", SeverityError, "Spezifikationskennung (BT-24) fehlt")
+	}
+	if firstNonEmpty(doc.ID, doc.LegacyID) == "" {
+		add("BR-02", SeverityError, "Rechnungsnummer (BT-1) fehlt")
+	}
+	if firstNonEmpty(doc.IssueDate, doc.LegacyIssueDate) == "" {
+		add("BR-03", SeverityError, "Rechnungsdatum (BT-2) fehlt")
+	}
+	if firstNonEmpty(doc.TypeCode, doc.LegacyTypeCode) == "" {
+		add("BR-04", SeverityError, "Rechnungstyp (BT-3) fehlt")
+	}
+
+	transaction := doc.Transaction
+	if transaction == nil {
+		transaction = doc.LegacyTransaction
+	}
+	if transaction == nil {
+		add("BR-CO-00", SeverityError, "Handelstransaktion (SupplyChainTradeTransaction) fehlt")
+		return violations, nil
+	}
+
+	// BR-DE-15: XRechnung requires the buyer reference for the routing
+	if profileFromGuideline(firstNonEmpty(doc.Guideline, doc.LegacyGuideline)) == ProfileXRechnung {
+		agreement := transaction.Agreement
+		if agreement == nil {
+			agreement = transaction.LegacyAgreement
+		}
+		var buyerReference string
+		if agreement != nil {
+			buyerReference = strings.TrimSpace(agreement.BuyerReference)
+		}
+		if buyerReference == "" {
+			add("BR-DE-15", SeverityWarning, "Käuferreferenz (BT-10, Leitweg-ID) fehlt, sie ist für XRechnung Pflicht")
+		} else if v.CheckLeitwegID {
+			if err := v.ValidateLeitwegID(buyerReference); err != nil {
+				add("LEITWEG-ID", SeverityWarning, "%v", err)
+			}
+		}
+	}
+
+	settlement := transaction.Settlement
+	if settlement == nil {
+		settlement = transaction.LegacySettlement
+	}
+	if settlement == nil {
+		add("BR-CO-00", SeverityError, "Zahlungsinformationen (ApplicableHeaderTradeSettlement) fehlen")
+		return violations, nil
+	}
+
+	if settlement.Currency == "" {
+		add("BR-05", SeverityError, "Rechnungswährung (BT-5) fehlt")
+	}
+
+	summation := settlement.Summation
+	if summation == nil {
+		summation = settlement.LegacySummation
+	}
+	if summation == nil {
+		add("BR-CO-00", SeverityError, "Rechnungssummen (BG-22) fehlen")
+		return violations, nil
+	}
+
+	currency := settlement.Currency
+	lineTotal, hasLineTotal := amountValue(summation.LineTotal, currency)
+	chargeTotal, _ := amountValue(summation.ChargeTotal, currency)
+	allowanceTotal, _ := amountValue(summation.AllowanceTotal, currency)
+	taxBasisTotal, hasTaxBasisTotal := amountValue(summation.TaxBasisTotal, currency)
+	taxTotal, hasTaxTotal := amountValue(summation.TaxTotal, currency)
+	rounding, _ := amountValue(summation.Rounding, currency)
+	grandTotal, hasGrandTotal := amountValue(summation.GrandTotal, currency)
+	prepaid, _ := amountValue(summation.TotalPrepaid, currency)
+	duePayable, hasDuePayable := amountValue(summation.DuePayable, currency)
+
+	if !hasGrandTotal {
+		add("BR-14", SeverityError, "Gesamtbetrag einschließlich Umsatzsteuer (BT-112) fehlt")
+	}
+	if !hasDuePayable {
+		add("BR-15", SeverityError, "Fälliger Zahlungsbetrag (BT-115) fehlt")
+	}
+
+	// BR-CO-10: sum of line net amounts
+	if hasLineTotal && len(transaction.Lines) > 0 {
+		var sum float64
+		for _, line := range transaction.Lines {
+			lineSummation := line.Summation
+			if lineSummation == nil {
+				lineSummation = line.LegacySummation
+			}
+			if lineSummation != nil {
+				amount, _ := amountValue(lineSummation.LineTotal, currency)
+				sum += amount
+			}
+		}
+		if !amountsEqual(lineTotal, sum) {
+			add("BR-CO-10", SeverityError, "Summe der Positionsnettobeträge (BT-106) %.2f entspricht nicht der Summe der Positionen %.2f", lineTotal, sum)
+		}
+	}
+
+	// BR-CO-11/BR-CO-12: document level allowances and charges
+	var allowanceSum, chargeSum float64
+	for _, ac := range settlement.AllowanceCharges {
+		amount, _ := amountValue(ac.Actual, currency)
+		if strings.TrimSpace(ac.ChargeIndicator) == "true" {
+			chargeSum += amount
+		} else {
+			allowanceSum += amount
+		}
+	}
+	if len(settlement.AllowanceCharges) > 0 {
+		if !amountsEqual(allowanceTotal, allowanceSum) {
+			add("BR-CO-11", SeverityError, "Summe der Nachlässe (BT-107) %.2f entspricht nicht der Summe der Nachlässe auf Dokumentebene %.2f", allowanceTotal, allowanceSum)
+		}
+		if !amountsEqual(chargeTotal, chargeSum) {
+			add("BR-CO-12", SeverityError, "Summe der Zuschläge (BT-108) %.2f entspricht nicht der Summe der Zuschläge auf Dokumentebene %.2f", chargeTotal, chargeSum)
+		}
+	}
+
+	// BR-CO-13: total without VAT
+	if hasLineTotal && hasTaxBasisTotal {
+		expected := lineTotal - allowanceTotal + chargeTotal
+		if !amountsEqual(taxBasisTotal, expected) {
+			add("BR-CO-13", SeverityError, "Gesamtbetrag ohne Umsatzsteuer (BT-109) %.2f entspricht nicht BT-106 - BT-107 + BT-108 = %.2f", taxBasisTotal, expected)
+		}
+	}
+
+	// BR-CO-14: total VAT equals the sum of the VAT breakdown
+	if hasTaxTotal && len(settlement.Taxes) > 0 {
+		var sum float64
+		for _, tax := range settlement.Taxes {
+			amount, _ := amountValue(tax.Calculated, currency)
+			sum += amount
+		}
+		if !amountsEqual(taxTotal, sum) {
+			add("BR-CO-14", SeverityError, "Umsatzsteuergesamtbetrag (BT-110) %.2f entspricht nicht der Summe der Steuerbeträge %.2f", taxTotal, sum)
+		}
+	}
+
+	// BR-CO-15: total with VAT
+	if hasGrandTotal && hasTaxBasisTotal {
+		expected := taxBasisTotal + taxTotal
+		if !amountsEqual(grandTotal, expected) {
+			add("BR-CO-15", SeverityError, "Gesamtbetrag einschließlich Umsatzsteuer (BT-112) %.2f entspricht nicht BT-109 + BT-110 = %.2f", grandTotal, expected)
+		}
+	}
+
+	// BR-CO-16: amount due for payment
+	if hasGrandTotal && hasDuePayable {
+		expected := grandTotal - prepaid + rounding
+		if !amountsEqual(duePayable, expected) {
+			add("BR-CO-16", SeverityError, "Fälliger Zahlungsbetrag (BT-115) %.2f entspricht nicht BT-112 - BT-113 + BT-114 = %.2f", duePayable, expected)
+		}
+	}
+
+	// BR-CO-17: VAT amount per category, rounding differences are common
+	for _, tax := range settlement.Taxes {
+		calculated, hasCalculated := amountValue(tax.Calculated, currency)
+		basis, hasBasis := amountValue(tax.Basis, currency)
+		rate, err := strconv.ParseFloat(strings.TrimSpace(firstNonEmpty(tax.Rate, tax.LegacyRate)), 64)
+		if !hasCalculated || !hasBasis || err != nil {
+			continue
+		}
+		expected := math.Round(basis*rate) / 100
+		if !amountsEqual(calculated, expected) {
+			add("BR-CO-17", SeverityWarning, "Steuerbetrag der Kategorie %s (%.2f %%) %.2f entspricht nicht Basis × Satz = %.2f", tax.Category, rate, calculated, expected)
+		}
+	}
+
+	return violations, nil
+}
+
+// HasErrors reports whether any violation has error severity
+func HasErrors(violations []RuleViolation) bool {
+	for _, violation := range violations {
+		if violation.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// amountValue returns the amount in the given currency, falling back to the
+// first amount when no currency matches
+func amountValue(amounts []ruleAmount, currency string) (float64, bool) {
+	if len(amounts) == 0 {
+		return 0, false
+	}
+
+	selected := amounts[0]
+	for _, amount := range amounts {
+		if amount.Currency == currency {
+			selected = amount
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(selected.Value), 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// amountsEqual compares two amounts with cent tolerance
+func amountsEqual(a, b float64) bool {
+	return math.Abs(a-b) < amountTolerance
+}
+
+// firstNonEmpty returns the first non-empty trimmed value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
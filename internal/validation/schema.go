@@ -0,0 +1,369 @@
+package validation
+
+import (
+	"bytes"
+	"embed"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+//go:embed schemas
+var embeddedSchemas embed.FS
+
+// schemaFiles maps the detected ZUGFeRD version to its bundled root schema
+// file; the bundled schemas are a simplified subset of the official ones
+var schemaFiles = map[string]string{
+	"1.0": "schemas/zugferd1/ZUGFeRD1p0.xsd",
+	"2.0": "schemas/cii/CrossIndustryInvoice.xsd",
+	"2.1": "schemas/cii/CrossIndustryInvoice.xsd",
+	"2.3": "schemas/cii/CrossIndustryInvoice.xsd",
+}
+
+// maxSchemaViolations limits the number of violations collected per document
+const maxSchemaViolations = 10
+
+// SchemaError lists the schema violations found in a document
+type SchemaError struct {
+	Schema     string
+	Violations []string
+
+	lang string
+}
+
+func (e *SchemaError) Error() string {
+	return i18n.Sprintf(e.lang, "XML entspricht nicht dem Schema %s:\n  - %s", e.Schema, strings.Join(e.Violations, "\n  - "))
+}
+
+// ValidateAgainstXSD checks the element structure of the XML against the
+// schema for its detected version. The schema is taken from Schemas if it
+// is set, otherwise it is compiled for this call from SchemaDir or, if that
+// is empty, from the bundled schemas. A *SchemaError is returned when the
+// document violates the schema.
+//
+// The bundled schemas are simplified: they only declare the elements every
+// profile requires, not the official Factur-X/ZUGFeRD schema packages.
+// Even with the official schemas in SchemaDir only the element structure
+// is checked, see xsd.go, so a document that passes may still be rejected
+// by a full XSD validator.
+func (v *Validator) ValidateAgainstXSD(data []byte) error {
+	major, minor, err := v.DetectVersion(data)
+	if err != nil {
+		return i18n.Errorf(v.Lang, "Strukturprüfung nicht möglich, Version unbekannt: %v", err)
+	}
+
+	root, err := parseTree(bytes.NewReader(data), v.Lang)
+	if err != nil {
+		return i18n.Errorf(v.Lang, "XML ist nicht wohlgeformt: %v", err)
+	}
+	// Only a schema directory with several profiles needs the profile
+	profile, _ := v.DetectProfile(data)
+
+	schemas := v.Schemas
+	if schemas == nil {
+		schemas = &SchemaSet{Dir: v.SchemaDir}
+	}
+	compiled, err := schemas.schema(schemaTarget{
+		version: fmt.Sprintf("%d.%d", major, minor),
+		root:    root.name,
+		profile: profile,
+	}, v.Lang)
+	if err != nil {
+		return err
+	}
+
+	if violations := compiled.schema.validate(root, maxSchemaViolations, v.Lang); len(violations) > 0 {
+		return &SchemaError{Schema: compiled.file, Violations: violations, lang: v.Lang}
+	}
+	return nil
+}
+
+// SchemaSet compiles every root schema once and keeps it for all later
+// validations, e.g. of a batch run, see Validator.Schemas. It is safe for
+// concurrent use. The zero value uses the bundled schemas.
+type SchemaSet struct {
+	// Dir is a directory of XSD files used instead of the bundled schemas,
+	// see Validator.SchemaDir. The root schema of a version is looked up
+	// under the name of the bundled one; without such a file the directory
+	// is searched for the schema that declares the root element of the
+	// document.
+	Dir string
+
+	mu       sync.Mutex
+	compiled map[string]*compiledSchema
+	resolved map[schemaTarget]string
+
+	// roots lists the schema files of Dir with the global elements they
+	// declare; it is read on the first search, see findSchemaFile
+	rootsOnce sync.Once
+	roots     []schemaRoot
+	rootsErr  error
+}
+
+// schemaTarget is what selects the root schema for a document: its version,
+// its root element and, for a directory with one schema per profile, its
+// profile
+type schemaTarget struct {
+	version string
+	root    xml.Name
+	profile string
+}
+
+// compiledSchema is one compiled root schema, or the error that kept it
+// from being compiled, which is returned again on every use
+type compiledSchema struct {
+	once   sync.Once
+	file   string
+	schema *schema
+	err    error
+}
+
+// schemaRoot is a schema file of a schema directory with the global
+// elements it declares itself, qualified with its target namespace
+type schemaRoot struct {
+	file     string
+	elements []xml.Name
+}
+
+// schema returns the compiled root schema for target, compiling it on
+// first use; concurrent callers for the same schema wait for one
+// compilation, and documents that resolve to the same file share it
+func (set *SchemaSet) schema(target schemaTarget, lang string) (*compiledSchema, error) {
+	file, err := set.resolve(target, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	set.mu.Lock()
+	if set.compiled == nil {
+		set.compiled = make(map[string]*compiledSchema)
+	}
+	compiled, ok := set.compiled[file]
+	if !ok {
+		compiled = &compiledSchema{}
+		set.compiled[file] = compiled
+	}
+	set.mu.Unlock()
+
+	compiled.once.Do(func() {
+		compiled.file, compiled.schema, compiled.err = set.compile(file, lang)
+	})
+	return compiled, compiled.err
+}
+
+// resolve returns the root schema file for target, below Dir if it is set;
+// the result is kept, so the directory is searched once per target
+func (set *SchemaSet) resolve(target schemaTarget, lang string) (string, error) {
+	bundled, ok := schemaFiles[target.version]
+	if !ok {
+		return "", i18n.Errorf(lang, "kein Schema für Version %s vorhanden", target.version)
+	}
+	if set.Dir == "" {
+		return bundled, nil
+	}
+
+	set.mu.Lock()
+	file, ok := set.resolved[target]
+	set.mu.Unlock()
+	if ok {
+		return file, nil
+	}
+
+	file, err := set.findSchemaFile(os.DirFS(set.Dir), target, bundled, lang)
+	if err != nil {
+		return "", err
+	}
+	set.mu.Lock()
+	if set.resolved == nil {
+		set.resolved = make(map[schemaTarget]string)
+	}
+	set.resolved[target] = file
+	set.mu.Unlock()
+	return file, nil
+}
+
+// compile loads and compiles the root schema file and returns it with the
+// path it was read from
+func (set *SchemaSet) compile(file, lang string) (string, *schema, error) {
+	if set.Dir == "" {
+		s, err := loadSchema(embeddedSchemas, file, lang)
+		return file, s, err
+	}
+
+	s, err := loadSchema(os.DirFS(set.Dir), file, lang)
+	if err != nil {
+		return "", nil, i18n.Errorf(lang, "Schema aus %s konnte nicht geladen werden: %v", set.Dir, err)
+	}
+	return filepath.Join(set.Dir, filepath.FromSlash(file)), s, nil
+}
+
+// findSchemaFile returns the root schema for target in a schema directory.
+// The fixed names are tried first: <version>/<name>, the path of the
+// bundled schema without the leading schemas/ (e.g.
+// cii/CrossIndustryInvoice.xsd) or <name> at the top level, where name is
+// the file name of the bundled root schema. Otherwise the directory is
+// searched for the schema that declares the root element of the document
+// in its namespace, so the official packages can be used as unpacked, e.g.
+// CrossIndustryInvoice_100pD16B.xsd or the Factur-X profile folders. Of
+// several such schemas the one whose path names the profile of the
+// document is taken.
+func (set *SchemaSet) findSchemaFile(fsys fs.FS, target schemaTarget, bundled, lang string) (string, error) {
+	name := path.Base(bundled)
+	candidates := []string{
+		path.Join(target.version, name),
+		strings.TrimPrefix(bundled, "schemas/"),
+		name,
+	}
+	for _, candidate := range candidates {
+		if info, err := fs.Stat(fsys, candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	set.rootsOnce.Do(func() {
+		set.roots, set.rootsErr = readSchemaRoots(fsys)
+	})
+	if set.rootsErr != nil {
+		return "", i18n.Errorf(lang, "Schemaverzeichnis %s konnte nicht durchsucht werden: %v", set.Dir, set.rootsErr)
+	}
+
+	var declaring []string
+	for _, root := range set.roots {
+		for _, element := range root.elements {
+			if element == target.root {
+				declaring = append(declaring, root.file)
+				break
+			}
+		}
+	}
+	switch len(declaring) {
+	case 0:
+		return "", i18n.Errorf(lang, "kein Schema für Version %s in %s gefunden, erwartet: %s oder ein Schema, das %s deklariert", target.version, set.Dir, strings.Join(candidates, ", "), formatName(target.root))
+	case 1:
+		return declaring[0], nil
+	}
+
+	// XRechnung documents fall back to the EN 16931 schema, as the
+	// Factur-X packages have no XRechnung schema of their own
+	profiles := []string{normalizeProfile(target.profile)}
+	if profiles[0] == "xrechnung" {
+		profiles = append(profiles, "en16931")
+	}
+	for _, profile := range profiles {
+		var matching []string
+		for _, file := range declaring {
+			if pathProfile(file) == profile {
+				matching = append(matching, file)
+			}
+		}
+		if len(matching) == 1 {
+			return matching[0], nil
+		}
+	}
+	return "", i18n.Errorf(lang, "mehrere Schemas in %s deklarieren %s, keines eindeutig für das Profil %s: %s", set.Dir, formatName(target.root), target.profile, strings.Join(declaring, ", "))
+}
+
+// readSchemaRoots reads the target namespace and the global element
+// declarations of every .xsd file below the root of fsys, in lexical order.
+// Files that are no schema are left out, a directory that cannot be read
+// is an error.
+func readSchemaRoots(fsys fs.FS) ([]schemaRoot, error) {
+	var roots []schemaRoot
+	err := fs.WalkDir(fsys, ".", func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.EqualFold(path.Ext(file), ".xsd") {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		if elements := globalElements(data); len(elements) > 0 {
+			roots = append(roots, schemaRoot{file: file, elements: elements})
+		}
+		return nil
+	})
+	return roots, err
+}
+
+// globalElements returns the elements a schema document declares at its
+// top level, qualified with its target namespace, or nil if data is no
+// schema
+func globalElements(data []byte) []xml.Name {
+	decoder := newDecoder(data)
+	var namespace string
+	var elements []xml.Name
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return elements
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1:
+				if t.Name.Space != xsdNamespace || t.Name.Local != "schema" {
+					return nil
+				}
+				for _, a := range t.Attr {
+					if a.Name.Space == "" && a.Name.Local == "targetNamespace" {
+						namespace = a.Value
+					}
+				}
+			case depth == 2 && t.Name.Space == xsdNamespace && t.Name.Local == "element":
+				for _, a := range t.Attr {
+					if a.Name.Space == "" && a.Name.Local == "name" {
+						elements = append(elements, xml.Name{Space: namespace, Local: a.Value})
+					}
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// knownProfiles are the profiles that name the schema folders and files
+// of the official packages, longer names before their prefixes
+var knownProfiles = [][]string{
+	{"basic", "wl"}, {"basicwl"}, {"basic"}, {"minimum"}, {"comfort"},
+	{"en16931"}, {"extended"}, {"xrechnung"},
+}
+
+// pathProfile returns the profile named by the folders or the file name of
+// a schema path, normalized like normalizeProfile, e.g. basic wl for
+// Factur-X_1.0.07_BASICWL/x.xsd or BASIC-WL/x.xsd, or an empty string
+func pathProfile(file string) string {
+	tokens := strings.FieldsFunc(strings.ToLower(file), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	for i := range tokens {
+		for _, profile := range knownProfiles {
+			if i+len(profile) <= len(tokens) && slices.Equal(tokens[i:i+len(profile)], profile) {
+				return normalizeProfile(strings.Join(profile, " "))
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeProfile returns a profile constant or a profile named in a path
+// in lower case, with BASICWL spelled like ProfileBasicWL
+func normalizeProfile(profile string) string {
+	if profile = strings.ToLower(profile); profile == "basicwl" {
+		return "basic wl"
+	}
+	return profile
+}
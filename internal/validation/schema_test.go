@@ -0,0 +1,177 @@
+package validation
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestValidateAgainstXSD(t *testing.T) {
+	v := &Validator{}
+	if err := v.ValidateAgainstXSD(readTestdata(t, "cii-invoice.xml")); err != nil {
+		t.Errorf("ValidateAgainstXSD: %v", err)
+	}
+
+	err := v.ValidateAgainstXSD([]byte(ciiInvoice))
+	if _, ok := err.(*SchemaError); !ok {
+		t.Errorf("ValidateAgainstXSD without ExchangedDocument = %v, want a *SchemaError", err)
+	}
+}
+
+var ciiRoot = xml.Name{Space: NamespaceCII, Local: "CrossIndustryInvoice"}
+
+func TestSchemaSetCompilesOnce(t *testing.T) {
+	set := &SchemaSet{}
+	compiled := make([]*compiledSchema, 16)
+
+	var wg sync.WaitGroup
+	for i := range compiled {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if compiled[i], err = set.schema(schemaTarget{version: "2.1", root: ciiRoot}, ""); err != nil {
+				t.Errorf("schema: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, c := range compiled {
+		if c != compiled[0] {
+			t.Fatalf("call %d got a different compiled schema", i)
+		}
+	}
+	if c, _ := set.schema(schemaTarget{version: "2.3", root: ciiRoot}, ""); c != compiled[0] {
+		t.Error("versions 2.1 and 2.3 compile the same bundled schema twice")
+	}
+	legacyRoot := xml.Name{Space: NamespaceZUGFeRD1, Local: "CrossIndustryDocument"}
+	if c, _ := set.schema(schemaTarget{version: "1.0", root: legacyRoot}, ""); c == compiled[0] {
+		t.Error("versions 1.0 and 2.1 share one compiled schema")
+	}
+}
+
+// writeSchemaDir copies the bundled CII schemas to the given root schema
+// paths below a temporary directory, each with the schema it imports
+func writeSchemaDir(t *testing.T, files ...string) string {
+	t.Helper()
+	root, err := embeddedSchemas.ReadFile("schemas/cii/CrossIndustryInvoice.xsd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := embeddedSchemas.ReadFile("schemas/cii/ReusableAggregateBusinessInformationEntity.xsd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for _, file := range files {
+		file = filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, root, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(filepath.Dir(file), "ReusableAggregateBusinessInformationEntity.xsd"), imported, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSchemaDirOfficialRootName(t *testing.T) {
+	dir := writeSchemaDir(t, "D16B/CrossIndustryInvoice_100pD16B.xsd")
+	v := &Validator{SchemaDir: dir}
+	if err := v.ValidateAgainstXSD(readTestdata(t, "cii-invoice.xml")); err != nil {
+		t.Errorf("ValidateAgainstXSD: %v", err)
+	}
+
+	err := v.ValidateAgainstXSD([]byte(ciiInvoice))
+	schemaErr, ok := err.(*SchemaError)
+	if !ok {
+		t.Fatalf("ValidateAgainstXSD without ExchangedDocument = %v, want a *SchemaError", err)
+	}
+	if want := filepath.Join(dir, "D16B", "CrossIndustryInvoice_100pD16B.xsd"); schemaErr.Schema != want {
+		t.Errorf("SchemaError.Schema = %s, want %s", schemaErr.Schema, want)
+	}
+}
+
+func TestSchemaDirProfileFolders(t *testing.T) {
+	dir := writeSchemaDir(t,
+		"Factur-X_1.0.07_BASIC/Factur-X_1.0.07_BASIC.xsd",
+		"Factur-X_1.0.07_BASICWL/Factur-X_1.0.07_BASICWL.xsd",
+		"Factur-X_1.0.07_EN16931/Factur-X_1.0.07_EN16931.xsd",
+		"Factur-X_1.0.07_EXTENDED/Factur-X_1.0.07_EXTENDED.xsd",
+	)
+	tests := []struct {
+		profile string
+		want    string
+	}{
+		{ProfileBasic, "Factur-X_1.0.07_BASIC/Factur-X_1.0.07_BASIC.xsd"},
+		{ProfileBasicWL, "Factur-X_1.0.07_BASICWL/Factur-X_1.0.07_BASICWL.xsd"},
+		{ProfileEN16931, "Factur-X_1.0.07_EN16931/Factur-X_1.0.07_EN16931.xsd"},
+		{ProfileExtended, "Factur-X_1.0.07_EXTENDED/Factur-X_1.0.07_EXTENDED.xsd"},
+		{ProfileXRechnung, "Factur-X_1.0.07_EN16931/Factur-X_1.0.07_EN16931.xsd"},
+	}
+
+	set := &SchemaSet{Dir: dir}
+	for _, tt := range tests {
+		t.Run(tt.profile, func(t *testing.T) {
+			file, err := set.resolve(schemaTarget{version: "2.1", root: ciiRoot, profile: tt.profile}, "")
+			if err != nil {
+				t.Fatalf("resolve: %v", err)
+			}
+			if file != tt.want {
+				t.Errorf("resolve = %s, want %s", file, tt.want)
+			}
+		})
+	}
+
+	if file, err := set.resolve(schemaTarget{version: "2.1", root: ciiRoot, profile: ProfileMinimum}, ""); err == nil {
+		t.Errorf("resolve for MINIMUM = %s, want an error naming the candidates", file)
+	}
+}
+
+func TestSchemaDirMissingRootSchema(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "other.xsd"), []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:example"><xs:element name="Order"/></xs:schema>`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := (&Validator{SchemaDir: dir}).ValidateAgainstXSD(readTestdata(t, "cii-invoice.xml"))
+	if err == nil || !strings.Contains(err.Error(), "CrossIndustryInvoice.xsd") {
+		t.Errorf("ValidateAgainstXSD = %v, want an error naming the expected schema", err)
+	}
+}
+
+// BenchmarkValidateAgainstXSDPerFile compiles the schema for every
+// document, as a validator without Schemas does
+func BenchmarkValidateAgainstXSDPerFile(b *testing.B) {
+	data := readTestdata(b, "cii-invoice.xml")
+	v := &Validator{}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := v.ValidateAgainstXSD(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkValidateAgainstXSDCached shares one SchemaSet across all
+// documents, as a batch run does
+func BenchmarkValidateAgainstXSDCached(b *testing.B) {
+	data := readTestdata(b, "cii-invoice.xml")
+	v := &Validator{Schemas: &SchemaSet{}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := v.ValidateAgainstXSD(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
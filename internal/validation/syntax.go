@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"encoding/xml"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// Syntaxes of electronic invoices. ZUGFeRD and Factur-X always use CII,
+// XRechnung may also be written in UBL.
+const (
+	SyntaxCII = "CII"
+	SyntaxUBL = "UBL"
+)
+
+// Namespace URIs of the UBL root elements
+const (
+	NamespaceUBLInvoice    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	NamespaceUBLCreditNote = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+)
+
+// DetectSyntax determines from the root element whether the document is a
+// CII or a UBL invoice
+func (v *Validator) DetectSyntax(data []byte) (string, error) {
+	root, err := rootElement(data, v.Lang)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case isUBLRoot(root):
+		return SyntaxUBL, nil
+	case root.Local == "CrossIndustryInvoice", root.Local == "CrossIndustryDocument":
+		return SyntaxCII, nil
+	}
+	return "", i18n.Errorf(v.Lang, "unbekanntes Wurzelelement: %s", root.Local)
+}
+
+// isUBLRoot reports whether name is an UBL Invoice or CreditNote root.
+// Documents without namespace are accepted by their local name.
+func isUBLRoot(name xml.Name) bool {
+	switch name.Space {
+	case NamespaceUBLInvoice, NamespaceUBLCreditNote:
+		return true
+	case "":
+		return name.Local == "Invoice" || name.Local == "CreditNote"
+	}
+	return false
+}
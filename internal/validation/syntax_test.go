@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const ciiInvoice = `<?xml version="1.0" encoding="UTF-8"?>
+<rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100" xmlns:ram="urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100">
+  <rsm:ExchangedDocumentContext>
+    <ram:GuidelineSpecifiedDocumentContextParameter>
+      <ram:ID>urn:cen.eu:en16931:2017</ram:ID>
+    </ram:GuidelineSpecifiedDocumentContextParameter>
+  </rsm:ExchangedDocumentContext>
+</rsm:CrossIndustryInvoice>`
+
+// readTestdata returns the content of a file below testdata
+func readTestdata(t testing.TB, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDetectSyntax(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"UBL invoice", readTestdata(t, "ubl-invoice.xml"), SyntaxUBL},
+		{"UBL credit note", readTestdata(t, "ubl-creditnote.xml"), SyntaxUBL},
+		{"CII invoice", []byte(ciiInvoice), SyntaxCII},
+	}
+
+	v := &Validator{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.DetectSyntax(tt.data)
+			if err != nil {
+				t.Fatalf("DetectSyntax: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectSyntax = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectSyntaxUnknownRoot(t *testing.T) {
+	v := &Validator{}
+	if syntax, err := v.DetectSyntax([]byte(`<?xml version="1.0"?><Order xmlns="urn:example"/>`)); err == nil {
+		t.Fatalf("DetectSyntax = %s, want an error", syntax)
+	}
+}
+
+func TestValidateZUGFeRDXMLUBL(t *testing.T) {
+	v := &Validator{}
+	for _, name := range []string{"ubl-invoice.xml", "ubl-creditnote.xml"} {
+		t.Run(name, func(t *testing.T) {
+			data := readTestdata(t, name)
+			if !v.ValidateZUGFeRDXML(data) {
+				t.Error("ValidateZUGFeRDXML = false, want true")
+			}
+			profile, err := v.DetectProfile(data)
+			if err != nil {
+				t.Fatalf("DetectProfile: %v", err)
+			}
+			if profile != ProfileXRechnung {
+				t.Errorf("DetectProfile = %s, want %s", profile, ProfileXRechnung)
+			}
+		})
+	}
+}
+
+func TestValidateZUGFeRDXMLRejectsUBLWithoutNamespace(t *testing.T) {
+	v := &Validator{}
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?><Invoice><ID>1</ID></Invoice>`)
+	if v.ValidateZUGFeRDXML(data) {
+		t.Error("ValidateZUGFeRDXML = true for an Invoice root without UBL namespace")
+	}
+}
@@ -1,52 +1,92 @@
 package validation
 
 import (
-    "strings"
+	"strings"
 )
 
 // Validator is responsible for validating ZUGFeRD XML data
-type Validator struct{}
+type Validator struct {
+	// Lang selects the language of errors and rule violation messages,
+	// see i18n.Resolve
+	Lang string
+
+	// CheckLeitwegID makes ValidateBusinessRules check the format of the
+	// buyer reference of XRechnung documents, see ValidateLeitwegID
+	CheckLeitwegID bool
+
+	// SchemaDir is a directory of XSD files that ValidateAgainstXSD uses
+	// instead of the bundled schemas, e.g. patched or extended versions or
+	// the unpacked official packages, see SchemaSet.Dir
+	SchemaDir string
+
+	// Schemas caches the compiled schemas across validations; if set, it
+	// takes precedence over SchemaDir
+	Schemas *SchemaSet
+}
+
+// Indicators are the lower case strings whose presence marks an XML as a
+// ZUGFeRD, Factur-X or XRechnung document: root elements, namespaces,
+// specification URNs and the names of the standards
+var Indicators = []string{
+	"urn:oasis:names:specification:ubl:schema:xsd:invoice-2",
+	"urn:oasis:names:specification:ubl:schema:xsd:creditnote-2",
+	"crossindustrydocument",
+	"crossindustryinvoice",
+	"urn:ferd:",
+	"urn:cen.eu:en16931",
+	"zugferd",
+	"factur-x",
+	"xrechnung",
+	"rsm:crossindustrydocument",
+}
 
 // IsZUGFeRDXML checks if the XML data appears to be a ZUGFeRD document
 func (v *Validator) IsZUGFeRDXML(data []byte) bool {
-    if len(data) == 0 {
-        return false
-    }
-
-    content := string(data)
-    contentLower := strings.ToLower(content)
-
-    indicators := []string{
-        "crossindustrydocument",
-        "crossindustryinvoice",
-        "urn:ferd:",
-        "urn:cen.eu:en16931",
-        "zugferd",
-        "factur-x",
-        "xrechnung",
-        "rsm:crossindustrydocument",
-        "crossindustryinvoice",
-    }
-
-    for _, indicator := range indicators {
-        if strings.Contains(contentLower, strings.ToLower(indicator)) {
-            return true
-        }
-    }
-
-    return false
+	return v.Confidence(data) > 0
+}
+
+// MatchedIndicators returns the Indicators found in data, compared
+// case-insensitively, in the order of Indicators
+func (v *Validator) MatchedIndicators(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	contentLower := strings.ToLower(string(data))
+	var matched []string
+	for _, indicator := range Indicators {
+		if strings.Contains(contentLower, indicator) {
+			matched = append(matched, indicator)
+		}
+	}
+	return matched
+}
+
+// Confidence returns how many Indicators are found in data. An invoice
+// usually matches three or more, e.g. its root element, the EN16931 URN
+// and the name of its standard; an unrelated XML that merely mentions
+// "zugferd" matches one.
+func (v *Validator) Confidence(data []byte) int {
+	return len(v.MatchedIndicators(data))
 }
 
 // ValidateZUGFeRDXML performs additional validation on the XML content
 func (v *Validator) ValidateZUGFeRDXML(data []byte) bool {
-    content := string(data)
+	content := string(data)
+
+	hasXMLDecl := strings.Contains(content, "<?xml")
+	hasRootElement := strings.Contains(content, "CrossIndustryDocument") ||
+		strings.Contains(content, "CrossIndustryInvoice")
+	hasNamespace := strings.Contains(content, "xmlns:") &&
+		(strings.Contains(content, "urn:ferd:") ||
+			strings.Contains(content, "urn:cen.eu:en16931"))
 
-    hasXMLDecl := strings.Contains(content, "<?xml")
-    hasRootElement := strings.Contains(content, "CrossIndustryDocument") || 
-                     strings.Contains(content, "CrossIndustryInvoice")
-    hasNamespace := strings.Contains(content, "xmlns:") && 
-                   (strings.Contains(content, "urn:ferd:") || 
-                    strings.Contains(content, "urn:cen.eu:en16931"))
+	// UBL documents often declare the root namespace as default namespace
+	if syntax, err := v.DetectSyntax(data); err == nil && syntax == SyntaxUBL {
+		hasRootElement = true
+		hasNamespace = strings.Contains(content, NamespaceUBLInvoice) ||
+			strings.Contains(content, NamespaceUBLCreditNote)
+	}
 
-    return hasXMLDecl && hasRootElement && hasNamespace
-}
\ No newline at end of file
+	return hasXMLDecl && hasRootElement && hasNamespace
+}
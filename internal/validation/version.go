@@ -0,0 +1,72 @@
+package validation
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// Namespace URIs of the CII root elements
+const (
+	NamespaceZUGFeRD1 = "urn:ferd:CrossIndustryDocument:invoice:1p0"
+	NamespaceCII      = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+)
+
+// DetectVersion determines the ZUGFeRD version from the root namespace and the
+// guideline ID in the document context.
+//
+// ZUGFeRD 2.1 and later share the Factur-X 1.0 guideline URNs, so those
+// documents are reported as 2.1. XRechnung 3.x guidelines are only defined
+// from ZUGFeRD 2.3 on and are reported as such.
+func (v *Validator) DetectVersion(data []byte) (major, minor int, err error) {
+	root, err := rootElement(data, v.Lang)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case root.Local == "CrossIndustryDocument" || strings.EqualFold(root.Space, NamespaceZUGFeRD1):
+		return 1, 0, nil
+	case root.Local != "CrossIndustryInvoice":
+		return 0, 0, i18n.Errorf(v.Lang, "unbekanntes Wurzelelement: %s", root.Local)
+	}
+
+	guideline, err := v.GuidelineID(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	urn := strings.ToLower(guideline)
+
+	switch {
+	case strings.Contains(urn, "urn:zugferd.de:2p0"):
+		return 2, 0, nil
+	case strings.Contains(urn, "xrechnung_3"):
+		return 2, 3, nil
+	case strings.Contains(urn, "urn:factur-x.eu:1p0"),
+		strings.Contains(urn, "xrechnung"),
+		strings.HasPrefix(urn, "urn:cen.eu:en16931"):
+		// Factur-X 1.0 is technically identical to ZUGFeRD 2.1
+		return 2, 1, nil
+	}
+
+	return 0, 0, i18n.Errorf(v.Lang, "Version konnte nicht aus dem Profil-Bezeichner ermittelt werden: %s", guideline)
+}
+
+// rootElement returns the name of the document's root element
+func rootElement(data []byte, lang string) (xml.Name, error) {
+	decoder := newDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return xml.Name{}, i18n.Errorf(lang, "kein Wurzelelement gefunden")
+		}
+		if err != nil {
+			return xml.Name{}, i18n.Errorf(lang, "XML konnte nicht gelesen werden: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// CheckWellFormed parses the whole document and fails on the first syntax
+// error, e.g. a truncated document or an unclosed element. Unlike the
+// lenient decoder used for detection, undefined entities are errors too.
+func (v *Validator) CheckWellFormed(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	hasRoot := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return i18n.Errorf(v.Lang, "XML ist nicht wohlgeformt: %v", err)
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			hasRoot = true
+		}
+	}
+
+	if !hasRoot {
+		return i18n.Errorf(v.Lang, "kein Wurzelelement gefunden")
+	}
+	return nil
+}
@@ -0,0 +1,657 @@
+package validation
+
+import (
+	"encoding/xml"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"zugferd-extractor/internal/i18n"
+)
+
+// This file implements the subset of XML Schema needed to check the element
+// structure of CII documents: global and local element declarations, named
+// and inline complex types, sequence/choice/all/any particles, model groups
+// and complexContent extension/restriction. Simple types, facets and
+// attributes are not checked.
+
+const xsdNamespace = "http://www.w3.org/2001/XMLSchema"
+
+// xmlNode is a generic element tree used for both schemas and instances
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	scope    map[string]string // namespace prefixes in scope
+	children []*xmlNode
+}
+
+// attr returns the value of the unqualified attribute with the given name
+func (n *xmlNode) attr(name string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// qname resolves a prefixed attribute value like "ram:IDType" to a full name
+func (n *xmlNode) qname(value string) xml.Name {
+	prefix, local, found := strings.Cut(value, ":")
+	if !found {
+		return xml.Name{Space: n.scope[""], Local: value}
+	}
+	return xml.Name{Space: n.scope[prefix], Local: local}
+}
+
+// parseTree reads an XML document into an element tree; errors are in
+// lang
+func parseTree(r io.Reader, lang string) (*xmlNode, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var root *xmlNode
+	var stack []*xmlNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name, attrs: t.Attr, scope: map[string]string{}}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				for prefix, uri := range parent.scope {
+					node.scope[prefix] = uri
+				}
+				parent.children = append(parent.children, node)
+			} else {
+				root = node
+			}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					node.scope[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					node.scope[""] = a.Value
+				}
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	if root == nil {
+		return nil, i18n.Errorf(lang, "kein Wurzelelement gefunden")
+	}
+	return root, nil
+}
+
+type particleKind int
+
+const (
+	particleElement particleKind = iota
+	particleSequence
+	particleChoice
+	particleAll
+	particleAny
+)
+
+// particle is one node of a complex type's content model
+type particle struct {
+	kind    particleKind
+	min     int
+	max     int // -1 means unbounded
+	element *elementDecl
+	items   []*particle
+}
+
+// elementDecl is a compiled element declaration
+type elementDecl struct {
+	name xml.Name
+	typ  *complexType
+}
+
+// complexType is a compiled type; simple types are complex types without
+// element content
+type complexType struct {
+	simple     bool
+	anyContent bool
+	content    *particle
+}
+
+var (
+	simpleContentType = &complexType{simple: true}
+	anyContentType    = &complexType{anyContent: true}
+)
+
+// schemaDoc holds the per-file settings that affect local declarations
+type schemaDoc struct {
+	targetNamespace string
+	qualified       bool
+}
+
+// schemaDef is a global schema component together with its defining file
+type schemaDef struct {
+	node *xmlNode
+	doc  *schemaDoc
+}
+
+// schema is a set of loaded schema files with lazily compiled components
+type schema struct {
+	fsys         fs.FS
+	loaded       map[string]bool
+	elementDefs  map[xml.Name]schemaDef
+	typeDefs     map[xml.Name]schemaDef
+	simpleDefs   map[xml.Name]bool
+	groupDefs    map[xml.Name]schemaDef
+	elements     map[xml.Name]*elementDecl
+	types        map[xml.Name]*complexType
+	rootElements []*elementDecl
+
+	// lang is the language of the errors of loading and compiling
+	lang string
+}
+
+// loadSchema reads the schema file rootFile and all files it includes or
+// imports from fsys and compiles the global element declarations; errors
+// are in lang
+func loadSchema(fsys fs.FS, rootFile, lang string) (*schema, error) {
+	s := &schema{
+		fsys:        fsys,
+		loaded:      map[string]bool{},
+		elementDefs: map[xml.Name]schemaDef{},
+		typeDefs:    map[xml.Name]schemaDef{},
+		simpleDefs:  map[xml.Name]bool{},
+		groupDefs:   map[xml.Name]schemaDef{},
+		elements:    map[xml.Name]*elementDecl{},
+		types:       map[xml.Name]*complexType{},
+		lang:        lang,
+	}
+
+	if err := s.loadFile(rootFile); err != nil {
+		return nil, err
+	}
+
+	for name := range s.elementDefs {
+		decl, err := s.globalElement(name)
+		if err != nil {
+			return nil, err
+		}
+		s.rootElements = append(s.rootElements, decl)
+	}
+	return s, nil
+}
+
+// loadFile registers the global components of one schema file
+func (s *schema) loadFile(file string) error {
+	if s.loaded[file] {
+		return nil
+	}
+	s.loaded[file] = true
+
+	f, err := s.fsys.Open(file)
+	if err != nil {
+		return i18n.Errorf(s.lang, "Schemadatei nicht gefunden: %s", file)
+	}
+	defer f.Close()
+
+	root, err := parseTree(f, s.lang)
+	if err != nil {
+		return i18n.Errorf(s.lang, "Schemadatei %s konnte nicht gelesen werden: %v", file, err)
+	}
+	if root.name.Space != xsdNamespace || root.name.Local != "schema" {
+		return i18n.Errorf(s.lang, "%s ist kein XML-Schema", file)
+	}
+
+	doc := &schemaDoc{}
+	doc.targetNamespace, _ = root.attr("targetNamespace")
+	form, _ := root.attr("elementFormDefault")
+	doc.qualified = form == "qualified"
+
+	for _, child := range root.children {
+		if child.name.Space != xsdNamespace {
+			continue
+		}
+		name, _ := child.attr("name")
+		qualifiedName := xml.Name{Space: doc.targetNamespace, Local: name}
+
+		switch child.name.Local {
+		case "include", "import":
+			location, ok := child.attr("schemaLocation")
+			if !ok {
+				continue
+			}
+			if err := s.loadFile(path.Join(path.Dir(file), location)); err != nil {
+				return err
+			}
+		case "element":
+			s.elementDefs[qualifiedName] = schemaDef{child, doc}
+		case "complexType":
+			s.typeDefs[qualifiedName] = schemaDef{child, doc}
+		case "simpleType":
+			s.simpleDefs[qualifiedName] = true
+		case "group":
+			s.groupDefs[qualifiedName] = schemaDef{child, doc}
+		}
+	}
+	return nil
+}
+
+// globalElement returns the compiled global element declaration
+func (s *schema) globalElement(name xml.Name) (*elementDecl, error) {
+	if decl, ok := s.elements[name]; ok {
+		return decl, nil
+	}
+	def, ok := s.elementDefs[name]
+	if !ok {
+		return nil, i18n.Errorf(s.lang, "Element %s ist im Schema nicht definiert", formatName(name))
+	}
+
+	decl := &elementDecl{name: name}
+	s.elements[name] = decl
+	typ, err := s.elementType(def)
+	if err != nil {
+		return nil, err
+	}
+	decl.typ = typ
+	return decl, nil
+}
+
+// elementType resolves the type of an element declaration
+func (s *schema) elementType(def schemaDef) (*complexType, error) {
+	if typeName, ok := def.node.attr("type"); ok {
+		return s.namedType(def.node.qname(typeName))
+	}
+	for _, child := range def.node.children {
+		switch child.name.Local {
+		case "complexType":
+			typ := &complexType{}
+			return typ, s.fillType(typ, schemaDef{child, def.doc})
+		case "simpleType":
+			return simpleContentType, nil
+		}
+	}
+	return anyContentType, nil
+}
+
+// namedType returns the compiled global type with the given name
+func (s *schema) namedType(name xml.Name) (*complexType, error) {
+	if name.Space == xsdNamespace {
+		if name.Local == "anyType" {
+			return anyContentType, nil
+		}
+		return simpleContentType, nil
+	}
+	if typ, ok := s.types[name]; ok {
+		return typ, nil
+	}
+	if s.simpleDefs[name] {
+		return simpleContentType, nil
+	}
+	def, ok := s.typeDefs[name]
+	if !ok {
+		return nil, i18n.Errorf(s.lang, "Typ %s ist im Schema nicht definiert", formatName(name))
+	}
+
+	// Register before filling so recursive types resolve
+	typ := &complexType{}
+	s.types[name] = typ
+	return typ, s.fillType(typ, def)
+}
+
+// fillType compiles the content model of a complexType definition
+func (s *schema) fillType(typ *complexType, def schemaDef) error {
+	for _, child := range def.node.children {
+		switch child.name.Local {
+		case "sequence", "choice", "all", "group":
+			p, err := s.compileParticle(schemaDef{child, def.doc})
+			if err != nil {
+				return err
+			}
+			typ.content = p
+		case "simpleContent":
+			typ.simple = true
+		case "complexContent":
+			for _, derivation := range child.children {
+				if derivation.name.Local != "extension" && derivation.name.Local != "restriction" {
+					continue
+				}
+				var own *particle
+				for _, c := range derivation.children {
+					switch c.name.Local {
+					case "sequence", "choice", "all", "group":
+						p, err := s.compileParticle(schemaDef{c, def.doc})
+						if err != nil {
+							return err
+						}
+						own = p
+					}
+				}
+				if derivation.name.Local == "restriction" {
+					typ.content = own
+					continue
+				}
+
+				baseName, _ := derivation.attr("base")
+				base, err := s.namedType(derivation.qname(baseName))
+				if err != nil {
+					return err
+				}
+				switch {
+				case base.anyContent:
+					typ.anyContent = true
+				case base.content == nil:
+					typ.content = own
+				case own == nil:
+					typ.content = base.content
+				default:
+					typ.content = &particle{kind: particleSequence, min: 1, max: 1, items: []*particle{base.content, own}}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// compileParticle compiles an element, model group or wildcard
+func (s *schema) compileParticle(def schemaDef) (*particle, error) {
+	node := def.node
+	p := &particle{min: 1, max: 1}
+	if value, ok := node.attr("minOccurs"); ok {
+		p.min, _ = strconv.Atoi(value)
+	}
+	if value, ok := node.attr("maxOccurs"); ok {
+		if value == "unbounded" {
+			p.max = -1
+		} else {
+			p.max, _ = strconv.Atoi(value)
+		}
+	}
+
+	switch node.name.Local {
+	case "element":
+		p.kind = particleElement
+		if ref, ok := node.attr("ref"); ok {
+			decl, err := s.globalElement(node.qname(ref))
+			if err != nil {
+				return nil, err
+			}
+			p.element = decl
+			return p, nil
+		}
+
+		name, _ := node.attr("name")
+		decl := &elementDecl{name: xml.Name{Local: name}}
+		form, _ := node.attr("form")
+		if form == "qualified" || (form == "" && def.doc.qualified) {
+			decl.name.Space = def.doc.targetNamespace
+		}
+		typ, err := s.elementType(def)
+		if err != nil {
+			return nil, err
+		}
+		decl.typ = typ
+		p.element = decl
+	case "any":
+		p.kind = particleAny
+	case "group":
+		ref, _ := node.attr("ref")
+		groupDef, ok := s.groupDefs[node.qname(ref)]
+		if !ok {
+			return nil, i18n.Errorf(s.lang, "Gruppe %s ist im Schema nicht definiert", ref)
+		}
+		for _, child := range groupDef.node.children {
+			switch child.name.Local {
+			case "sequence", "choice", "all":
+				inner, err := s.compileParticle(schemaDef{child, groupDef.doc})
+				if err != nil {
+					return nil, err
+				}
+				inner.min, inner.max = p.min, p.max
+				return inner, nil
+			}
+		}
+		return nil, i18n.Errorf(s.lang, "Gruppe %s enthält kein Inhaltsmodell", ref)
+	default:
+		switch node.name.Local {
+		case "sequence":
+			p.kind = particleSequence
+		case "choice":
+			p.kind = particleChoice
+		case "all":
+			p.kind = particleAll
+		}
+		for _, child := range node.children {
+			switch child.name.Local {
+			case "element", "sequence", "choice", "all", "group", "any":
+				item, err := s.compileParticle(schemaDef{child, def.doc})
+				if err != nil {
+					return nil, err
+				}
+				p.items = append(p.items, item)
+			}
+		}
+	}
+	return p, nil
+}
+
+// schemaRun collects the violations of one instance validation
+type schemaRun struct {
+	violations []string
+	limit      int
+	lang       string
+}
+
+func (r *schemaRun) report(format string, args ...any) {
+	if len(r.violations) < r.limit {
+		r.violations = append(r.violations, i18n.Sprintf(r.lang, format, args...))
+	}
+}
+
+// validate checks an instance tree against the schema; violations are
+// reported in lang
+func (s *schema) validate(root *xmlNode, limit int, lang string) []string {
+	run := &schemaRun{limit: limit, lang: lang}
+
+	for _, decl := range s.rootElements {
+		if decl.name == root.name {
+			run.element(root, decl, "/"+root.name.Local)
+			return run.violations
+		}
+	}
+
+	run.report("Wurzelelement %s ist im Schema nicht definiert", formatName(root.name))
+	return run.violations
+}
+
+// element validates the children of node against its declaration
+func (r *schemaRun) element(node *xmlNode, decl *elementDecl, elementPath string) {
+	typ := decl.typ
+	switch {
+	case typ.anyContent:
+		return
+	case typ.simple || typ.content == nil:
+		if len(node.children) > 0 {
+			r.report("%s: unerwartetes Kindelement %s", elementPath, node.children[0].name.Local)
+		}
+		return
+	}
+
+	pos := r.match(typ.content, node.children, 0, elementPath)
+	if pos < len(node.children) {
+		r.report("%s: unerwartetes Element %s", elementPath, node.children[pos].name.Local)
+	}
+}
+
+// match consumes the children matched by p starting at pos and returns the
+// new position; missing required content is reported
+func (r *schemaRun) match(p *particle, children []*xmlNode, pos int, elementPath string) int {
+	count := 0
+	for p.max < 0 || count < p.max {
+		next, ok := r.matchOnce(p, children, pos, elementPath)
+		if !ok {
+			break
+		}
+		pos = next
+		count++
+	}
+
+	if count < p.min && !(count == 0 && contentEmptiable(p)) {
+		r.report("%s: %s fehlt", elementPath, r.describe(p))
+	}
+	return pos
+}
+
+// matchOnce matches a single occurrence of p
+func (r *schemaRun) matchOnce(p *particle, children []*xmlNode, pos int, elementPath string) (int, bool) {
+	if pos >= len(children) {
+		return pos, false
+	}
+	child := children[pos]
+
+	switch p.kind {
+	case particleElement:
+		if child.name != p.element.name {
+			return pos, false
+		}
+		r.element(child, p.element, elementPath+"/"+child.name.Local)
+		return pos + 1, true
+	case particleAny:
+		return pos + 1, true
+	case particleSequence:
+		if !canStart(p, child.name) {
+			return pos, false
+		}
+		for _, item := range p.items {
+			pos = r.match(item, children, pos, elementPath)
+		}
+		return pos, true
+	case particleChoice:
+		for _, item := range p.items {
+			if canStart(item, child.name) {
+				return r.match(item, children, pos, elementPath), true
+			}
+		}
+		return pos, false
+	case particleAll:
+		used := make([]bool, len(p.items))
+		start := pos
+		for pos < len(children) {
+			progressed := false
+			for i, item := range p.items {
+				if !used[i] && canStart(item, children[pos].name) {
+					used[i] = true
+					pos = r.match(item, children, pos, elementPath)
+					progressed = true
+					break
+				}
+			}
+			if !progressed {
+				break
+			}
+		}
+		if pos == start {
+			return pos, false
+		}
+		for i, item := range p.items {
+			if !used[i] && item.min > 0 && !contentEmptiable(item) {
+				r.report("%s: %s fehlt", elementPath, r.describe(item))
+			}
+		}
+		return pos, true
+	}
+	return pos, false
+}
+
+// canStart reports whether an element with the given name can begin p
+func canStart(p *particle, name xml.Name) bool {
+	switch p.kind {
+	case particleElement:
+		return p.element.name == name
+	case particleAny:
+		return true
+	case particleSequence:
+		for _, item := range p.items {
+			if canStart(item, name) {
+				return true
+			}
+			if !emptiable(item) {
+				return false
+			}
+		}
+	case particleChoice, particleAll:
+		for _, item := range p.items {
+			if canStart(item, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// emptiable reports whether p may match no elements at all
+func emptiable(p *particle) bool {
+	return p.min == 0 || contentEmptiable(p)
+}
+
+// contentEmptiable reports whether one occurrence of p may be empty
+func contentEmptiable(p *particle) bool {
+	switch p.kind {
+	case particleSequence, particleAll:
+		for _, item := range p.items {
+			if !emptiable(item) {
+				return false
+			}
+		}
+		return true
+	case particleChoice:
+		for _, item := range p.items {
+			if emptiable(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// describe names the content that p expects for error messages
+func (r *schemaRun) describe(p *particle) string {
+	switch p.kind {
+	case particleElement:
+		return i18n.Sprintf(r.lang, "Element %s", p.element.name.Local)
+	case particleAny:
+		return i18n.T(r.lang, "ein beliebiges Element")
+	case particleSequence, particleAll:
+		for _, item := range p.items {
+			if !emptiable(item) {
+				return r.describe(item)
+			}
+		}
+	case particleChoice:
+		var names []string
+		for _, item := range p.items {
+			names = append(names, r.describe(item))
+		}
+		return i18n.Sprintf(r.lang, "eines von (%s)", strings.Join(names, ", "))
+	}
+	return i18n.T(r.lang, "Inhalt")
+}
+
+// formatName formats an expanded name as {namespace}local
+func formatName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}